@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/auditevents"
+	"github.com/spf13/cobra"
+)
+
+// InitAuditEventsCmd initializes the audit-events command for the gitlabctl CLI. This command sets up the flags for the
+// command, parsing the provided scope, time bounds and cursor file before passing them to the auditevents package for
+// enumeration.
+func (a *Gitlabctl) InitAuditEventsCmd() {
+	options := auditevents.EnumerateAuditEventsOptions{
+		GroupID:    "",
+		ProjectID:  "",
+		CursorFile: "",
+	}
+	since := ""
+	until := ""
+
+	a.AuditEventsCmd = &cobra.Command{
+		Use:         "audit-events",
+		Short:       "Export Gitlab audit events",
+		Long:        `Export Gitlab audit events for the instance, a group or a project, optionally resuming from a cursor file so only new events are returned`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if since != "" {
+				options.Since, err = auditevents.ParseTime(since)
+			}
+			if err == nil && until != "" {
+				options.Until, err = auditevents.ParseTime(until)
+			}
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			a.Metadata.SetOptions(options)
+			report, err := auditevents.EnumerateAuditEvents(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.AuditEventsCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path. Collects group audit events.")
+	a.AuditEventsCmd.Flags().StringVar(&options.ProjectID, "project", "", "Project ID or full path. Collects project audit events.")
+	a.AuditEventsCmd.Flags().StringVar(&since, "since", "", "Only return events created after this time (RFC 3339 or YYYY-MM-DD)")
+	a.AuditEventsCmd.Flags().StringVar(&until, "until", "", "Only return events created before this time (RFC 3339 or YYYY-MM-DD)")
+	a.AuditEventsCmd.Flags().StringVar(&options.CursorFile, "cursor-file", "", "Path to a cursor file. Only events newer than the cursor are returned, and the cursor is advanced after a successful run.")
+	a.AuditEventsCmd.MarkFlagsMutuallyExclusive("group-id", "project")
+
+	a.RootCmd.AddCommand(a.AuditEventsCmd)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Method-Security/gitlabctl/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// InitConfigCmd initializes the config command for the gitlabctl CLI. Its subcommands list and validate the profiles in
+// the config file without contacting Gitlab, so they need neither a token nor a base URL.
+func (a *Gitlabctl) InitConfigCmd() {
+	a.ConfigCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the gitlabctl config file and its profiles",
+		Long:  `Inspect the gitlabctl config file and its profiles`,
+	}
+
+	a.ConfigListCmd = &cobra.Command{
+		Use:         "list",
+		Short:       "List the profiles in the config file",
+		Long:        `List the profiles in the config file, showing where each profile's token comes from without revealing it`,
+		Annotations: map[string]string{skipClientAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			a.runConfigCmd(false)
+		},
+	}
+
+	a.ConfigValidateCmd = &cobra.Command{
+		Use:         "validate",
+		Short:       "Validate the profiles in the config file",
+		Long:        `Validate the profiles in the config file, failing if any profile is invalid or the selected profile does not exist`,
+		Annotations: map[string]string{skipClientAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			a.runConfigCmd(true)
+		},
+	}
+
+	a.ConfigCmd.AddCommand(a.ConfigListCmd)
+	a.ConfigCmd.AddCommand(a.ConfigValidateCmd)
+	a.RootCmd.AddCommand(a.ConfigCmd)
+}
+
+func (a *Gitlabctl) runConfigCmd(validate bool) {
+	configPath, required := config.ConfigPath(a.RootFlags.ConfigFile, os.Getenv)
+	file, err := config.LoadFile(configPath, required)
+	if err != nil {
+		errorMessage := err.Error()
+		a.OutputSignal.ErrorMessage = &errorMessage
+		a.OutputSignal.Status = 1
+		return
+	}
+
+	selected := file.SelectedProfile(a.RootFlags.Profile, os.Getenv)
+	report := config.SummarizeProfiles(configPath, file, selected, validate, a.isFlag)
+	if validate && len(report.Errors) > 0 {
+		errorMessage := "invalid config file " + configPath
+		a.OutputSignal.ErrorMessage = &errorMessage
+		a.OutputSignal.Status = 1
+	}
+	a.OutputSignal.Content = &report
+}
+
+// isFlag reports whether name is a flag of any gitlabctl command.
+func (a *Gitlabctl) isFlag(name string) bool {
+	found := false
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		check := func(flag *pflag.Flag) {
+			if flag.Name == name {
+				found = true
+			}
+		}
+		cmd.LocalFlags().VisitAll(check)
+		cmd.PersistentFlags().VisitAll(check)
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(a.RootCmd)
+	return found
+}
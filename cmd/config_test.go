@@ -0,0 +1,191 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/fakegitlab"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProfile(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	configFile := writeConfig(t, fmt.Sprintf(`
+default_profile: fake
+profiles:
+  fake:
+    base_url: %s/api/v4
+    token: profile-token
+    defaults:
+      mine: "false"
+`, srv.URL))
+
+	data := execute(t, []string{"projects"}, "--config", configFile)
+	out := result{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if got := length(t, out.Content, "resources", "projects"); got != 3 {
+		t.Errorf("%d projects, want 3 as the profile defaults --mine to false", got)
+	}
+	if got := server.Requests()[0].Header.Get("PRIVATE-TOKEN"); got != "profile-token" {
+		t.Errorf("token = %q, want the profile token", got)
+	}
+
+	execute(t, []string{"projects"}, "--config", configFile, "--token", "flag-token")
+	requests := server.Requests()
+	if got := requests[len(requests)-1].Header.Get("PRIVATE-TOKEN"); got != "flag-token" {
+		t.Errorf("token = %q, want --token to take precedence over the profile", got)
+	}
+
+	// GITLAB_TOKEN takes precedence over the default profile, but not over a profile selected explicitly
+	executeWithEnv(t, map[string]string{"GITLAB_TOKEN": "env-token"}, []string{"projects"}, "--config", configFile)
+	requests = server.Requests()
+	if got := requests[len(requests)-1].Header.Get("PRIVATE-TOKEN"); got != "env-token" {
+		t.Errorf("token = %q, want GITLAB_TOKEN to take precedence over the default profile", got)
+	}
+	for _, env := range []map[string]string{
+		{"GITLAB_TOKEN": "env-token"},
+		{"GITLAB_TOKEN": "env-token", "GITLABCTL_PROFILE": "fake"},
+	} {
+		flags := []string{"--config", configFile}
+		if env["GITLABCTL_PROFILE"] == "" {
+			flags = append(flags, "--profile", "fake")
+		}
+		executeWithEnv(t, env, []string{"projects"}, flags...)
+		requests = server.Requests()
+		if got := requests[len(requests)-1].Header.Get("PRIVATE-TOKEN"); got != "profile-token" {
+			t.Errorf("token = %q, want the selected profile to take precedence over GITLAB_TOKEN", got)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	configFile := writeConfig(t, `
+default_profile: saas
+profiles:
+  saas:
+    base_url: https://gitlab.com/api/v4
+    token_env: SAAS_TOKEN
+  broken:
+    token: t
+    defaults:
+      outptu: json
+`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStatus int
+		wantErrors int
+	}{
+		{name: "Test List", args: []string{"config", "list"}, wantStatus: 0, wantErrors: 0},
+		{name: "Test Validate", args: []string{"config", "validate"}, wantStatus: 1, wantErrors: 1},
+		{name: "Test Validate Unknown Profile", args: []string{"config", "validate", "--profile", "missing"}, wantStatus: 1, wantErrors: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := result{}
+			if err := json.Unmarshal(execute(t, tt.args, "--config", configFile), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", out.Status, tt.wantStatus)
+			}
+			if got := length(t, out.Content, "errors"); got != tt.wantErrors {
+				t.Errorf("%d errors (%v), want %d", got, lookup(t, out.Content, "errors"), tt.wantErrors)
+			}
+			if got := length(t, out.Content, "resources", "profiles"); got != 2 {
+				t.Errorf("%d profiles, want 2", got)
+			}
+			if got := lookup(t, out.Content, "resources", "profiles", 1, "token_source"); got != "env:SAAS_TOKEN" {
+				t.Errorf("token_source = %v, want env:SAAS_TOKEN", got)
+			}
+		})
+	}
+}
+
+func TestProfileAuth(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	configFile := writeConfig(t, fmt.Sprintf(`
+profiles:
+  job:
+    base_url: %[1]s/api/v4
+    auth: job_token
+  oauth:
+    base_url: %[1]s/api/v4
+    auth: oauth
+    token_command: echo oauth-token
+`, srv.URL))
+
+	tests := []struct {
+		name       string
+		profile    string
+		flags      []string
+		env        map[string]string
+		header     string
+		wantHeader string
+	}{
+		{
+			name:       "Test Job Token",
+			profile:    "job",
+			env:        map[string]string{"CI_JOB_TOKEN": "job-token"},
+			header:     "JOB-TOKEN",
+			wantHeader: "job-token",
+		},
+		{
+			name:       "Test OAuth",
+			profile:    "oauth",
+			header:     "Authorization",
+			wantHeader: "Bearer oauth-token",
+		},
+		{
+			name:       "Test Token Flag With OAuth Profile",
+			profile:    "oauth",
+			flags:      []string{"--token", "flag-token"},
+			header:     "PRIVATE-TOKEN",
+			wantHeader: "flag-token",
+		},
+		{
+			name:       "Test Job Token Profile With Token Env",
+			profile:    "job",
+			env:        map[string]string{"CI_JOB_TOKEN": "job-token", "GITLAB_TOKEN": "env-token"},
+			header:     "JOB-TOKEN",
+			wantHeader: "job-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executeWithEnv(t, tt.env, []string{"whoami"}, append([]string{"--config", configFile, "--profile", tt.profile}, tt.flags...)...)
+			requests := server.Requests()
+			last := requests[len(requests)-1]
+			for _, header := range []string{"PRIVATE-TOKEN", "JOB-TOKEN", "Authorization"} {
+				want := ""
+				if header == tt.header {
+					want = tt.wantHeader
+				}
+				if got := last.Header.Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
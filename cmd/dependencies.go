@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/Method-Security/gitlabctl/internal/dependencies"
+	"github.com/palantir/witchcraft-go-logging/wlog/svclog/svc1log"
+	"github.com/spf13/cobra"
+)
+
+// InitDependenciesCmd initializes the dependencies command for the gitlabctl CLI. This command sets up the flags for the
+// command, parsing the provided project or group ID before passing them to the dependencies package for enumeration.
+// When --cyclonedx is set the command overrides the root PersistentPostRunE, writing a CycloneDX SBOM in place of the
+// gitlabctl report. The SBOM cannot be streamed, so the command rejects --cyclonedx with the ndjson output format before
+// the root PersistentPreRunE creates the output file.
+func (a *Gitlabctl) InitDependenciesCmd() {
+	options := dependencies.EnumerateDependenciesOptions{
+		ProjectID: "",
+		GroupID:   "",
+	}
+	cyclonedx := false
+	checkpointFile := ""
+
+	a.DependenciesCmd = &cobra.Command{
+		Use:         "dependencies",
+		Short:       "Export Gitlab project dependency lists",
+		Long:        `Export the dependency lists produced by Gitlab dependency scanning for a project or every project in a group, as a gitlabctl report or a CycloneDX 1.5 JSON SBOM`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			options.Checkpoint, err = a.openCheckpoint(checkpointFile, options.GroupID, "dependencies")
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			a.Metadata.SetOptions(options)
+			report, err := dependencies.EnumerateDependencies(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.closeCheckpoint(options.Checkpoint, err == nil && len(report.Errors) == 0)
+			a.OutputSignal.Content = report
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if output, _ := cmd.Flags().GetString("output"); cyclonedx && strings.EqualFold(output, "ndjson") {
+				return errors.New("--cyclonedx cannot be combined with the ndjson output format")
+			}
+			return a.RootCmd.PersistentPreRunE(cmd, args)
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if !cyclonedx {
+				return a.RootCmd.PersistentPostRunE(cmd, args)
+			}
+			return a.finishRun(cmd, func() error {
+				return a.writeCycloneDX(cmd)
+			})
+		},
+	}
+	a.DependenciesCmd.Flags().StringVar(&options.ProjectID, "project", "", "Project ID or full path")
+	a.DependenciesCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path")
+	a.DependenciesCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", checkpointUsage)
+	a.DependenciesCmd.Flags().BoolVar(&cyclonedx, "cyclonedx", false, "Write a CycloneDX 1.5 JSON SBOM instead of the gitlabctl report. The --output format is ignored, and cannot be ndjson.")
+	a.DependenciesCmd.MarkFlagsMutuallyExclusive("project", "group-id")
+	a.DependenciesCmd.MarkFlagsOneRequired("project", "group-id")
+
+	a.RootCmd.AddCommand(a.DependenciesCmd)
+}
+
+// writeCycloneDX writes the dependencies report held in the output signal as a CycloneDX SBOM to the configured output
+// file, or STDOUT. Non-fatal errors have no place in the SBOM, so they are logged instead.
+func (a *Gitlabctl) writeCycloneDX(cmd *cobra.Command) error {
+	if a.OutputSignal.ErrorMessage != nil {
+		return errors.New(*a.OutputSignal.ErrorMessage)
+	}
+	report, ok := a.OutputSignal.Content.(*dependencies.GitlabResourceReport)
+	if !ok {
+		return errors.New("no dependencies report to write")
+	}
+	for _, reportErr := range report.Errors {
+		svc1log.FromContext(cmd.Context()).Warn("Non-fatal error during dependency enumeration", svc1log.SafeParam("error", reportErr))
+	}
+
+	bom, err := dependencies.NewBOM(report, a.Version)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return err
+	}
+	if a.OutputConfig.FilePath != nil {
+		return os.WriteFile(*a.OutputConfig.FilePath, data, 0644)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
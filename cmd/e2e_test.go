@@ -0,0 +1,910 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/cmd"
+	"github.com/Method-Security/gitlabctl/internal/fakegitlab"
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"gopkg.in/yaml.v2"
+)
+
+const fixtureDir = "../testdata/gitlab"
+
+// result is the signal written by a command run with --output json.
+type result struct {
+	Content      map[string]interface{} `json:"content"`
+	Status       int                    `json:"status"`
+	ErrorMessage *string                `json:"error_message"`
+}
+
+func newGitlabctl() *cmd.Gitlabctl {
+	gitlabctl := cmd.NewGitlabctl("test")
+	gitlabctl.InitRootCommand()
+	gitlabctl.InitProjectsCmd()
+	gitlabctl.InitVulnerabilityCmd()
+	gitlabctl.InitWhoamiCmd()
+	gitlabctl.InitUsersCmd()
+	gitlabctl.InitGroupsCmd()
+	gitlabctl.InitAuditEventsCmd()
+	gitlabctl.InitRegistryCmd()
+	gitlabctl.InitPackagesCmd()
+	gitlabctl.InitDependenciesCmd()
+	gitlabctl.InitLicensesCmd()
+	gitlabctl.InitConfigCmd()
+	return gitlabctl
+}
+
+// execute runs gitlabctl with args followed by the given root flags, returning the raw output file contents.
+func execute(t *testing.T, args []string, rootFlags ...string) []byte {
+	t.Helper()
+	return executeWithEnv(t, nil, args, rootFlags...)
+}
+
+// executeWithEnv runs gitlabctl like execute, isolated from the caller's gitlabctl environment variables and config
+// file, with only the given environment variables set.
+func executeWithEnv(t *testing.T, env map[string]string, args []string, rootFlags ...string) []byte {
+	t.Helper()
+	for _, name := range []string{"GITLAB_TOKEN", "CI_JOB_TOKEN", "GITLABCTL_PROFILE", "GITLABCTL_CONFIG"} {
+		t.Setenv(name, env[name])
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	outputFile := filepath.Join(t.TempDir(), "output.json")
+	gitlabctl := newGitlabctl()
+	gitlabctl.RootCmd.SetArgs(append(append(args, rootFlags...), "--quiet", "--output", "json", "--output-file", outputFile))
+	if err := gitlabctl.RootCmd.Execute(); err != nil {
+		t.Fatalf("gitlabctl %s: %v", strings.Join(args, " "), err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// run runs gitlabctl against srv and returns the signal it wrote.
+func run(t *testing.T, baseURL string, args ...string) result {
+	t.Helper()
+	data := execute(t, args, "--base-url", baseURL+"/api/v4", "--token", "test-token")
+	out := result{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid output %s: %v", data, err)
+	}
+	return out
+}
+
+// lookup walks a path of map keys and slice indexes through decoded JSON.
+func lookup(t *testing.T, value interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				t.Fatalf("lookup %v: %v is not an object", path, value)
+			}
+			value = m[k]
+		case int:
+			s, ok := value.([]interface{})
+			if !ok || k >= len(s) {
+				t.Fatalf("lookup %v: %v has no index %d", path, value, k)
+			}
+			value = s[k]
+		}
+	}
+	return value
+}
+
+func length(t *testing.T, value interface{}, path ...interface{}) int {
+	t.Helper()
+	s, ok := lookup(t, value, path...).([]interface{})
+	if !ok {
+		return 0
+	}
+	return len(s)
+}
+
+func errorsContain(t *testing.T, out result, substring string) bool {
+	t.Helper()
+	for _, e := range lookup(t, out.Content, "errors").([]interface{}) {
+		if strings.Contains(e.(string), substring) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCommands(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		args      []string
+		resources string
+		wantCount int
+		wantError string
+	}{
+		{name: "Test Projects", args: []string{"projects", "--mine=false"}, resources: "projects", wantCount: 3},
+		{name: "Test Group Projects", args: []string{"projects", "--group-id", "10"}, resources: "projects", wantCount: 3},
+		{name: "Test Vulnerabilities", args: []string{"vulnerabilities", "--project", "1"}, resources: "vulnerabilities", wantCount: 3},
+		{name: "Test Vulnerabilities Resolved", args: []string{"vulnerabilities", "--project", "1", "--states", "resolved"}, resources: "vulnerabilities", wantCount: 1},
+		{name: "Test Users", args: []string{"users"}, resources: "users", wantCount: 3, wantError: "failed to list SSH keys for user bob"},
+		{name: "Test Groups", args: []string{"groups"}, resources: "groups", wantCount: 3},
+		{name: "Test Audit Events", args: []string{"audit-events", "--group-id", "10"}, resources: "events", wantCount: 2},
+		{name: "Test Registry", args: []string{"registry", "--project", "1"}, resources: "repositories", wantCount: 1},
+		{name: "Test Group Registry", args: []string{"registry", "--group-id", "10"}, resources: "repositories", wantCount: 1},
+		{name: "Test Packages", args: []string{"packages", "--group-id", "10"}, resources: "packages", wantCount: 3},
+		{name: "Test Dependencies", args: []string{"dependencies", "--project", "1"}, resources: "projects", wantCount: 1},
+		{name: "Test Group Dependencies", args: []string{"dependencies", "--group-id", "10"}, resources: "projects", wantCount: 2, wantError: "403"},
+		{name: "Test Licenses", args: []string{"licenses", "--group-id", "10"}, resources: "projects", wantCount: 2, wantError: "403"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := run(t, srv.URL, tt.args...)
+			if out.Status != 0 {
+				t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+			}
+			if got := length(t, out.Content, "resources", tt.resources); got != tt.wantCount {
+				t.Errorf("%d %s, want %d", got, tt.resources, tt.wantCount)
+			}
+			errs := length(t, out.Content, "errors")
+			if tt.wantError == "" && errs != 0 {
+				t.Errorf("unexpected errors: %v", lookup(t, out.Content, "errors"))
+			}
+			if tt.wantError != "" && !errorsContain(t, out, tt.wantError) {
+				t.Errorf("errors %v do not contain %q", lookup(t, out.Content, "errors"), tt.wantError)
+			}
+		})
+	}
+}
+
+func TestWhoami(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "whoami")
+	if out.Status != 0 {
+		t.Fatalf("status = %d, want 0", out.Status)
+	}
+	if got := lookup(t, out.Content, "resources", "user", "username"); got != "root" {
+		t.Errorf("username = %v, want root", got)
+	}
+}
+
+func TestVulnerabilitiesSummary(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "vulnerabilities", "--project", "1", "--summary", "--fail-on", "critical")
+	if out.Status != 1 || out.ErrorMessage == nil || !strings.Contains(*out.ErrorMessage, "1 unsuppressed vulnerabilities") {
+		t.Errorf("status = %d (%v), want a critical threshold failure", out.Status, out.ErrorMessage)
+	}
+	if got := length(t, out.Content, "resources", "vulnerabilities"); got != 0 {
+		t.Errorf("%d vulnerabilities listed in summary mode, want 0", got)
+	}
+	if got := lookup(t, out.Content, "summary", "counts", "total"); got != float64(3) {
+		t.Errorf("total = %v, want 3", got)
+	}
+}
+
+func TestVulnerabilitiesGroupSummary(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "vulnerabilities", "--group-id", "10", "--summary")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	byProject := lookup(t, out.Content, "summary", "counts", "by_project").(map[string]interface{})
+	if len(byProject) != 2 || byProject["acme/api"] != float64(3) || byProject["acme/platform/infra/terraform"] != float64(1) {
+		t.Errorf("by_project = %v, want the open vulnerabilities of both projects that have any", byProject)
+	}
+	// Project 2 has no vulnerabilities fixture
+	if !errorsContain(t, out, "404") {
+		t.Errorf("errors = %v, want the project without vulnerabilities", lookup(t, out.Content, "errors"))
+	}
+}
+
+func TestVulnerabilitiesSLA(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantSLA bool
+	}{
+		{name: "Test Default SLA", args: []string{}, wantSLA: true},
+		{name: "Test SLA Disabled", args: []string{"--sla", ""}, wantSLA: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := run(t, srv.URL, append([]string{"vulnerabilities", "--project", "1"}, tt.args...)...)
+			if out.Status != 0 {
+				t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+			}
+			for _, vuln := range lookup(t, out.Content, "resources", "vulnerabilities").([]interface{}) {
+				_, hasAge := vuln.(map[string]interface{})["age_days"]
+				if hasAge != tt.wantSLA {
+					t.Errorf("vulnerability has age_days = %v, want %v", hasAge, tt.wantSLA)
+				}
+			}
+		})
+	}
+}
+
+func TestVulnerabilitiesYAML(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	outputFile := filepath.Join(t.TempDir(), "output.yaml")
+	gitlabctl := newGitlabctl()
+	gitlabctl.RootCmd.SetArgs([]string{"vulnerabilities", "--project", "1", "--fixture-dir", fixtureDir, "--quiet",
+		"--output", "yaml", "--output-file", outputFile})
+	if err := gitlabctl.RootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := struct {
+		Content struct {
+			Resources struct {
+				Vulnerabilities []map[string]interface{} `yaml:"vulnerabilities"`
+			} `yaml:"resources"`
+		} `yaml:"content"`
+	}{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid output %s: %v", data, err)
+	}
+	vulns := out.Content.Resources.Vulnerabilities
+	if len(vulns) != 3 {
+		t.Fatalf("%d vulnerabilities, want 3", len(vulns))
+	}
+	// The Gitlab fields are inlined alongside gitlabctl's own
+	if _, nested := vulns[0]["projectvulnerability"]; nested || vulns[0]["id"] != 1001 || vulns[0]["severity"] != "critical" {
+		t.Errorf("vulnerability = %v, want the Gitlab fields inlined", vulns[0])
+	}
+}
+
+func TestVulnerabilitiesSetState(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "vulnerabilities", "set-state", "--project", "1", "--to", "dismissed", "--comment", "accepted",
+		"--dismissal-reason", "acceptable_risk", "--severities", "critical")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if got := length(t, out.Content, "resources", "changes"); got != 1 {
+		t.Fatalf("%d changes, want 1", got)
+	}
+	if got := lookup(t, out.Content, "resources", "changes", 0, "applied"); got != true {
+		t.Errorf("applied = %v, want true", got)
+	}
+
+	mutations := 0
+	for _, request := range server.Requests() {
+		if request.Path == "graphql" {
+			mutations++
+			if !strings.Contains(request.Body, "vulnerabilityDismiss") || !strings.Contains(request.Body, "ACCEPTABLE_RISK") {
+				t.Errorf("unexpected mutation %s", request.Body)
+			}
+		}
+	}
+	if mutations != 1 {
+		t.Errorf("%d mutations, want 1", mutations)
+	}
+}
+
+func TestVulnerabilitiesCreateIssues(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "vulnerabilities", "create-issues", "--project", "1", "--group-by", "identifier", "--labels", "security")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if got := length(t, out.Content, "resources", "issues"); got != 3 {
+		t.Fatalf("%d issues, want 3", got)
+	}
+
+	created := 0
+	for _, request := range server.Requests() {
+		if request.Method == http.MethodPost && request.Path == "projects/1/issues" {
+			created++
+			if !strings.Contains(request.Body, "gitlabctl-fingerprint:") {
+				t.Errorf("issue description has no fingerprint: %s", request.Body)
+			}
+		}
+	}
+	if created != 3 {
+		t.Errorf("%d issues created, want 3", created)
+	}
+}
+
+func TestVulnerabilitiesCreateIssuesLinksOnUpdate(t *testing.T) {
+	fingerprint := vulnerability.Fingerprint(1, vulnerability.IssueGroupingVulnerability, "vulnerability-1001")
+	cases := []struct {
+		name   string
+		state  string
+		linked string
+		links  int
+	}{
+		{"update links new vulnerabilities", "opened", `[]`, 1},
+		{"reopen links new vulnerabilities", "closed", `[]`, 1},
+		{"update skips linked vulnerabilities", "opened", `[{"id": "gid://gitlab/Vulnerability/1001"}]`, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, srv := fakegitlab.NewTestServer(fixtureDir)
+			defer srv.Close()
+			issues := fmt.Sprintf(`[{"id": 7100, "iid": 3, "project_id": 1, "state": %q, "description": "gitlabctl-fingerprint:%s"}]`, c.state, fingerprint)
+			server.Handle(http.MethodGet, "projects/1/issues", fakegitlab.Response{Body: json.RawMessage(issues)})
+			server.Handle(http.MethodPut, "projects/1/issues/3", fakegitlab.Response{Body: json.RawMessage(`{"id": 7100, "iid": 3}`)})
+			server.Handle(http.MethodPost, "graphql", fakegitlab.Response{Body: json.RawMessage(fmt.Sprintf(`{"data": {"result": {"errors": [], "relatedVulnerabilities": {"nodes": %s, "pageInfo": {"hasNextPage": false}}}}}`, c.linked))})
+
+			out := run(t, srv.URL, "vulnerabilities", "create-issues", "--project", "1")
+			if out.Status != 0 {
+				t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+			}
+
+			links := 0
+			for _, request := range server.Requests() {
+				if request.Path == "graphql" && strings.Contains(request.Body, "vulnerabilityIssueLinkCreate") && strings.Contains(request.Body, "gid://gitlab/Issue/7100") {
+					links++
+					if !strings.Contains(request.Body, "gid://gitlab/Vulnerability/1001") {
+						t.Errorf("link request does not link vulnerability 1001: %s", request.Body)
+					}
+				}
+			}
+			if links != c.links {
+				t.Errorf("%d link requests for the existing issue, want %d", links, c.links)
+			}
+		})
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "groups", "--group-id", "999")
+	if out.Status != 1 || out.ErrorMessage == nil || !strings.Contains(*out.ErrorMessage, "404") {
+		t.Errorf("status = %d (%v), want a 404 failure", out.Status, out.ErrorMessage)
+	}
+}
+
+func TestGroupsSubgroupSAMLGroupLinks(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	// acme/platform inherits the SAML group links of its top-level group acme
+	out := run(t, srv.URL, "groups", "--group-id", "11")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	groups := lookup(t, out.Content, "resources", "groups").([]interface{})
+	if len(groups) == 0 {
+		t.Fatal("no groups audited")
+	}
+	for _, group := range groups {
+		audit := group.(map[string]interface{})
+		if audit["saml_group_links"] != float64(1) || audit["saml_group_links_configured"] != true {
+			t.Errorf("group %v: saml_group_links = %v, configured = %v, want the top-level group's link", audit["full_path"], audit["saml_group_links"], audit["saml_group_links_configured"])
+		}
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	out := run(t, srv.URL, "dependencies", "--group-id", "10")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if got := length(t, out.Content, "error_details"); got != 1 {
+		t.Fatalf("%d error details, want 1", got)
+	}
+	detail := lookup(t, out.Content, "error_details", 0)
+	if lookup(t, detail, "class") != "forbidden" || lookup(t, detail, "http_status") != float64(403) || lookup(t, detail, "retryable") != false {
+		t.Errorf("error details = %v, want a forbidden error", detail)
+	}
+	if lookup(t, detail, "resource_kind") != "project" || lookup(t, detail, "message") != lookup(t, out.Content, "errors", 0) {
+		t.Errorf("error details = %v, want the project and the rendering listed in errors", detail)
+	}
+	if got := lookup(t, out.Content, "error_counts", "forbidden"); got != float64(1) {
+		t.Errorf("forbidden error count = %v, want 1", got)
+	}
+}
+
+func TestReportMetadata(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	server.Handle(http.MethodGet, "metadata",
+		fakegitlab.Response{Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "0"}},
+		fakegitlab.Response{Body: json.RawMessage(`{"version": "17.4.0-ee", "revision": "a1b2c3d", "enterprise": true}`)},
+	)
+
+	out := run(t, srv.URL, "projects", "--group-id", "10")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	metadata := lookup(t, out.Content, "metadata")
+	want := map[string]interface{}{
+		"tool_version":     "test",
+		"command":          "gitlabctl projects",
+		"username":         "root",
+		"options.group_id": "10",
+		"options.mine":     true,
+		"instance.version": "17.4.0-ee",
+		"instance.edition": "ee",
+		"api.retries":      float64(0),
+		// The throttled and retried metadata request, and the user request
+		"api.identity_calls": float64(3),
+	}
+	for path, value := range want {
+		keys := []interface{}{}
+		for _, key := range strings.Split(path, ".") {
+			keys = append(keys, key)
+		}
+		if got := lookup(t, metadata, keys...); got != value {
+			t.Errorf("metadata %s = %v, want %v", path, got, value)
+		}
+	}
+	calls, _ := lookup(t, metadata, "api", "calls").(float64)
+	pages, _ := lookup(t, metadata, "api", "pages").(float64)
+	if int(calls) != len(server.Requests())-3 || pages == 0 || pages > calls {
+		t.Errorf("api calls = %v and pages = %v, want %d calls including some pages", calls, pages, len(server.Requests())-3)
+	}
+	if length(t, metadata, "phases") != 2 || lookup(t, metadata, "phases", 0, "name") != "setup" || lookup(t, metadata, "phases", 1, "name") != "run" {
+		t.Errorf("phases = %v, want setup and run", lookup(t, metadata, "phases"))
+	}
+}
+
+func TestIdentityLookup(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	cacheDir := t.TempDir()
+
+	tests := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantIdentity bool
+	}{
+		{name: "Test Enumeration", args: []string{"projects", "--token", "test-token", "--cache-dir", cacheDir}, wantIdentity: true},
+		{name: "Test Offline", args: []string{"projects", "--token", "test-token", "--cache-dir", cacheDir, "--offline"}},
+		{name: "Test Job Token", args: []string{"projects"}, env: map[string]string{"CI_JOB_TOKEN": "job-token"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.Requests())
+			executeWithEnv(t, tt.env, tt.args, "--base-url", srv.URL+"/api/v4")
+			identity := false
+			for _, request := range server.Requests()[sent:] {
+				if request.Path == "metadata" || request.Path == "user" {
+					identity = true
+				}
+			}
+			if identity != tt.wantIdentity {
+				t.Errorf("identity looked up = %v, want %v", identity, tt.wantIdentity)
+			}
+		})
+	}
+}
+
+func TestTraceFile(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	out := run(t, srv.URL, "dependencies", "--group-id", "10", "--trace-file", traceFile)
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	data, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := map[string]int{}
+	failed := map[string]int{}
+	metrics := false
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid trace file line %s: %v", line, err)
+		}
+		if _, ok := record["ScopeMetrics"]; ok {
+			metrics = true
+			continue
+		}
+		name := record["Name"].(string)
+		spans[name]++
+		if lookup(t, record, "Status", "Code") == "Error" {
+			failed[name]++
+		}
+	}
+	for _, name := range []string{"gitlabctl dependencies", "setup", "run", "list group projects", "GET groups/:id/projects"} {
+		if spans[name] == 0 {
+			t.Errorf("no %q span in %v", name, spans)
+		}
+	}
+	// Project 2's dependencies are forbidden
+	if spans["list project dependencies"] != 3 || failed["list project dependencies"] != 1 || failed["GET projects/:id/dependencies"] != 1 {
+		t.Errorf("spans = %v and failed spans = %v, want one of three projects failing", spans, failed)
+	}
+	if !metrics {
+		t.Error("no metrics were written to the trace file")
+	}
+}
+
+// runCycloneDX runs gitlabctl dependencies with --cyclonedx against srv, returning the SBOM it wrote and the error
+// the command failed with.
+func runCycloneDX(t *testing.T, baseURL string, args ...string) (map[string]interface{}, error) {
+	t.Helper()
+	for _, name := range []string{"GITLAB_TOKEN", "CI_JOB_TOKEN", "GITLABCTL_PROFILE", "GITLABCTL_CONFIG"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	outputFile := filepath.Join(t.TempDir(), "bom.json")
+	gitlabctl := newGitlabctl()
+	gitlabctl.RootCmd.SetArgs(append(append([]string{"dependencies", "--cyclonedx"}, args...),
+		"--base-url", baseURL+"/api/v4", "--token", "test-token", "--quiet", "--output-file", outputFile))
+	if err := gitlabctl.RootCmd.Execute(); err != nil {
+		if _, statErr := os.Stat(outputFile); !os.IsNotExist(statErr) {
+			t.Errorf("failed run wrote an SBOM: %v", statErr)
+		}
+		return nil, err
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bom := map[string]interface{}{}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("invalid SBOM %s: %v", data, err)
+	}
+	return bom, nil
+}
+
+func TestCycloneDX(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	bom, err := runCycloneDX(t, srv.URL, "--project", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lookup(t, bom, "metadata", "component", "name"); got != "acme/api" {
+		t.Errorf("metadata.component.name = %v, want acme/api", got)
+	}
+
+	// Project 2's dependencies are forbidden, which leaves nothing to describe
+	if _, err := runCycloneDX(t, srv.URL, "--project", "2"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("error = %v, want the forbidden dependencies", err)
+	}
+
+	// The run is traced whichever output it writes, and traced as failed when writing the SBOM fails
+	for _, tt := range []struct {
+		project string
+		status  string
+	}{
+		{"1", "Unset"},
+		{"2", "Error"},
+	} {
+		traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+		_, _ = runCycloneDX(t, srv.URL, "--project", tt.project, "--trace-file", traceFile)
+		data, err := os.ReadFile(traceFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			record := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("invalid trace file line %s: %v", line, err)
+			}
+			if record["Name"] == "gitlabctl dependencies" {
+				found = true
+				if got := lookup(t, record, "Status", "Code"); got != tt.status {
+					t.Errorf("project %s: run span status = %v, want %s", tt.project, got, tt.status)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("project %s: no run span in trace file %s", tt.project, data)
+		}
+	}
+
+	// The SBOM cannot be streamed, so the ndjson output format is rejected before the output file is created
+	if _, err := runCycloneDX(t, srv.URL, "--project", "1", "--output", "ndjson"); err == nil || !strings.Contains(err.Error(), "ndjson") {
+		t.Errorf("error = %v, want --cyclonedx to be rejected with ndjson", err)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	server.Handle(http.MethodGet, "users",
+		fakegitlab.Response{Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "0"}},
+		fakegitlab.Response{Body: json.RawMessage(`[{"id": 1, "username": "root", "state": "active"}]`)},
+	)
+
+	out := run(t, srv.URL, "users")
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if got := length(t, out.Content, "resources", "users"); got != 1 {
+		t.Errorf("%d users, want 1", got)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	server.MaxPerPage = 2
+
+	out := run(t, srv.URL, "projects", "--mine=false")
+	if got := length(t, out.Content, "resources", "projects"); got != 3 {
+		t.Errorf("%d projects, want 3", got)
+	}
+	pages := 0
+	for _, request := range server.Requests() {
+		if request.Path == "projects" {
+			pages++
+		}
+	}
+	if pages != 2 {
+		t.Errorf("%d pages requested, want 2", pages)
+	}
+}
+
+func TestFixtureDir(t *testing.T) {
+	data := execute(t, []string{"whoami"}, "--fixture-dir", fixtureDir)
+	out := result{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != 0 {
+		t.Fatalf("status = %d, want 0", out.Status)
+	}
+	if got := lookup(t, out.Content, "base_url"); got != "https://gitlab.fixtures.invalid/api/v4" {
+		t.Errorf("base_url = %v", got)
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(fakegitlab.New(fixtureDir))
+	defer srv.Close()
+
+	data := execute(t, []string{"whoami"}, "--base-url", srv.URL+"/api/v4", "--token", "test-token", "--insecure-skip-verify")
+	out := result{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if out.ErrorMessage == nil || !strings.HasPrefix(*out.ErrorMessage, "WARNING: TLS certificate verification is disabled") {
+		t.Errorf("error_message = %v, want the insecure warning", out.ErrorMessage)
+	}
+	if got := lookup(t, out.Content, "resources", "user", "username"); got != "root" {
+		t.Errorf("username = %v, want root", got)
+	}
+}
+
+func TestRelativeURLRoot(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	data := execute(t, []string{"vulnerabilities", "set-state", "--project", "1", "--to", "resolved", "--comment", "fixed"},
+		"--base-url", srv.URL+"/gitlab", "--token", "test-token")
+	out := result{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", out.Status, *out.ErrorMessage)
+	}
+	if got := lookup(t, out.Content, "base_url"); got != srv.URL+"/gitlab/api/v4" {
+		t.Errorf("base_url = %v, want %s/gitlab/api/v4", got, srv.URL)
+	}
+
+	graphQLRequests := 0
+	for _, request := range server.Requests() {
+		if !strings.HasPrefix(request.URLPath, "/gitlab/api/") {
+			t.Errorf("request to %s is outside the relative URL root", request.URLPath)
+		}
+		if request.URLPath == "/gitlab/api/graphql" {
+			graphQLRequests++
+		}
+	}
+	if graphQLRequests == 0 {
+		t.Error("no GraphQL requests were sent to /gitlab/api/graphql")
+	}
+}
+
+func TestOfflineCache(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	cacheDir := t.TempDir()
+	online := run(t, srv.URL, "projects", "--mine=false", "--cache-dir", cacheDir)
+	if online.Status != 0 {
+		t.Fatalf("status = %d (%v), want 0", online.Status, *online.ErrorMessage)
+	}
+	sent := len(server.Requests())
+	cached := run(t, srv.URL, "projects", "--mine=false", "--cache-dir", cacheDir)
+	if got := len(server.Requests()); got != sent {
+		t.Errorf("fresh cache sent %d more requests, want none", got-sent)
+	}
+	srv.Close()
+
+	offline := run(t, srv.URL, "projects", "--mine=false", "--cache-dir", cacheDir, "--offline")
+	if offline.Status != 0 {
+		t.Fatalf("offline status = %d (%v), want 0", offline.Status, *offline.ErrorMessage)
+	}
+	for _, out := range []result{cached, offline} {
+		if got := length(t, out.Content, "resources", "projects"); got != 3 {
+			t.Errorf("cached run found %d projects, want 3", got)
+		}
+	}
+
+	uncached := run(t, srv.URL, "groups", "--group-id", "10", "--cache-dir", cacheDir, "--offline")
+	if uncached.Status == 0 || !strings.Contains(*uncached.ErrorMessage, "not in the cache") {
+		t.Errorf("uncached offline run = %d (%v), want a cache miss error", uncached.Status, uncached.ErrorMessage)
+	}
+}
+
+// runNDJSON runs gitlabctl against srv with --output ndjson and returns the records it wrote.
+func runNDJSON(t *testing.T, baseURL string, args ...string) []map[string]interface{} {
+	t.Helper()
+	for _, name := range []string{"GITLAB_TOKEN", "CI_JOB_TOKEN", "GITLABCTL_PROFILE", "GITLABCTL_CONFIG"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	outputFile := filepath.Join(t.TempDir(), "output.ndjson")
+	gitlabctl := newGitlabctl()
+	gitlabctl.RootCmd.SetArgs(append(args, "--base-url", baseURL+"/api/v4", "--token", "test-token", "--quiet",
+		"--output", "ndjson", "--output-file", outputFile))
+	if err := gitlabctl.RootCmd.Execute(); err != nil {
+		t.Fatalf("gitlabctl %s: %v", strings.Join(args, " "), err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNDJSON(t *testing.T) {
+	_, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		kind       string
+		wantCount  int
+		wantStatus float64
+		wantError  string
+	}{
+		{name: "Test Streamed Vulnerabilities", args: []string{"vulnerabilities", "--project", "1", "--fail-on", "critical"}, kind: "vulnerabilities", wantCount: 3, wantStatus: 1},
+		{name: "Test Streamed Group Vulnerabilities", args: []string{"vulnerabilities", "--group-id", "10"}, kind: "vulnerabilities", wantCount: 4, wantError: "404"},
+		{name: "Test Streamed Group Projects", args: []string{"projects", "--group-id", "10"}, kind: "projects", wantCount: 3},
+		{name: "Test Collected Licenses", args: []string{"licenses", "--group-id", "10"}, kind: "projects", wantCount: 2, wantError: "403"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := runNDJSON(t, srv.URL, tt.args...)
+			count := 0
+			for _, record := range records[:len(records)-1] {
+				if record["type"] != "resource" {
+					t.Errorf("record = %v, want a resource", record)
+				}
+				if record["kind"] == tt.kind {
+					count++
+				}
+			}
+			if count != tt.wantCount {
+				t.Errorf("got %d %s records, want %d", count, tt.kind, tt.wantCount)
+			}
+			trailer := records[len(records)-1]
+			if trailer["type"] != "trailer" || trailer["status"] != tt.wantStatus {
+				t.Fatalf("trailer = %v, want status %v", trailer, tt.wantStatus)
+			}
+			if got := lookup(t, trailer, "resource_counts", tt.kind); got != float64(tt.wantCount) {
+				t.Errorf("resource_counts.%s = %v, want %d", tt.kind, got, tt.wantCount)
+			}
+			errs, _ := trailer["errors"].([]interface{})
+			if tt.wantError != "" && (len(errs) == 0 || !strings.Contains(errs[0].(string), tt.wantError)) {
+				t.Errorf("errors = %v, want a %s error", errs, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	server, srv := fakegitlab.NewTestServer(fixtureDir)
+	defer srv.Close()
+	checkpointFile := filepath.Join(t.TempDir(), "scan.checkpoint")
+
+	// Project 2's dependencies are forbidden, so the first scan is incomplete and its checkpoint is kept
+	first := run(t, srv.URL, "dependencies", "--group-id", "10", "--checkpoint", checkpointFile)
+	if got := length(t, first.Content, "resources", "projects"); got != 2 || !errorsContain(t, first, "403") {
+		t.Fatalf("first scan found %d projects and errors %v, want 2 and a 403", got, lookup(t, first.Content, "errors"))
+	}
+	if _, err := os.Stat(checkpointFile); err != nil {
+		t.Fatalf("checkpoint of the incomplete scan: %v", err)
+	}
+
+	sent := len(server.Requests())
+	resumed := run(t, srv.URL, "licenses", "--group-id", "10", "--checkpoint", checkpointFile)
+	if got := length(t, resumed.Content, "resources", "projects"); got != 2 {
+		t.Errorf("resumed scan found %d projects, want the 2 of the first scan", got)
+	}
+	if len(server.Requests()) == sent {
+		t.Error("resumed scan did not retry the failed project")
+	}
+	for _, request := range server.Requests()[sent:] {
+		// Every run looks up the instance and the authenticated user for the report metadata
+		if request.Path == "metadata" || request.Path == "user" {
+			continue
+		}
+		if request.Path != "projects/2/dependencies" {
+			t.Errorf("resumed scan requested %s, want only the failed project's dependencies", request.Path)
+		}
+	}
+
+	mismatched := run(t, srv.URL, "projects", "--group-id", "10", "--checkpoint", checkpointFile)
+	if mismatched.Status != 1 || !strings.Contains(*mismatched.ErrorMessage, "belongs to another scan") {
+		t.Errorf("mismatched scan = %d (%v), want a checkpoint error", mismatched.Status, mismatched.ErrorMessage)
+	}
+
+	// Project 2 has no vulnerabilities, so the group scan is incomplete and resuming only retries that project
+	vulnsCheckpoint := filepath.Join(t.TempDir(), "vulnerabilities.checkpoint")
+	for i := 0; i < 2; i++ {
+		sent = len(server.Requests())
+		out := run(t, srv.URL, "vulnerabilities", "--group-id", "10", "--checkpoint", vulnsCheckpoint)
+		if got := length(t, out.Content, "resources", "vulnerabilities"); got != 4 || !errorsContain(t, out, "404") {
+			t.Fatalf("vulnerability scan %d found %d vulnerabilities and errors %v, want 4 and a 404", i, got, lookup(t, out.Content, "errors"))
+		}
+		if i == 0 {
+			continue
+		}
+		for _, request := range server.Requests()[sent:] {
+			if request.Path == "metadata" || request.Path == "user" {
+				continue
+			}
+			if request.Path != "projects/2/vulnerabilities" {
+				t.Errorf("resumed vulnerability scan requested %s, want only the failed project's vulnerabilities", request.Path)
+			}
+		}
+	}
+
+	projectsCheckpoint := filepath.Join(t.TempDir(), "projects.checkpoint")
+	complete := run(t, srv.URL, "projects", "--group-id", "10", "--checkpoint", projectsCheckpoint)
+	if got := length(t, complete.Content, "resources", "projects"); got != 3 {
+		t.Errorf("found %d projects, want 3", got)
+	}
+	if _, err := os.Stat(projectsCheckpoint); !os.IsNotExist(err) {
+		t.Errorf("checkpoint of the complete scan was kept: %v", err)
+	}
+}
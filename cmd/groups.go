@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/groups"
+	"github.com/spf13/cobra"
+)
+
+// InitGroupsCmd initializes the groups command for the gitlabctl CLI. This command sets up the flags for the command,
+// parsing the provided group ID or name and loading the baseline file before passing them to the groups package for
+// auditing.
+func (a *Gitlabctl) InitGroupsCmd() {
+	options := groups.EnumerateGroupsOptions{
+		GroupID:   "",
+		GroupName: "",
+		Baseline:  nil,
+	}
+	baselineFile := ""
+
+	a.GroupsCmd = &cobra.Command{
+		Use:         "groups",
+		Short:       "Audit Gitlab group settings",
+		Long:        `Audit the security relevant settings of Gitlab groups and their subgroups, reporting drift from a supplied baseline`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if baselineFile != "" {
+				baseline, err := groups.LoadBaseline(baselineFile)
+				if err != nil {
+					errorMessage := err.Error()
+					a.OutputSignal.ErrorMessage = &errorMessage
+					a.OutputSignal.Status = 1
+					return
+				}
+				options.Baseline = baseline
+			}
+			a.Metadata.SetOptions(options)
+			report, err := groups.EnumerateGroups(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.GroupsCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path. If neither this nor --group-name is set, all top-level groups are audited.")
+	a.GroupsCmd.Flags().StringVar(&options.GroupName, "group-name", "", "Group name")
+	a.GroupsCmd.Flags().StringVar(&baselineFile, "baseline", "", "Path to a YAML file holding the expected group settings")
+	a.GroupsCmd.MarkFlagsMutuallyExclusive("group-id", "group-name")
+
+	a.RootCmd.AddCommand(a.GroupsCmd)
+}
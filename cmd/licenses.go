@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/licenses"
+	"github.com/spf13/cobra"
+)
+
+// InitLicensesCmd initializes the licenses command for the gitlabctl CLI. This command sets up the flags for the
+// command, parsing the provided project or group ID and loading the license policy file before passing them to the
+// licenses package for evaluation.
+func (a *Gitlabctl) InitLicensesCmd() {
+	options := licenses.EnumerateLicensesOptions{
+		ProjectID: "",
+		GroupID:   "",
+		Policy:    nil,
+	}
+	policyFile := ""
+	checkpointFile := ""
+
+	a.LicensesCmd = &cobra.Command{
+		Use:         "licenses",
+		Short:       "Report Gitlab dependency license compliance",
+		Long:        `Aggregate the licenses of the dependencies detected in Gitlab projects, evaluating them against an allow/deny license policy`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if policyFile != "" {
+				policy, err := licenses.LoadPolicy(policyFile)
+				if err != nil {
+					errorMessage := err.Error()
+					a.OutputSignal.ErrorMessage = &errorMessage
+					a.OutputSignal.Status = 1
+					return
+				}
+				options.Policy = policy
+			}
+			var err error
+			options.Checkpoint, err = a.openCheckpoint(checkpointFile, options.GroupID, "dependencies")
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			a.Metadata.SetOptions(options)
+			report, err := licenses.EnumerateLicenses(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.closeCheckpoint(options.Checkpoint, err == nil && len(report.Errors) == 0)
+			a.OutputSignal.Content = report
+		},
+	}
+	a.LicensesCmd.Flags().StringVar(&options.ProjectID, "project", "", "Project ID or full path")
+	a.LicensesCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path")
+	a.LicensesCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", checkpointUsage)
+	a.LicensesCmd.Flags().StringVar(&policyFile, "policy", "", "Path to a YAML file holding the allow and deny license lists")
+	a.LicensesCmd.MarkFlagsMutuallyExclusive("project", "group-id")
+	a.LicensesCmd.MarkFlagsOneRequired("project", "group-id")
+
+	a.RootCmd.AddCommand(a.LicensesCmd)
+}
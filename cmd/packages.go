@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/packages"
+	"github.com/spf13/cobra"
+)
+
+// InitPackagesCmd initializes the packages command for the gitlabctl CLI. This command sets up the flags for the
+// command, parsing the provided group ID, package types and namespace prefixes before passing them to the packages
+// package for enumeration.
+func (a *Gitlabctl) InitPackagesCmd() {
+	options := packages.EnumeratePackagesOptions{
+		GroupID:           "",
+		PackageTypes:      []string{},
+		NamespacePrefixes: []string{},
+	}
+
+	a.PackagesCmd = &cobra.Command{
+		Use:         "packages",
+		Short:       "Enumerate Gitlab packages and their dependency confusion risk",
+		Long:        `Enumerate the packages published across a Gitlab group, flagging names that collide with public registry namespaces and packages published from unprotected refs`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			a.Metadata.SetOptions(options)
+			report, err := packages.EnumeratePackages(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.PackagesCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path")
+	a.PackagesCmd.Flags().StringSliceVar(&options.PackageTypes, "types", []string{}, "Package types. Valid values include 'npm', 'maven', 'pypi', 'nuget', 'golang', 'conan', 'generic'. If no values are provided, all of these are included.")
+	a.PackagesCmd.Flags().StringSliceVar(&options.NamespacePrefixes, "namespace-prefixes", []string{}, "Organisation scopes and name prefixes (e.g. '@acme', 'com.acme', 'acme-') that protect package names from public registry collisions")
+	_ = a.PackagesCmd.MarkFlagRequired("group-id")
+
+	a.RootCmd.AddCommand(a.PackagesCmd)
+}
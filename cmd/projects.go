@@ -1,26 +1,41 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/Method-Security/gitlabctl/internal/projects"
 	"github.com/spf13/cobra"
 )
 
 // InitProjectsCmd initializes the projects command for the gitlabctl CLI. This command sets up the flags for the command,
 // parsing the provided group ID, archived, and mine flags before passing them to the projects package for enumeration.
+// Group enumerations can record their progress in a checkpoint file, to resume them if they are interrupted.
 func (a *Gitlabctl) InitProjectsCmd() {
 	options := projects.EnumerateProjectsOptions{
 		Mine:     true,
 		Archived: false,
 		GroupID:  "",
 	}
+	checkpointFile := ""
 
 	a.ProjectsCmd = &cobra.Command{
-		Use:   "projects",
-		Short: "Enumerate Gitlab projects",
-		Long:  `Enumerate Gitlab projects`,
+		Use:         "projects",
+		Short:       "Enumerate Gitlab projects",
+		Long:        `Enumerate Gitlab projects`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
 		Run: func(cmd *cobra.Command, args []string) {
-			var report *projects.GitlabResourceReport
+			options.Stream = a.Stream
 			var err error
+			scan := fmt.Sprintf("projects archived=%t mine=%t", options.Archived, options.Mine)
+			options.Checkpoint, err = a.openCheckpoint(checkpointFile, options.GroupID, scan)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			a.Metadata.SetOptions(options)
+			var report *projects.GitlabResourceReport
 			if options.GroupID == "" {
 				report, err = projects.EnumerateProjects(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
 			} else {
@@ -31,12 +46,14 @@ func (a *Gitlabctl) InitProjectsCmd() {
 				a.OutputSignal.ErrorMessage = &errorMessage
 				a.OutputSignal.Status = 1
 			}
+			a.closeCheckpoint(options.Checkpoint, err == nil && len(report.Errors) == 0)
 			a.OutputSignal.Content = report
 		},
 	}
 	a.ProjectsCmd.Flags().BoolVar(&options.Archived, "archived", false, "Include archived projects")
 	a.ProjectsCmd.Flags().BoolVar(&options.Mine, "mine", true, "Include only projects owned by the authenticated user.")
 	a.ProjectsCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID")
+	a.ProjectsCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", checkpointUsage)
 
 	a.RootCmd.AddCommand(a.ProjectsCmd)
 }
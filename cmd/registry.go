@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// InitRegistryCmd initializes the registry command for the gitlabctl CLI. This command sets up the flags for the
+// command, parsing the provided project or group ID and the stale and mutable tag settings before passing them to the
+// registry package for enumeration.
+func (a *Gitlabctl) InitRegistryCmd() {
+	options := registry.EnumerateRegistryOptions{
+		ProjectID:         "",
+		GroupID:           "",
+		StaleDays:         90,
+		StaleTagThreshold: 100,
+		MutableTags:       []string{"latest"},
+		TagDetails:        true,
+	}
+
+	a.RegistryCmd = &cobra.Command{
+		Use:         "registry",
+		Short:       "Enumerate Gitlab container registry repositories and tags",
+		Long:        `Enumerate Gitlab container registry repositories and tags, flagging public registries, repositories with many stale tags and mutable tags`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			a.Metadata.SetOptions(options)
+			report, err := registry.EnumerateRegistry(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.RegistryCmd.Flags().StringVar(&options.ProjectID, "project", "", "Project ID or full path")
+	a.RegistryCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path")
+	a.RegistryCmd.Flags().IntVar(&options.StaleDays, "stale-days", 90, "Age in days after which a tag is considered stale")
+	a.RegistryCmd.Flags().IntVar(&options.StaleTagThreshold, "stale-tag-threshold", 100, "Number of stale tags at which a repository is flagged")
+	a.RegistryCmd.Flags().StringSliceVar(&options.MutableTags, "mutable-tags", []string{"latest"}, "Tag names that are expected to be overwritten and are flagged as mutable")
+	a.RegistryCmd.Flags().BoolVar(&options.TagDetails, "tag-details", true, "Fetch the digest, size and creation date of every tag. Requires one request per tag.")
+	a.RegistryCmd.MarkFlagsMutuallyExclusive("project", "group-id")
+	a.RegistryCmd.MarkFlagsOneRequired("project", "group-id")
+
+	a.RootCmd.AddCommand(a.RegistryCmd)
+}
@@ -4,12 +4,23 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
 	"github.com/Method-Security/gitlabctl/internal/config"
+	"github.com/Method-Security/gitlabctl/internal/fakegitlab"
+	"github.com/Method-Security/gitlabctl/internal/httpcache"
+	"github.com/Method-Security/gitlabctl/internal/ndjson"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+	"github.com/Method-Security/gitlabctl/internal/telemetry"
+	"github.com/Method-Security/gitlabctl/internal/whoami"
 	"github.com/Method-Security/pkg/signal"
 	"github.com/Method-Security/pkg/writer"
 	"github.com/palantir/pkg/datetime"
@@ -18,19 +29,50 @@ import (
 	"github.com/xanzy/go-gitlab"
 )
 
+// skipClientAnnotation marks commands that do not talk to Gitlab, and so need neither a profile nor a client.
+const skipClientAnnotation = "gitlabctl/skip-client"
+
+// enumerationAnnotation marks commands that enumerate Gitlab resources, whose reports record the Gitlab instance and
+// user they were produced from.
+const enumerationAnnotation = "gitlabctl/enumeration"
+
+// checkpointUsage is the usage of the --checkpoint flag of the commands supporting resumable group scans.
+const checkpointUsage = "Path to a checkpoint file recording the progress of a group scan, so an interrupted run resumes where it left off. Requires --group-id"
+
+// fixtureBaseURL is the base URL used when serving requests from fixtures without an explicit --base-url.
+const fixtureBaseURL = "https://gitlab.fixtures.invalid/api/v4"
+
 // Gitlabctl is the main struct for the gitlabctl CLI. It contains the version, root flags, output config, output signal,
 // information, providing a context for subcommands to leverage during execution. The output signal is used to write the
-// output of the command to the desired output format and location.
+// output of the command to the desired output format and location. Stream is set when the output format is ndjson, for
+// commands to stream their resources to as they are fetched. Metadata describes the run, and is attached to its report.
+// Telemetry traces the run when an OpenTelemetry exporter is configured.
 type Gitlabctl struct {
-	Version          string
-	RootFlags        config.RootFlags
-	OutputConfig     writer.OutputConfig
-	OutputSignal     signal.Signal
-	RootCmd          *cobra.Command
-	VersionCmd       *cobra.Command
-	ProjectsCmd      *cobra.Command
-	VulnerabilityCmd *cobra.Command
-	GitlabClient     *gitlab.Client
+	Version                      string
+	RootFlags                    config.RootFlags
+	OutputConfig                 writer.OutputConfig
+	OutputSignal                 signal.Signal
+	RootCmd                      *cobra.Command
+	VersionCmd                   *cobra.Command
+	ProjectsCmd                  *cobra.Command
+	VulnerabilityCmd             *cobra.Command
+	VulnerabilitySetStateCmd     *cobra.Command
+	VulnerabilityCreateIssuesCmd *cobra.Command
+	WhoamiCmd                    *cobra.Command
+	UsersCmd                     *cobra.Command
+	GroupsCmd                    *cobra.Command
+	AuditEventsCmd               *cobra.Command
+	RegistryCmd                  *cobra.Command
+	PackagesCmd                  *cobra.Command
+	DependenciesCmd              *cobra.Command
+	LicensesCmd                  *cobra.Command
+	ConfigCmd                    *cobra.Command
+	ConfigListCmd                *cobra.Command
+	ConfigValidateCmd            *cobra.Command
+	GitlabClient                 *gitlab.Client
+	Stream                       *ndjson.Writer
+	Metadata                     *reportmeta.Metadata
+	Telemetry                    *telemetry.Telemetry
 }
 
 // NewGitlabctl creates a new Gitlabctl struct with the provided version. The root flags, output config, and output format.
@@ -59,60 +101,173 @@ func NewGitlabctl(version string) *Gitlabctl {
 func (a *Gitlabctl) InitRootCommand() {
 	var outputFormat string
 	var outputFile string
+	var fixtureDir string
 	a.RootCmd = &cobra.Command{
 		Use:   "gitlabctl",
 		Short: "Gitlabctl CLI",
 		Long:  `Gitlabctl CLI`,
-		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
-			cmd.SetContext(svc1log.WithLogger(cmd.Context(), config.InitializeLogging(cmd, &a.RootFlags)))
-			var token string
-			if os.Getenv("GITLAB_TOKEN") != "" {
-				token = os.Getenv("GITLAB_TOKEN")
-			} else if a.RootFlags.Token != "" {
-				token = a.RootFlags.Token
-			} else {
-				return errors.New("either GITLAB_TOKEN environment variable or --token must be set")
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) (err error) {
+			a.Metadata = reportmeta.New(a.Version, cmd.CommandPath())
+			a.Telemetry, err = telemetry.Start(cmd.Context(), cmd.CommandPath(), telemetry.Options{
+				ServiceVersion: a.Version,
+				TraceFile:      a.RootFlags.TraceFile,
+				OTLPEndpoint:   a.RootFlags.OTLPEndpoint,
+			}, os.Getenv)
+			if err != nil {
+				return err
 			}
-			a.GitlabClient, _ = gitlab.NewClient(token, gitlab.WithBaseURL(a.RootFlags.BaseURL))
+			a.enterPhase(cmd, reportmeta.PhaseSetup)
+			defer func() {
+				if err != nil {
+					// PersistentPostRunE does not run when setting up fails, so the run ends here
+					a.shutdownTelemetry(cmd, err)
+					return
+				}
+				a.enterPhase(cmd, reportmeta.PhaseRun)
+			}()
 
-			if a.RootFlags.BaseURL == "" {
-				return errors.New("base-url flag not set")
+			skipClient := cmd.Annotations[skipClientAnnotation] != ""
+			if !skipClient {
+				if err := a.applyConfigFile(cmd); err != nil {
+					return err
+				}
 			}
-			a.RootFlags.BaseURL = config.NormalizeGitlabURL(a.RootFlags.BaseURL)
+			cmd.SetContext(svc1log.WithLogger(cmd.Context(), config.InitializeLogging(cmd, &a.RootFlags)))
 
-			format, err := validateOutputFormat(outputFormat)
-			if err != nil {
-				return err
+			if !skipClient {
+				clientOptions := a.Metadata.ClientOptions()
+				var httpClient *http.Client
+				if fixtureDir != "" {
+					// Serve every request from the fixtures, so the CLI can be demoed without a Gitlab instance
+					if a.RootFlags.BaseURL == "" {
+						a.RootFlags.BaseURL = fixtureBaseURL
+					}
+					if a.RootFlags.Token == "" {
+						a.RootFlags.Token = "fixture"
+					}
+					httpClient = &http.Client{Transport: fakegitlab.New(fixtureDir).Transport()}
+				} else {
+					var err error
+					httpClient, err = config.NewHTTPClient(a.RootFlags)
+					if err != nil {
+						return err
+					}
+					if a.RootFlags.InsecureSkipVerify {
+						svc1log.FromContext(cmd.Context()).Warn(config.InsecureSkipVerifyWarning)
+					}
+				}
+				if a.RootFlags.Offline && a.RootFlags.CacheDir == "" {
+					return errors.New("--offline requires --cache-dir")
+				}
+				if a.RootFlags.CacheDir != "" {
+					if httpClient == nil {
+						httpClient = &http.Client{}
+					}
+					httpClient.Transport = httpcache.New(a.RootFlags.CacheDir, a.RootFlags.CacheTTL, a.RootFlags.Offline, httpClient.Transport)
+				}
+				if a.Telemetry != nil {
+					if httpClient == nil {
+						httpClient = &http.Client{}
+					}
+					httpClient.Transport = a.Telemetry.Transport(httpClient.Transport)
+				}
+				if httpClient != nil {
+					clientOptions = append(clientOptions, gitlab.WithHTTPClient(httpClient))
+				}
+
+				if a.RootFlags.Token == "" {
+					return errors.New("either --token, the GITLAB_TOKEN environment variable, a profile token source or CI_JOB_TOKEN must be set")
+				}
+				if a.RootFlags.BaseURL == "" {
+					return errors.New("base-url flag not set")
+				}
+				instanceURL, err := config.ParseInstanceURL(a.RootFlags.BaseURL)
+				if err != nil {
+					return err
+				}
+				a.RootFlags.BaseURL = instanceURL.APIURL()
+				clientOptions = append(clientOptions, gitlab.WithBaseURL(a.RootFlags.BaseURL))
+				a.GitlabClient, err = config.NewGitlabClient(a.RootFlags, clientOptions...)
+				if err != nil {
+					return err
+				}
+				// Offline runs must not contact Gitlab, and job tokens cannot read the instance or the user
+				if cmd.Annotations[enumerationAnnotation] != "" && !a.RootFlags.Offline && a.RootFlags.AuthType != config.AuthJobToken {
+					a.lookupIdentity(cmd.Context())
+				}
 			}
+
 			var outputFilePointer *string
 			if outputFile != "" {
 				outputFilePointer = &outputFile
 			} else {
 				outputFilePointer = nil
 			}
+			if strings.ToLower(outputFormat) == "ndjson" {
+				var err error
+				a.Stream, err = ndjson.Create(outputFilePointer)
+				return err
+			}
+			format, err := validateOutputFormat(outputFormat)
+			if err != nil {
+				return err
+			}
 			a.OutputConfig = writer.NewOutputConfig(outputFilePointer, format)
 			return nil
 		},
 		PersistentPostRunE: func(cmd *cobra.Command, _ []string) error {
-			completedAt := datetime.DateTime(time.Now())
-			a.OutputSignal.CompletedAt = &completedAt
-			return writer.Write(
-				a.OutputSignal.Content,
-				a.OutputConfig,
-				a.OutputSignal.StartedAt,
-				a.OutputSignal.CompletedAt,
-				a.OutputSignal.Status,
-				a.OutputSignal.ErrorMessage,
-			)
+			return a.finishRun(cmd, func() error {
+				completedAt := datetime.DateTime(time.Now())
+				a.OutputSignal.CompletedAt = &completedAt
+				reportmeta.Attach(a.OutputSignal.Content, a.Metadata)
+				if a.RootFlags.InsecureSkipVerify && cmd.Annotations[skipClientAnnotation] == "" {
+					// Make sure anyone consuming the report knows its data was fetched over an unverified connection
+					warning := config.InsecureSkipVerifyWarning
+					if a.OutputSignal.ErrorMessage != nil {
+						warning += "; " + *a.OutputSignal.ErrorMessage
+					}
+					a.OutputSignal.ErrorMessage = &warning
+				}
+				if a.Stream != nil {
+					return a.Stream.Finish(a.OutputSignal.Content, ndjson.Trailer{
+						Status:       a.OutputSignal.Status,
+						ErrorMessage: a.OutputSignal.ErrorMessage,
+						StartedAt:    time.Time(a.OutputSignal.StartedAt),
+						CompletedAt:  time.Time(completedAt),
+					})
+				}
+				return writer.Write(
+					a.OutputSignal.Content,
+					a.OutputConfig,
+					a.OutputSignal.StartedAt,
+					a.OutputSignal.CompletedAt,
+					a.OutputSignal.Status,
+					a.OutputSignal.ErrorMessage,
+				)
+			})
 		},
 	}
 
 	a.RootCmd.PersistentFlags().BoolVarP(&a.RootFlags.Quiet, "quiet", "q", false, "Suppress output")
 	a.RootCmd.PersistentFlags().BoolVarP(&a.RootFlags.Verbose, "verbose", "v", false, "Verbose output")
-	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.BaseURL, "base-url", "", "Base URL for Gitlab API. (e.g. https://gitlab.com/api/v4)")
-	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.Token, "token", "", "Gitlab Access Token. Can also be set via GITLAB_TOKEN environment variable")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.BaseURL, "base-url", "", "Base URL of the Gitlab instance or its API, e.g. https://gitlab.com, https://example.com/gitlab or http://localhost:8080/api/v4")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.Token, "token", "", "Gitlab Access Token. Can also be set via GITLAB_TOKEN environment variable or a profile")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.Profile, "profile", "", "Config file profile to use. Can also be set via GITLABCTL_PROFILE environment variable, defaulting to the file's default_profile")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.ConfigFile, "config", "", "Path to the config file. Can also be set via GITLABCTL_CONFIG environment variable (default ~/.config/gitlabctl/config.yaml)")
 	a.RootCmd.PersistentFlags().StringVarP(&outputFile, "output-file", "f", "", "Path to output file. If blank, will output to STDOUT")
-	a.RootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "signal", "Output format (signal, json, yaml). Default value is signal")
+	a.RootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "signal", "Output format (signal, json, yaml, ndjson). Only projects and vulnerabilities stream ndjson as they are fetched, other commands write it once they complete. Default value is signal")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.CACert, "ca-cert", "", "Path to a PEM bundle of additional certificate authorities to trust")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.ClientCert, "client-cert", "", "Path to a PEM client certificate to present for mutual TLS. Requires --client-key")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.ClientKey, "client-key", "", "Path to the PEM private key of --client-cert")
+	a.RootCmd.PersistentFlags().BoolVar(&a.RootFlags.InsecureSkipVerify, "insecure-skip-verify", false, "Skip TLS certificate verification. Insecure, and reported as a warning in the output")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.Proxy, "proxy", "", "HTTP(S) proxy URL (e.g. http://proxy:3128). Defaults to the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.CacheDir, "cache-dir", "", "Cache Gitlab API GET responses in this directory, keyed by URL and token")
+	a.RootCmd.PersistentFlags().DurationVar(&a.RootFlags.CacheTTL, "cache-ttl", 10*time.Minute, "How long cached responses are served without revalidating them with Gitlab. Requires --cache-dir")
+	a.RootCmd.PersistentFlags().BoolVar(&a.RootFlags.Offline, "offline", false, "Serve every request from --cache-dir regardless of age, without contacting Gitlab. Uncached requests fail")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.TraceFile, "trace-file", "", "Write OpenTelemetry spans and metrics of the run to this file as JSON lines")
+	a.RootCmd.PersistentFlags().StringVar(&a.RootFlags.OTLPEndpoint, "otlp-endpoint", "", "Export OpenTelemetry spans and metrics to this OTLP/HTTP collector URL (e.g. http://localhost:4318). Defaults to the OTEL_EXPORTER_OTLP_* environment variables")
+	a.RootCmd.PersistentFlags().StringVar(&fixtureDir, "fixture-dir", "", "Serve Gitlab API requests from the fixtures in this directory instead of a Gitlab instance")
+	_ = a.RootCmd.PersistentFlags().MarkHidden("fixture-dir")
 
 	a.VersionCmd = &cobra.Command{
 		Use:   "version",
@@ -130,6 +285,110 @@ func (a *Gitlabctl) InitRootCommand() {
 	a.RootCmd.AddCommand(a.VersionCmd)
 }
 
+// applyConfigFile loads the config file and fills in the root flags, and the defaults of the command's flags, from the
+// selected profile. Flags set on the command line always take precedence over the profile.
+func (a *Gitlabctl) applyConfigFile(cmd *cobra.Command) error {
+	configPath, required := config.ConfigPath(a.RootFlags.ConfigFile, os.Getenv)
+	file, err := config.LoadFile(configPath, required)
+	if err != nil {
+		return err
+	}
+	profile, err := file.Apply(cmd.Context(), &a.RootFlags, os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(profile.Defaults))
+	for name := range profile.Defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(profile.Defaults[name]); err != nil {
+			return fmt.Errorf("profile %s: invalid default for --%s: %w", a.RootFlags.Profile, name, err)
+		}
+	}
+	return nil
+}
+
+// enterPhase starts a phase of the run, timing it in the report metadata and tracing it as a span the command's
+// context carries.
+func (a *Gitlabctl) enterPhase(cmd *cobra.Command, name string) {
+	a.Metadata.Enter(name)
+	cmd.SetContext(a.Telemetry.Enter(cmd.Context(), name))
+}
+
+// finishRun ends the run of a command: it ends the phase in progress, writes the command's output with write, and then
+// shuts telemetry down, tracing the run as failed when writing failed or the command reported an error. Every
+// PersistentPostRunE ends the run through it, whichever output it writes.
+func (a *Gitlabctl) finishRun(cmd *cobra.Command, write func() error) (err error) {
+	a.Metadata.Finish()
+	defer func() {
+		runErr := err
+		if runErr == nil && a.OutputSignal.Status != 0 && a.OutputSignal.ErrorMessage != nil {
+			runErr = errors.New(*a.OutputSignal.ErrorMessage)
+		}
+		a.shutdownTelemetry(cmd, runErr)
+	}()
+	return write()
+}
+
+// shutdownTelemetry ends the trace of the run, failed when err is set, and exports it. Failing to export telemetry
+// does not fail the command.
+func (a *Gitlabctl) shutdownTelemetry(cmd *cobra.Command, err error) {
+	if err := a.Telemetry.Shutdown(cmd.Context(), err); err != nil {
+		svc1log.FromContext(cmd.Context()).Warn("Failed to export telemetry", svc1log.SafeParam("error", err.Error()))
+	}
+}
+
+// lookupIdentity records the Gitlab instance and the authenticated user in the metadata of the run. The token may not be
+// allowed to read them, so failing to look them up leaves them empty rather than failing the command. The lookup's
+// requests are counted apart from those of the scan.
+func (a *Gitlabctl) lookupIdentity(ctx context.Context) {
+	logger := svc1log.FromContext(ctx)
+	ctx = reportmeta.IdentityContext(ctx)
+	var instance *reportmeta.Instance
+	info, err := whoami.FetchInstanceInfo(ctx, a.GitlabClient)
+	if err != nil {
+		logger.Debug("Failed to look up the Gitlab instance for the report metadata", svc1log.SafeParam("error", err.Error()))
+	} else {
+		instance = &reportmeta.Instance{Version: info.Version, Revision: info.Revision, Edition: info.Edition}
+	}
+	username := ""
+	user, _, err := a.GitlabClient.Users.CurrentUser(gitlab.WithContext(ctx))
+	if err != nil {
+		logger.Debug("Failed to look up the authenticated user for the report metadata", svc1log.SafeParam("error", err.Error()))
+	} else {
+		username = user.Username
+	}
+	a.Metadata.SetIdentity(instance, username)
+}
+
+// openCheckpoint opens the checkpoint file of a group scan, described by scan, returning nil when no file is set.
+// The base URL is part of the scan, so a checkpoint is never resumed against another Gitlab instance.
+func (a *Gitlabctl) openCheckpoint(path string, groupID string, scan string) (*checkpoint.Checkpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if groupID == "" {
+		return nil, errors.New("--checkpoint requires --group-id")
+	}
+	return checkpoint.Open(path, fmt.Sprintf("%s %s group=%s", a.RootFlags.BaseURL, scan, groupID))
+}
+
+// closeCheckpoint closes the checkpoint of a group scan, which is removed when the scan completed without errors.
+func (a *Gitlabctl) closeCheckpoint(cp *checkpoint.Checkpoint, complete bool) {
+	if err := cp.Close(complete); err != nil && a.OutputSignal.ErrorMessage == nil {
+		errorMessage := err.Error()
+		a.OutputSignal.ErrorMessage = &errorMessage
+		a.OutputSignal.Status = 1
+	}
+}
+
 func validateOutputFormat(output string) (writer.Format, error) {
 	var format writer.FormatValue
 	switch strings.ToLower(output) {
@@ -140,7 +399,7 @@ func validateOutputFormat(output string) (writer.Format, error) {
 	case "signal":
 		format = writer.SIGNAL
 	default:
-		return writer.Format{}, errors.New("invalid output format. Valid formats are: json, yaml, signal")
+		return writer.Format{}, errors.New("invalid output format. Valid formats are: json, yaml, signal, ndjson")
 	}
 	return writer.NewFormat(format), nil
 }
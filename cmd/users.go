@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/users"
+	"github.com/spf13/cobra"
+)
+
+// InitUsersCmd initializes the users command for the gitlabctl CLI. This command sets up the flags for the command,
+// parsing the dormant window, blocked, bots and identity provider flags before passing them to the users package for
+// auditing.
+func (a *Gitlabctl) InitUsersCmd() {
+	options := users.EnumerateUsersOptions{
+		DormantDays:    90,
+		IncludeBlocked: false,
+		IncludeBots:    false,
+		Providers:      []string{},
+	}
+
+	a.UsersCmd = &cobra.Command{
+		Use:         "users",
+		Short:       "Audit Gitlab instance users",
+		Long:        `Audit Gitlab instance users for two-factor authentication, administrator accounts, dormant accounts and linked identities. Requires an administrator token.`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			a.Metadata.SetOptions(options)
+			report, err := users.EnumerateUsers(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.UsersCmd.Flags().IntVar(&options.DormantDays, "dormant-days", 90, "Number of days without sign-in or activity after which an account is flagged as dormant. Set to 0 to disable.")
+	a.UsersCmd.Flags().BoolVar(&options.IncludeBlocked, "include-blocked", false, "Include blocked and deactivated users")
+	a.UsersCmd.Flags().BoolVar(&options.IncludeBots, "include-bots", false, "Include bot and internal users")
+	a.UsersCmd.Flags().StringSliceVar(&options.Providers, "providers", []string{}, "Identity providers (e.g. 'saml', 'ldapmain') that satisfy the linked identity check. If no values are provided, any provider does.")
+
+	a.RootCmd.AddCommand(a.UsersCmd)
+}
@@ -1,40 +1,271 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/Method-Security/gitlabctl/internal/vulnerability"
 	"github.com/spf13/cobra"
 )
 
 // InitVulnerabilityCmd initializes the vulnerability command for the gitlabctl CLI. This command sets up the flags for the
 // command, parsing the provided state and severity filters before passing them to the vulnerability package for enumeration.
+// When a failure threshold is set, the command fails if any unsuppressed vulnerability meets it.
 func (a *Gitlabctl) InitVulnerabilityCmd() {
 	projectID := 0
+	groupID := ""
 	severities := make([]string, 0)
 	states := make([]string, 0)
+	minSeverity := ""
+	reportTypes := make([]string, 0)
+	rulesFile := ""
+	failOn := ""
+	slaDays := make([]string, 0)
+	summary := false
+	top := 10
+	checkpointFile := ""
 	a.VulnerabilityCmd = &cobra.Command{
-		Use:     "vulnerabilities",
-		Short:   "Enumerate Gitlab vulnerabilities",
-		Long:    `Enumerate Gitlab vulnerabilities`,
-		Aliases: []string{"vulns"},
+		Use:         "vulnerabilities",
+		Short:       "Enumerate Gitlab vulnerabilities",
+		Long:        `Enumerate Gitlab vulnerabilities`,
+		Annotations: map[string]string{enumerationAnnotation: "true"},
+		Aliases:     []string{"vulns"},
 		Run: func(cmd *cobra.Command, args []string) {
-			opts, err := vulnerability.NewEnumerateSecurityVulnerabilitiesOptions(projectID, states, severities)
+			opts, err := vulnerability.NewEnumerateSecurityVulnerabilitiesOptions(projectID, groupID, states, severities, minSeverity, reportTypes)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			opts.SLA, err = vulnerability.NewSLAPolicy(slaDays)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			opts.Summary = summary
+			opts.TopIdentifiers = top
+			opts.Stream = a.Stream
+			if rulesFile != "" {
+				rules, err := vulnerability.LoadRules(rulesFile)
+				if err != nil {
+					errorMessage := err.Error()
+					a.OutputSignal.ErrorMessage = &errorMessage
+					a.OutputSignal.Status = 1
+					return
+				}
+				opts.Rules = rules
+			}
+			if failOn != "" {
+				opts.FailOnSeverity, err = vulnerability.ParseSeverity(failOn)
+				if err != nil {
+					errorMessage := err.Error()
+					a.OutputSignal.ErrorMessage = &errorMessage
+					a.OutputSignal.Status = 1
+					return
+				}
+			}
+			opts.Checkpoint, err = a.openCheckpoint(checkpointFile, groupID, "vulnerabilities")
 			if err != nil {
 				errorMessage := err.Error()
 				a.OutputSignal.ErrorMessage = &errorMessage
 				a.OutputSignal.Status = 1
 				return
 			}
+			a.Metadata.SetOptions(opts)
 			report, err := vulnerability.EnumerateSecurityVulnerabilities(cmd.Context(), a.RootFlags.BaseURL, opts, a.GitlabClient)
 			if err != nil {
 				errorMessage := err.Error()
 				a.OutputSignal.ErrorMessage = &errorMessage
 				a.OutputSignal.Status = 1
+			} else if report.ThresholdExceeded > 0 {
+				errorMessage := fmt.Sprintf("%d unsuppressed vulnerabilities at or above %s severity", report.ThresholdExceeded, opts.FailOnSeverity)
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
 			}
+			a.closeCheckpoint(opts.Checkpoint, err == nil && len(report.Errors) == 0)
 			a.OutputSignal.Content = report
 		},
 	}
 	a.VulnerabilityCmd.Flags().IntVar(&projectID, "project", 0, "Project ID")
-	a.VulnerabilityCmd.Flags().StringSliceVar(&states, "states", []string{}, "Vulnerability states. Valid values are 'detected', 'dismissed', 'resolved'. If no values are provided, 'detected' will be used by default.")
+	a.VulnerabilityCmd.Flags().StringVar(&groupID, "group-id", "", "Group ID or full path. Enumerates the vulnerabilities of every project in the group and its subgroups")
+	a.VulnerabilityCmd.Flags().StringSliceVar(&states, "states", []string{}, "Vulnerability states. Valid values are 'detected', 'confirmed', 'resolved', 'dismissed'. If no values are provided, 'detected' and 'confirmed' will be used by default.")
 	a.VulnerabilityCmd.Flags().StringSliceVar(&severities, "severities", []string{}, "Vulnerability severities. Valid values are 'unknown', 'info', 'low', 'medium', 'high', 'critical'.")
+	a.VulnerabilityCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only return vulnerabilities at or above this severity")
+	a.VulnerabilityCmd.Flags().StringSliceVar(&reportTypes, "report-types", []string{}, "Vulnerability report types. Valid values are 'sast', 'dast', 'dependency_scanning', 'container_scanning', 'secret_detection', 'coverage_fuzzing', 'api_fuzzing', 'cluster_image_scanning', 'generic'.")
+	a.VulnerabilityCmd.Flags().StringVar(&rulesFile, "rules", "", "Path to a YAML file holding suppression rules")
+	a.VulnerabilityCmd.Flags().StringVar(&failOn, "fail-on", "", "Fail if any unsuppressed vulnerability is at or above this severity")
+	a.VulnerabilityCmd.Flags().StringSliceVar(&slaDays, "sla", vulnerability.DefaultSLAPolicy().Pairs(), "Remediation SLA in days by severity, as severity=days pairs. Severities without an SLA are not evaluated, and --sla \"\" disables SLA evaluation.")
+	a.VulnerabilityCmd.Flags().BoolVar(&summary, "summary", false, "Report aggregated counts instead of individual vulnerabilities")
+	a.VulnerabilityCmd.Flags().IntVar(&top, "top", 10, "Number of most common CVEs to list in the summary")
+	a.VulnerabilityCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", checkpointUsage)
+	a.VulnerabilityCmd.MarkFlagsMutuallyExclusive("severities", "min-severity")
+	a.VulnerabilityCmd.MarkFlagsMutuallyExclusive("project", "group-id")
+	a.VulnerabilityCmd.MarkFlagsOneRequired("project", "group-id")
 	a.RootCmd.AddCommand(a.VulnerabilityCmd)
+
+	a.initVulnerabilitySetStateCmd()
+	a.initVulnerabilityCreateIssuesCmd()
+}
+
+// initVulnerabilitySetStateCmd initializes the vulnerabilities set-state subcommand, which transitions the vulnerabilities
+// selected by a project, group or IDs file, narrowed down by the filter flags, to a new state.
+func (a *Gitlabctl) initVulnerabilitySetStateCmd() {
+	options := vulnerability.TransitionOptions{
+		ProjectID: "",
+		GroupID:   "",
+		DryRun:    false,
+	}
+	idsFile := ""
+	targetState := ""
+	dismissalReason := ""
+	states := make([]string, 0)
+	severities := make([]string, 0)
+	minSeverity := ""
+	reportTypes := make([]string, 0)
+	scanners := make([]string, 0)
+
+	a.VulnerabilitySetStateCmd = &cobra.Command{
+		Use:   "set-state",
+		Short: "Bulk transition Gitlab vulnerabilities to a new state",
+		Long:  `Bulk transition Gitlab vulnerabilities to confirmed, dismissed or resolved, recording a comment and, when dismissing, a dismissal reason. Use --dry-run to report the planned transitions without applying them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if idsFile != "" {
+				ids, err := vulnerability.LoadVulnerabilityIDs(idsFile)
+				if err != nil {
+					errorMessage := err.Error()
+					a.OutputSignal.ErrorMessage = &errorMessage
+					a.OutputSignal.Status = 1
+					return
+				}
+				options.IDs = ids
+			}
+			filter, err := vulnerability.ParseFilter(states, severities, minSeverity, reportTypes, scanners)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			filter.Identifiers = options.Filter.Identifiers
+			filter.Paths = options.Filter.Paths
+			options.Filter = *filter
+			options.TargetState = vulnerability.State(targetState)
+			options.DismissalReason = vulnerability.DismissalReason(dismissalReason)
+
+			a.Metadata.SetOptions(options)
+			report, err := vulnerability.TransitionVulnerabilities(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&options.ProjectID, "project", "", "Project ID or full path")
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path")
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&idsFile, "ids-file", "", "Path to a JSON file holding a list of vulnerability IDs. Cannot be combined with --scanners, --identifiers or --paths")
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&targetState, "to", "", "State to transition vulnerabilities to. Valid values are 'confirmed', 'dismissed', 'resolved'.")
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&options.Comment, "comment", "", "Comment recorded with every transition")
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&dismissalReason, "dismissal-reason", "", "Reason recorded when dismissing. Valid values are 'acceptable_risk', 'false_positive', 'mitigating_control', 'used_in_tests', 'not_applicable'.")
+	a.VulnerabilitySetStateCmd.Flags().StringSliceVar(&states, "states", []string{}, "Only transition vulnerabilities in these states. If no values are provided, 'detected' and 'confirmed' will be used by default.")
+	a.VulnerabilitySetStateCmd.Flags().StringSliceVar(&severities, "severities", []string{}, "Only transition vulnerabilities with these severities. Valid values are 'unknown', 'info', 'low', 'medium', 'high', 'critical'.")
+	a.VulnerabilitySetStateCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only transition vulnerabilities at or above this severity")
+	a.VulnerabilitySetStateCmd.Flags().StringSliceVar(&reportTypes, "report-types", []string{}, "Only transition vulnerabilities with these report types (e.g. sast, dependency_scanning)")
+	a.VulnerabilitySetStateCmd.Flags().StringSliceVar(&scanners, "scanners", []string{}, "Only transition vulnerabilities reported by these Gitlab analyzers or report types (e.g. semgrep, gemnasium, dependency_scanning)")
+	a.VulnerabilitySetStateCmd.Flags().StringSliceVar(&options.Filter.Identifiers, "identifiers", []string{}, "Only transition vulnerabilities with these identifiers (e.g. CVE-2021-44228, CWE-79). Supports * wildcards.")
+	a.VulnerabilitySetStateCmd.Flags().StringSliceVar(&options.Filter.Paths, "paths", []string{}, "Only transition vulnerabilities found in files matching these globs. ** matches across directories.")
+	a.VulnerabilitySetStateCmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "Report the planned transitions without applying them")
+	a.VulnerabilitySetStateCmd.MarkFlagsMutuallyExclusive("project", "group-id", "ids-file")
+	a.VulnerabilitySetStateCmd.MarkFlagsOneRequired("project", "group-id", "ids-file")
+	a.VulnerabilitySetStateCmd.MarkFlagsMutuallyExclusive("severities", "min-severity")
+	_ = a.VulnerabilitySetStateCmd.MarkFlagRequired("to")
+	_ = a.VulnerabilitySetStateCmd.MarkFlagRequired("comment")
+
+	a.VulnerabilityCmd.AddCommand(a.VulnerabilitySetStateCmd)
+}
+
+// initVulnerabilityCreateIssuesCmd initializes the vulnerabilities create-issues subcommand, which creates, updates and
+// closes a Gitlab issue per vulnerability or per identifier for the vulnerabilities matching the filter flags.
+func (a *Gitlabctl) initVulnerabilityCreateIssuesCmd() {
+	options := vulnerability.IssueOptions{
+		ProjectID:    "",
+		GroupID:      "",
+		Labels:       []string{},
+		Confidential: true,
+		DryRun:       false,
+	}
+	groupBy := ""
+	assignees := make([]string, 0)
+	templateFile := ""
+	severities := make([]string, 0)
+	minSeverity := ""
+	reportTypes := make([]string, 0)
+	scanners := make([]string, 0)
+
+	a.VulnerabilityCreateIssuesCmd = &cobra.Command{
+		Use:   "create-issues",
+		Short: "Create Gitlab issues from vulnerabilities",
+		Long:  `Create a Gitlab issue per vulnerability, or per identifier within a project, for open vulnerabilities. Issues are found again by a fingerprint stored in their description, so repeated runs update them rather than creating duplicates, and issues are closed once all their vulnerabilities are resolved or dismissed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := vulnerability.ParseFilter(nil, severities, minSeverity, reportTypes, scanners)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			filter.Identifiers = options.Filter.Identifiers
+			filter.Paths = options.Filter.Paths
+			options.Filter = *filter
+			options.GroupBy = vulnerability.IssueGrouping(groupBy)
+			if options.Assignees, err = vulnerability.NewAssigneeRules(assignees); err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+				return
+			}
+			if templateFile != "" {
+				data, err := os.ReadFile(templateFile)
+				if err != nil {
+					errorMessage := err.Error()
+					a.OutputSignal.ErrorMessage = &errorMessage
+					a.OutputSignal.Status = 1
+					return
+				}
+				options.Template = string(data)
+			}
+
+			a.Metadata.SetOptions(options)
+			report, err := vulnerability.CreateIssues(cmd.Context(), a.RootFlags.BaseURL, &options, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+	a.VulnerabilityCreateIssuesCmd.Flags().StringVar(&options.ProjectID, "project", "", "Project ID or full path")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringVar(&options.GroupID, "group-id", "", "Group ID or full path")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringVar(&groupBy, "group-by", "vulnerability", "Create an issue per 'vulnerability', or per 'identifier' within a project")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&options.Labels, "labels", []string{}, "Labels added to every issue")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&assignees, "assignees", []string{}, "Assignee rules as key=username pairs, where the key is a severity, a report type or * (e.g. critical=alice,sast=bob,*=carol)")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringVar(&templateFile, "template", "", "Path to a Go text/template file issue descriptions are rendered with")
+	a.VulnerabilityCreateIssuesCmd.Flags().BoolVar(&options.Confidential, "confidential", true, "Create confidential issues")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&severities, "severities", []string{}, "Only create issues for vulnerabilities with these severities. Valid values are 'unknown', 'info', 'low', 'medium', 'high', 'critical'.")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only create issues for vulnerabilities at or above this severity")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&reportTypes, "report-types", []string{}, "Only create issues for vulnerabilities with these report types (e.g. sast, dependency_scanning)")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&scanners, "scanners", []string{}, "Only create issues for vulnerabilities reported by these Gitlab analyzers or report types (e.g. semgrep, gemnasium, dependency_scanning)")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&options.Filter.Identifiers, "identifiers", []string{}, "Only create issues for vulnerabilities with these identifiers (e.g. CVE-2021-44228, CWE-79). Supports * wildcards.")
+	a.VulnerabilityCreateIssuesCmd.Flags().StringSliceVar(&options.Filter.Paths, "paths", []string{}, "Only create issues for vulnerabilities found in files matching these globs. ** matches across directories.")
+	a.VulnerabilityCreateIssuesCmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "Report the planned issue changes without applying them")
+	a.VulnerabilityCreateIssuesCmd.MarkFlagsMutuallyExclusive("project", "group-id")
+	a.VulnerabilityCreateIssuesCmd.MarkFlagsOneRequired("project", "group-id")
+	a.VulnerabilityCreateIssuesCmd.MarkFlagsMutuallyExclusive("severities", "min-severity")
+
+	a.VulnerabilityCmd.AddCommand(a.VulnerabilityCreateIssuesCmd)
 }
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/whoami"
+	"github.com/spf13/cobra"
+)
+
+// InitWhoamiCmd initializes the whoami command for the gitlabctl CLI. This command introspects the supplied token,
+// reporting the authenticated user, the token's scopes and expiry, the Gitlab instance version and edition, and which
+// gitlabctl commands will work or be degraded with that token.
+func (a *Gitlabctl) InitWhoamiCmd() {
+	a.WhoamiCmd = &cobra.Command{
+		Use:   "whoami",
+		Short: "Report the identity and capabilities of the supplied Gitlab token",
+		Long:  `Report the identity and capabilities of the supplied Gitlab token`,
+		Run: func(cmd *cobra.Command, args []string) {
+			report, err := whoami.EnumerateIdentity(cmd.Context(), a.RootFlags.BaseURL, a.GitlabClient)
+			if err != nil {
+				errorMessage := err.Error()
+				a.OutputSignal.ErrorMessage = &errorMessage
+				a.OutputSignal.Status = 1
+			}
+			a.OutputSignal.Content = report
+		},
+	}
+
+	a.RootCmd.AddCommand(a.WhoamiCmd)
+}
@@ -0,0 +1,50 @@
+package auditevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Cursor records the newest audit event returned for a scope, allowing the next run to only return newer events.
+type Cursor struct {
+	Scope         Scope     `json:"scope" yaml:"scope"`
+	ScopeID       string    `json:"scope_id" yaml:"scope_id"`
+	LastEventID   int       `json:"last_event_id" yaml:"last_event_id"`
+	LastCreatedAt time.Time `json:"last_created_at" yaml:"last_created_at"`
+}
+
+// LoadCursor reads a Cursor from the provided path. A missing file is not an error and returns a nil Cursor, as it is
+// expected on the first run.
+func LoadCursor(path string) (*Cursor, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &Cursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor file %s: %w", path, err)
+	}
+	return cursor, nil
+}
+
+// SaveCursor writes the Cursor to the provided path, replacing the file atomically so an interrupted write cannot
+// corrupt the previous cursor.
+func SaveCursor(path string, cursor *Cursor) error {
+	data, err := json.MarshalIndent(cursor, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
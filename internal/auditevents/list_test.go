@@ -0,0 +1,133 @@
+package auditevents_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/auditevents"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestNormalizeEvent(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event *gitlab.AuditEvent
+		want  *auditevents.Event
+	}{
+		{
+			name: "Test Member Added",
+			event: &gitlab.AuditEvent{
+				ID:         42,
+				AuthorID:   7,
+				EntityID:   3,
+				EntityType: "Group",
+				EventType:  "member_created",
+				CreatedAt:  &createdAt,
+				Details: gitlab.AuditEventDetails{
+					Add:           "user_access",
+					As:            "Developer",
+					AuthorName:    "Alice",
+					TargetID:      float64(12345678),
+					TargetType:    "User",
+					TargetDetails: "bob",
+					IPAddress:     "10.0.0.1",
+					EntityPath:    "acme",
+				},
+			},
+			want: &auditevents.Event{
+				ID:         42,
+				Timestamp:  createdAt,
+				EventType:  "member_created",
+				Action:     "add user_access as Developer",
+				Actor:      auditevents.Actor{ID: 7, Name: "Alice"},
+				Target:     auditevents.Target{ID: "12345678", Type: "User", Details: "bob"},
+				IPAddress:  "10.0.0.1",
+				EntityType: "Group",
+				EntityID:   3,
+				EntityPath: "acme",
+			},
+		},
+		{
+			name: "Test Setting Changed",
+			event: &gitlab.AuditEvent{
+				ID:        43,
+				CreatedAt: &createdAt,
+				Details: gitlab.AuditEventDetails{
+					Change:   "visibility",
+					From:     "private",
+					To:       "public",
+					TargetID: "acme/app",
+				},
+			},
+			want: &auditevents.Event{
+				ID:        43,
+				Timestamp: createdAt,
+				Action:    `change visibility from "private" to "public"`,
+				Target:    auditevents.Target{ID: "acme/app"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := auditevents.NormalizeEvent(tt.event)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.cursor")
+
+	cursor, err := auditevents.LoadCursor(path)
+	if err != nil || cursor != nil {
+		t.Fatalf("LoadCursor() on a missing file = %v, %v, want nil, nil", cursor, err)
+	}
+
+	want := &auditevents.Cursor{
+		Scope:         auditevents.ScopeGroup,
+		ScopeID:       "acme",
+		LastEventID:   42,
+		LastCreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := auditevents.SaveCursor(path, want); err != nil {
+		t.Fatalf("SaveCursor() returned error: %v", err)
+	}
+	got, err := auditevents.LoadCursor(path)
+	if err != nil {
+		t.Fatalf("LoadCursor() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Test Date", value: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Test RFC 3339", value: "2024-03-01T12:00:00Z", want: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "Test Invalid", value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditevents.ParseTime(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTime(%s) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(tt.want) {
+				t.Errorf("ParseTime(%s) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
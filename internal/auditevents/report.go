@@ -0,0 +1,63 @@
+package auditevents
+
+import (
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// Scope represents the level at which audit events are collected.
+type Scope string
+
+const (
+	ScopeInstance Scope = "instance"
+	ScopeGroup    Scope = "group"
+	ScopeProject  Scope = "project"
+)
+
+// Actor represents the user or system that performed an audited action.
+type Actor struct {
+	ID    int    `json:"id" yaml:"id"`
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	Class string `json:"class,omitempty" yaml:"class,omitempty"`
+}
+
+// Target represents the resource an audited action was performed on.
+type Target struct {
+	ID      string `json:"id" yaml:"id"`
+	Type    string `json:"type" yaml:"type"`
+	Details string `json:"details" yaml:"details"`
+}
+
+// Event is the gitlabctl normalized representation of a Gitlab audit event, independent of the scope it was collected
+// from.
+type Event struct {
+	ID         int       `json:"id" yaml:"id"`
+	Timestamp  time.Time `json:"timestamp" yaml:"timestamp"`
+	EventType  string    `json:"event_type" yaml:"event_type"`
+	Action     string    `json:"action" yaml:"action"`
+	Actor      Actor     `json:"actor" yaml:"actor"`
+	Target     Target    `json:"target" yaml:"target"`
+	IPAddress  string    `json:"ip_address" yaml:"ip_address"`
+	EntityType string    `json:"entity_type" yaml:"entity_type"`
+	EntityID   int       `json:"entity_id" yaml:"entity_id"`
+	EntityPath string    `json:"entity_path" yaml:"entity_path"`
+}
+
+// GitlabResources represents a collection of normalized Gitlab audit events, ordered from oldest to newest.
+type GitlabResources struct {
+	Events []*Event `json:"events" yaml:"events"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+// The Cursor field holds the position the next incremental run will resume from.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+	Cursor    *Cursor         `json:"cursor,omitempty" yaml:"cursor,omitempty"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
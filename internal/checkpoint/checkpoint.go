@@ -0,0 +1,236 @@
+// Package checkpoint records the progress of long running group scans in a checkpoint file, so a scan interrupted by
+// a crash or a network failure resumes where it left off instead of starting over.
+//
+// A checkpoint tracks two kinds of progress: the position reached in paginated listings, such as the projects of a
+// group, along with the items already listed; and the results of the units of work already completed, such as the
+// dependencies of a project. The file is a journal of JSON lines appended as progress is made, so recording progress
+// costs the same however large the scan grows, and a line torn by a crash only loses that line's progress.
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// header is the first line of a checkpoint file, identifying the scan it belongs to.
+type header struct {
+	Scan string `json:"scan"`
+}
+
+// entry is a line of a checkpoint file recording progress. Listing entries record a page of a listing and the page to
+// fetch next, which is 0 once the listing is complete. Result entries record a completed unit of work.
+type entry struct {
+	Listing  string            `json:"listing,omitempty"`
+	Items    []json.RawMessage `json:"items,omitempty"`
+	NextPage int               `json:"next_page,omitempty"`
+	Result   string            `json:"result,omitempty"`
+	Value    json.RawMessage   `json:"value,omitempty"`
+}
+
+// listing is the progress of a paginated listing.
+type listing struct {
+	items    []json.RawMessage
+	nextPage int
+	done     bool
+}
+
+// Checkpoint is the progress of a scan, loaded from and recorded to a checkpoint file. Every method of a nil
+// Checkpoint is a no-op reporting no progress, so scans run without a checkpoint file need no special handling. It
+// is safe for concurrent use.
+type Checkpoint struct {
+	path     string
+	mu       sync.Mutex
+	file     *os.File
+	listings map[string]*listing
+	results  map[string]json.RawMessage
+}
+
+// Open opens the checkpoint file at path for a scan, identified by a description of the scan such as its command and
+// options. The progress recorded by a previous run of the same scan is loaded, while a file recorded by a different
+// scan is an error, as resuming from it would mix unrelated results.
+func Open(path string, scan string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, listings: map[string]*listing{}, results: map[string]json.RawMessage{}}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := c.load(data, scan); err != nil {
+			return nil, err
+		}
+	}
+
+	c.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	if len(data) == 0 {
+		if err := c.append(header{Scan: scan}); err != nil {
+			return nil, err
+		}
+	} else if data[len(data)-1] != '\n' {
+		// Terminate the line torn by the interrupted run, so the next entry starts on its own line
+		if _, err := c.file.Write([]byte{'\n'}); err != nil {
+			return nil, fmt.Errorf("failed to write checkpoint file: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Checkpoint) load(data []byte, scan string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	if !scanner.Scan() {
+		return fmt.Errorf("invalid checkpoint file %s", c.path)
+	}
+	recorded := header{}
+	if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil || recorded.Scan == "" {
+		return fmt.Errorf("invalid checkpoint file %s", c.path)
+	}
+	if recorded.Scan != scan {
+		return fmt.Errorf("checkpoint file %s belongs to another scan (%s), remove it or use another file", c.path, recorded.Scan)
+	}
+
+	for scanner.Scan() {
+		e := entry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A line torn by an interrupted run is skipped, and its progress made again
+			continue
+		}
+		switch {
+		case e.Listing != "":
+			l := c.listing(e.Listing)
+			l.items = append(l.items, e.Items...)
+			l.nextPage = e.NextPage
+			l.done = e.NextPage == 0
+		case e.Result != "":
+			c.results[e.Result] = e.Value
+		}
+	}
+	return scanner.Err()
+}
+
+// Position returns the page of a paginated listing to fetch next, decoding the items of the pages already fetched
+// into items, which must be a pointer to a slice. The returned page is 0 once the listing is complete.
+func (c *Checkpoint) Position(name string, items interface{}) (int, error) {
+	if c == nil {
+		return 1, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.listings[name]
+	if !ok {
+		return 1, nil
+	}
+	data, err := json.Marshal(l.items)
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(data, items); err != nil {
+		return 0, fmt.Errorf("invalid checkpoint file %s: %w", c.path, err)
+	}
+	if l.done {
+		return 0, nil
+	}
+	return l.nextPage, nil
+}
+
+// Advance records a page of a paginated listing: the items it held, and the page to fetch next, which is 0 once the
+// listing is complete. items must be a slice.
+func (c *Checkpoint) Advance(name string, items interface{}, nextPage int) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := c.listing(name)
+	l.items = append(l.items, raw...)
+	l.nextPage = nextPage
+	l.done = nextPage == 0
+	return c.append(entry{Listing: name, Items: raw, NextPage: nextPage})
+}
+
+// Result decodes the recorded result of a completed unit of work into value, returning false when it was not
+// completed yet.
+func (c *Checkpoint) Result(id string, value interface{}) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.results[id]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("invalid checkpoint file %s: %w", c.path, err)
+	}
+	return true, nil
+}
+
+// Complete records the result of a completed unit of work, so it is not done again when the scan resumes.
+func (c *Checkpoint) Complete(id string, value interface{}) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[id] = data
+	return c.append(entry{Result: id, Value: data})
+}
+
+// Close closes the checkpoint file. When the scan completed without errors the file is removed, so the next run
+// starts a fresh scan; otherwise it is kept, and the next run only retries the work that failed.
+func (c *Checkpoint) Close(complete bool) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+	if complete {
+		return os.Remove(c.path)
+	}
+	return nil
+}
+
+func (c *Checkpoint) listing(name string) *listing {
+	l, ok := c.listings[name]
+	if !ok {
+		l = &listing{}
+		c.listings[name] = l
+	}
+	return l
+}
+
+func (c *Checkpoint) append(line interface{}) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	return nil
+}
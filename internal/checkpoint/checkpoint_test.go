@@ -0,0 +1,131 @@
+package checkpoint_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
+)
+
+type project struct {
+	ID int `json:"id"`
+}
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.checkpoint")
+	first, err := checkpoint.Open(path, "scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Advance("groups/10/projects", []project{{ID: 1}, {ID: 2}}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Advance("groups/11/projects", []project{{ID: 3}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Complete("projects/1", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Close(false); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a run interrupted while recording its progress
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"result":"projects/2","val`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	resumed, err := checkpoint.Open(path, "scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		listing   string
+		wantPage  int
+		wantItems []project
+	}{
+		{name: "Test Partial Listing", listing: "groups/10/projects", wantPage: 2, wantItems: []project{{ID: 1}, {ID: 2}}},
+		{name: "Test Complete Listing", listing: "groups/11/projects", wantPage: 0, wantItems: []project{{ID: 3}}},
+		{name: "Test New Listing", listing: "groups/12/projects", wantPage: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []project
+			page, err := resumed.Position(tt.listing, &items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page != tt.wantPage || !reflect.DeepEqual(items, tt.wantItems) {
+				t.Errorf("Position() = %d, %v, want %d, %v", page, items, tt.wantPage, tt.wantItems)
+			}
+		})
+	}
+
+	var values []string
+	if done, err := resumed.Result("projects/1", &values); err != nil || !done || !reflect.DeepEqual(values, []string{"a", "b"}) {
+		t.Errorf("Result(projects/1) = %v, %v, %v, want the recorded result", done, values, err)
+	}
+	if done, _ := resumed.Result("projects/2", &values); done {
+		t.Error("Result(projects/2) = true, want the torn result to be dropped")
+	}
+
+	// Progress recorded after the torn line is kept
+	if err := resumed.Complete("projects/2", []string{"c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.Close(false); err != nil {
+		t.Fatal(err)
+	}
+	again, err := checkpoint.Open(path, "scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done, _ := again.Result("projects/2", &values); !done {
+		t.Error("Result(projects/2) = false after resuming twice, want true")
+	}
+	if err := again.Close(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file still exists after a complete scan: %v", err)
+	}
+}
+
+func TestCheckpointOtherScan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.checkpoint")
+	c, err := checkpoint.Open(path, "dependencies group=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkpoint.Open(path, "dependencies group=11"); err == nil || !strings.Contains(err.Error(), "belongs to another scan") {
+		t.Errorf("Open() error = %v, want a scan mismatch", err)
+	}
+}
+
+func TestCheckpointNil(t *testing.T) {
+	var c *checkpoint.Checkpoint
+	var items []project
+	if page, err := c.Position("groups/10/projects", &items); page != 1 || err != nil {
+		t.Errorf("Position() = %d, %v, want the first page", page, err)
+	}
+	if err := c.Advance("groups/10/projects", items, 0); err != nil {
+		t.Error(err)
+	}
+	if done, err := c.Result("projects/1", &items); done || err != nil {
+		t.Errorf("Result() = %v, %v, want no result", done, err)
+	}
+	if err := c.Close(true); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/xanzy/go-gitlab"
+)
+
+// NewGitlabClient creates the Gitlab client for the root flags' token, sending it in the header matching its auth
+// type: JOB-TOKEN for job tokens, an Authorization bearer header for OAuth2 tokens and PRIVATE-TOKEN otherwise.
+func NewGitlabClient(rootFlags RootFlags, options ...gitlab.ClientOptionFunc) (*gitlab.Client, error) {
+	switch rootFlags.AuthType {
+	case AuthJobToken:
+		return gitlab.NewJobClient(rootFlags.Token, options...)
+	case AuthOAuth:
+		return gitlab.NewOAuthClient(rootFlags.Token, options...)
+	default:
+		return gitlab.NewClient(rootFlags.Token, options...)
+	}
+}
@@ -1,10 +1,29 @@
 // Package config contains common configuration values that are used by the various commands and subcommands in the CLI.
 package config
 
+import "time"
+
 // The RootFlags struct contains the common flags that are used by the various commands and subcommands in the CLI.
+// Profile and ConfigFile select the config file profile that fills in the settings not set on the command line, and
+// AuthType records how the Token authenticates. CACert, ClientCert, ClientKey, InsecureSkipVerify and Proxy configure
+// the TLS and proxy settings of the connection to Gitlab. CacheDir, CacheTTL and Offline configure the on-disk cache of
+// Gitlab API responses. TraceFile and OTLPEndpoint configure where OpenTelemetry traces and metrics are exported.
 type RootFlags struct {
-	Quiet   bool
-	Verbose bool
-	BaseURL string
-	Token   string
+	Quiet              bool
+	Verbose            bool
+	BaseURL            string
+	Token              string
+	AuthType           AuthType
+	Profile            string
+	ConfigFile         string
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+	Proxy              string
+	CacheDir           string
+	CacheTTL           time.Duration
+	Offline            bool
+	TraceFile          string
+	OTLPEndpoint       string
 }
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// JobTokenEnvVar names the environment variable holding the CI job token in Gitlab CI jobs.
+	JobTokenEnvVar = "CI_JOB_TOKEN"
+	// keyringService is the service under which tokens are stored in the OS keyring.
+	keyringService = "gitlabctl"
+	// tokenCommandTimeout bounds how long a token_command may run.
+	tokenCommandTimeout = 30 * time.Second
+)
+
+// AuthType is the way a token authenticates with Gitlab, which determines the header it is sent in.
+type AuthType string
+
+const (
+	// AuthPrivateToken sends a personal, project or group access token in the PRIVATE-TOKEN header.
+	AuthPrivateToken AuthType = "private_token"
+	// AuthJobToken sends a CI job token in the JOB-TOKEN header.
+	AuthJobToken AuthType = "job_token"
+	// AuthOAuth sends an OAuth2 access token in the Authorization header as a bearer token.
+	AuthOAuth AuthType = "oauth"
+)
+
+// AuthTypes lists the valid auth types.
+var AuthTypes = []AuthType{AuthPrivateToken, AuthJobToken, AuthOAuth}
+
+// Credential is a token along with the way it authenticates and a description of where it came from.
+type Credential struct {
+	Token    string
+	AuthType AuthType
+	Source   string
+}
+
+// tokenSources returns the names of the token sources set on the profile.
+func (p *Profile) tokenSources() []string {
+	sources := []string{}
+	for name, value := range map[string]string{
+		"token":         p.Token,
+		"token_env":     p.TokenEnv,
+		"token_file":    p.TokenFile,
+		"token_command": p.TokenCommand,
+		"keyring":       p.Keyring,
+	} {
+		if value != "" {
+			sources = append(sources, name)
+		}
+	}
+	return sources
+}
+
+// authType returns the profile's auth type, defaulting to a private token.
+func (p *Profile) authType() AuthType {
+	if p.Auth != "" {
+		return p.Auth
+	}
+	return AuthPrivateToken
+}
+
+// TokenSource describes where the profile's token comes from without revealing it.
+func (p *Profile) TokenSource() string {
+	switch {
+	case p.TokenEnv != "":
+		return "env:" + p.TokenEnv
+	case p.TokenFile != "":
+		return "file:" + p.TokenFile
+	case p.TokenCommand != "":
+		return "command"
+	case p.Keyring != "":
+		return "keyring:" + p.Keyring
+	case p.Token != "":
+		return "config"
+	case p.authType() == AuthJobToken:
+		return "env:" + JobTokenEnvVar
+	default:
+		return "none"
+	}
+}
+
+// Credential resolves the profile's token from its token source. Job token profiles without a token source read the
+// CI_JOB_TOKEN environment variable. An empty token is returned, without error, when the profile has no token source.
+func (p *Profile) Credential(ctx context.Context, getenv func(string) string) (Credential, error) {
+	credential := Credential{AuthType: p.authType(), Source: p.TokenSource()}
+	var err error
+	switch {
+	case p.TokenEnv != "":
+		credential.Token = getenv(p.TokenEnv)
+	case p.TokenFile != "":
+		credential.Token, err = readTokenFile(p.TokenFile)
+	case p.TokenCommand != "":
+		credential.Token, err = runTokenCommand(ctx, p.TokenCommand)
+	case p.Keyring != "":
+		credential.Token, err = lookupKeyring(ctx, p.Keyring)
+	case p.Token != "":
+		credential.Token = p.Token
+	case credential.AuthType == AuthJobToken:
+		credential.Token = getenv(JobTokenEnvVar)
+	}
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to read token from %s: %w", credential.Source, err)
+	}
+	return credential, nil
+}
+
+// ParseAuthType parses an auth type.
+func ParseAuthType(value string) (AuthType, error) {
+	for _, authType := range AuthTypes {
+		if AuthType(strings.ToLower(value)) == authType {
+			return authType, nil
+		}
+	}
+	valid := make([]string, 0, len(AuthTypes))
+	for _, authType := range AuthTypes {
+		valid = append(valid, string(authType))
+	}
+	return "", fmt.Errorf("invalid auth type %q: valid values are %s", value, strings.Join(valid, ", "))
+}
+
+func readTokenFile(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%s is empty", filePath)
+	}
+	return token, nil
+}
+
+// runTokenCommand runs a shell command and returns its trimmed standard output as the token.
+func runTokenCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", errors.New("token_command printed no token")
+	}
+	return token, nil
+}
+
+// DefaultCredentialsPath returns the path of the file-backed keyring used when the OS keyring is unavailable:
+// credentials.yaml next to the default config file.
+func DefaultCredentialsPath() string {
+	configPath := DefaultConfigPath()
+	if configPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(configPath), "credentials.yaml")
+}
+
+// lookupKeyring returns the token stored under name for the gitlabctl service in the OS keyring (the macOS keychain
+// or the Secret Service on Linux), falling back to the credentials file when the keyring is unavailable or holds no
+// such token.
+func lookupKeyring(ctx context.Context, name string) (string, error) {
+	var command []string
+	switch runtime.GOOS {
+	case "darwin":
+		command = []string{"security", "find-generic-password", "-s", keyringService, "-a", name, "-w"}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		command = []string{"secret-tool", "lookup", "service", keyringService, "account", name}
+	}
+	if command != nil {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			output, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+			if token := strings.TrimSpace(string(output)); err == nil && token != "" {
+				return token, nil
+			}
+		}
+	}
+	return lookupCredentialsFile(DefaultCredentialsPath(), name)
+}
+
+// lookupCredentialsFile returns the token stored under name in a credentials file, a YAML map of names to tokens that
+// must not be readable by other users.
+func lookupCredentialsFile(filePath string, name string) (string, error) {
+	if filePath == "" {
+		return "", errors.New("no keyring is available")
+	}
+	info, err := os.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%q is not in the OS keyring and %s does not exist", name, filePath)
+	}
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("%s must not be accessible by other users (chmod 600)", filePath)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	tokens := map[string]string{}
+	if err := yaml.UnmarshalStrict(data, &tokens); err != nil {
+		return "", fmt.Errorf("failed to parse credentials file %s: %w", filePath, err)
+	}
+	token, ok := tokens[name]
+	if !ok || token == "" {
+		return "", fmt.Errorf("%q is not in the OS keyring or %s", name, filePath)
+	}
+	return token, nil
+}
@@ -0,0 +1,141 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/config"
+)
+
+func TestProfileCredential(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	// Hide any OS keyring, so keyring lookups fall back to the credentials file
+	t.Setenv("PATH", "")
+	credentialsPath := config.DefaultCredentialsPath()
+	if err := os.MkdirAll(filepath.Dir(credentialsPath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credentialsPath, []byte("onprem: keyring-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	getenv := env(map[string]string{"TOKEN": "env-token", "CI_JOB_TOKEN": "job-token"})
+
+	tests := []struct {
+		name    string
+		profile config.Profile
+		want    config.Credential
+		wantErr bool
+	}{
+		{
+			name:    "Test Token",
+			profile: config.Profile{Token: "config-token"},
+			want:    config.Credential{Token: "config-token", AuthType: config.AuthPrivateToken, Source: "config"},
+		},
+		{
+			name:    "Test Token Env",
+			profile: config.Profile{TokenEnv: "TOKEN", Auth: config.AuthOAuth},
+			want:    config.Credential{Token: "env-token", AuthType: config.AuthOAuth, Source: "env:TOKEN"},
+		},
+		{
+			name:    "Test Token File",
+			profile: config.Profile{TokenFile: tokenFile},
+			want:    config.Credential{Token: "file-token", AuthType: config.AuthPrivateToken, Source: "file:" + tokenFile},
+		},
+		{
+			name:    "Test Missing Token File",
+			profile: config.Profile{TokenFile: filepath.Join(t.TempDir(), "missing")},
+			wantErr: true,
+		},
+		{
+			name:    "Test Keyring File Fallback",
+			profile: config.Profile{Keyring: "onprem"},
+			want:    config.Credential{Token: "keyring-token", AuthType: config.AuthPrivateToken, Source: "keyring:onprem"},
+		},
+		{
+			name:    "Test Keyring Missing Entry",
+			profile: config.Profile{Keyring: "saas"},
+			wantErr: true,
+		},
+		{
+			name:    "Test Job Token",
+			profile: config.Profile{Auth: config.AuthJobToken},
+			want:    config.Credential{Token: "job-token", AuthType: config.AuthJobToken, Source: "env:CI_JOB_TOKEN"},
+		},
+		{
+			name:    "Test No Source",
+			profile: config.Profile{},
+			want:    config.Credential{AuthType: config.AuthPrivateToken, Source: "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.profile.Credential(context.Background(), getenv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Credential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Credential() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileCredentialCommand(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no shell available")
+	}
+
+	got, err := (&config.Profile{TokenCommand: "echo command-token"}).Credential(context.Background(), os.Getenv)
+	if err != nil || got.Token != "command-token" || got.Source != "command" {
+		t.Errorf("Credential() = %+v, %v, want command-token", got, err)
+	}
+
+	if _, err := (&config.Profile{TokenCommand: "echo denied >&2; exit 1"}).Credential(context.Background(), os.Getenv); err == nil {
+		t.Error("expected an error for a failing token_command")
+	}
+	if _, err := (&config.Profile{TokenCommand: "true"}).Credential(context.Background(), os.Getenv); err == nil {
+		t.Error("expected an error for a token_command printing no token")
+	}
+}
+
+func TestCredentialsFilePermissions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PATH", "")
+	credentialsPath := config.DefaultCredentialsPath()
+	if err := os.MkdirAll(filepath.Dir(credentialsPath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credentialsPath, []byte("onprem: keyring-token\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&config.Profile{Keyring: "onprem"}).Credential(context.Background(), os.Getenv); err == nil {
+		t.Error("expected an error for a credentials file readable by other users")
+	}
+}
+
+func TestApplyJobTokenFallback(t *testing.T) {
+	file := &config.File{}
+	flags := config.RootFlags{}
+	if _, err := file.Apply(context.Background(), &flags, env(map[string]string{"CI_JOB_TOKEN": "job-token"})); err != nil {
+		t.Fatal(err)
+	}
+	if flags.Token != "job-token" || flags.AuthType != config.AuthJobToken {
+		t.Errorf("Apply() = %+v, want the CI job token", flags)
+	}
+
+	flags = config.RootFlags{}
+	if _, err := file.Apply(context.Background(), &flags, env(map[string]string{"CI_JOB_TOKEN": "job-token", "GITLAB_TOKEN": "env-token"})); err != nil {
+		t.Fatal(err)
+	}
+	if flags.Token != "env-token" || flags.AuthType != config.AuthPrivateToken {
+		t.Errorf("Apply() = %+v, want GITLAB_TOKEN to take precedence over the CI job token", flags)
+	}
+}
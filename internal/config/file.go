@@ -0,0 +1,282 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConfigEnvVar names the environment variable holding the path of the config file.
+	ConfigEnvVar = "GITLABCTL_CONFIG"
+	// ProfileEnvVar names the environment variable holding the profile to use.
+	ProfileEnvVar = "GITLABCTL_PROFILE"
+	// TokenEnvVar names the environment variable holding the Gitlab access token.
+	TokenEnvVar = "GITLAB_TOKEN"
+)
+
+// reservedDefaults are flags that cannot be defaulted by a profile, mapped to what to use instead: the profile has
+// dedicated fields for most of them, and telemetry is started before the profile is applied.
+var reservedDefaults = map[string]string{
+	"base-url":             "use the profile field instead",
+	"token":                "use the profile field instead",
+	"profile":              "use the profile field instead",
+	"config":               "use the profile field instead",
+	"ca-cert":              "use the profile field instead",
+	"client-cert":          "use the profile field instead",
+	"client-key":           "use the profile field instead",
+	"insecure-skip-verify": "use the profile field instead",
+	"proxy":                "use the profile field instead",
+	"trace-file":           "telemetry starts before the profile is applied, set the flag instead",
+	"otlp-endpoint":        "telemetry starts before the profile is applied, set the flag or OTEL_EXPORTER_OTLP_ENDPOINT instead",
+}
+
+// Profile holds the settings used to reach one Gitlab instance. BaseURL is required.
+// The token comes from at most one source: the environment variable named by TokenEnv, the file at TokenFile, the
+// standard output of the shell command TokenCommand, the entry named Keyring in the OS keyring, or Token verbatim.
+// Auth selects how the token authenticates, defaulting to a private token; job token profiles without a token source
+// read CI_JOB_TOKEN. CACert is the path of a PEM bundle of additional certificate authorities to trust, ClientCert and
+// ClientKey the PEM certificate and key presented for mutual TLS, InsecureSkipVerify disables certificate verification,
+// and Proxy is the URL of the HTTP proxy to send requests through.
+// Defaults holds default values for command flags, keyed by flag name (e.g. output: json), which are applied to every
+// command that has the flag and on which the flag is not set explicitly.
+type Profile struct {
+	BaseURL            string            `json:"base_url" yaml:"base_url"`
+	Auth               AuthType          `json:"auth" yaml:"auth"`
+	Token              string            `json:"token" yaml:"token"`
+	TokenEnv           string            `json:"token_env" yaml:"token_env"`
+	TokenFile          string            `json:"token_file" yaml:"token_file"`
+	TokenCommand       string            `json:"token_command" yaml:"token_command"`
+	Keyring            string            `json:"keyring" yaml:"keyring"`
+	CACert             string            `json:"ca_cert" yaml:"ca_cert"`
+	ClientCert         string            `json:"client_cert" yaml:"client_cert"`
+	ClientKey          string            `json:"client_key" yaml:"client_key"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	Proxy              string            `json:"proxy" yaml:"proxy"`
+	Defaults           map[string]string `json:"defaults" yaml:"defaults"`
+}
+
+// File is the gitlabctl config file, holding named profiles and the name of the profile used when none is selected.
+type File struct {
+	DefaultProfile string              `json:"default_profile" yaml:"default_profile"`
+	Profiles       map[string]*Profile `json:"profiles" yaml:"profiles"`
+}
+
+// DefaultConfigPath returns the path of the config file used when neither --config nor GITLABCTL_CONFIG is set:
+// $XDG_CONFIG_HOME/gitlabctl/config.yaml, falling back to ~/.config/gitlabctl/config.yaml.
+func DefaultConfigPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gitlabctl", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gitlabctl", "config.yaml")
+}
+
+// ConfigPath returns the config file to load, in order of precedence: the --config flag, the GITLABCTL_CONFIG
+// environment variable, then the default path. The returned bool reports whether the path was chosen explicitly, in
+// which case the file must exist.
+func ConfigPath(flag string, getenv func(string) string) (string, bool) {
+	if flag != "" {
+		return flag, true
+	}
+	if path := getenv(ConfigEnvVar); path != "" {
+		return path, true
+	}
+	return DefaultConfigPath(), false
+}
+
+// LoadFile reads the config file at the provided path. A missing file yields an empty config unless required is set.
+func LoadFile(filePath string, required bool) (*File, error) {
+	file := &File{Profiles: map[string]*Profile{}}
+	if filePath == "" {
+		return file, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filePath, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]*Profile{}
+	}
+	for name, profile := range file.Profiles {
+		if profile == nil {
+			file.Profiles[name] = &Profile{}
+		}
+	}
+	return file, nil
+}
+
+// ProfileNames returns the names of the profiles in the file, sorted.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectedProfile returns the name of the profile to use, in order of precedence: the --profile flag, the
+// GITLABCTL_PROFILE environment variable, then the file's default_profile. An empty name means no profile is used.
+func (f *File) SelectedProfile(flag string, getenv func(string) string) string {
+	if flag != "" {
+		return flag
+	}
+	if name := getenv(ProfileEnvVar); name != "" {
+		return name
+	}
+	return f.DefaultProfile
+}
+
+// Profile returns the named profile, or an error listing the available profiles if there is none by that name.
+func (f *File) Profile(name string) (*Profile, error) {
+	profile, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available profiles: %s)", name, strings.Join(f.ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// Apply fills the root flags from the selected profile. Every setting is taken from the first source that provides it:
+// the command line flag, then its environment variable, then the profile. The token is the exception, as selecting a
+// profile with --profile or GITLABCTL_PROFILE is as explicit as setting GITLAB_TOKEN: it is taken from --token, then
+// the token source of an explicitly selected profile, then GITLAB_TOKEN, then the token source of the default profile.
+// When no source provides a token inside a Gitlab CI job, its CI_JOB_TOKEN is used. The auth type always follows the
+// token: the profile's auth for a profile token, and a private token for --token and GITLAB_TOKEN.
+func (f *File) Apply(ctx context.Context, rootFlags *RootFlags, getenv func(string) string) (*Profile, error) {
+	explicit := rootFlags.Profile != "" || getenv(ProfileEnvVar) != ""
+	rootFlags.Profile = f.SelectedProfile(rootFlags.Profile, getenv)
+	profile := &Profile{}
+	if rootFlags.Profile != "" {
+		var err error
+		if profile, err = f.Profile(rootFlags.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	fromProfile := func() error {
+		credential, err := profile.Credential(ctx, getenv)
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", rootFlags.Profile, err)
+		}
+		if credential.Token != "" {
+			rootFlags.Token = credential.Token
+			rootFlags.AuthType = credential.AuthType
+		}
+		return nil
+	}
+	switch {
+	case rootFlags.Token != "":
+		if rootFlags.AuthType == "" {
+			rootFlags.AuthType = AuthPrivateToken
+		}
+	case explicit:
+		if err := fromProfile(); err != nil {
+			return nil, err
+		}
+	}
+	if rootFlags.Token == "" && getenv(TokenEnvVar) != "" {
+		rootFlags.Token = getenv(TokenEnvVar)
+		rootFlags.AuthType = AuthPrivateToken
+	}
+	if rootFlags.Token == "" && !explicit {
+		if err := fromProfile(); err != nil {
+			return nil, err
+		}
+	}
+	if rootFlags.Token == "" && getenv(JobTokenEnvVar) != "" && profile.Auth == "" {
+		rootFlags.Token = getenv(JobTokenEnvVar)
+		rootFlags.AuthType = AuthJobToken
+	}
+	if rootFlags.AuthType == "" {
+		rootFlags.AuthType = profile.authType()
+	}
+	if rootFlags.BaseURL == "" {
+		rootFlags.BaseURL = profile.BaseURL
+	}
+	if rootFlags.CACert == "" {
+		rootFlags.CACert = profile.CACert
+	}
+	if rootFlags.ClientCert == "" && rootFlags.ClientKey == "" {
+		rootFlags.ClientCert = profile.ClientCert
+		rootFlags.ClientKey = profile.ClientKey
+	}
+	rootFlags.InsecureSkipVerify = rootFlags.InsecureSkipVerify || profile.InsecureSkipVerify
+	if rootFlags.Proxy == "" {
+		rootFlags.Proxy = profile.Proxy
+	}
+	return profile, nil
+}
+
+// Validate returns the problems with the profile. isFlag reports whether a name is a flag of some gitlabctl command, so
+// that misspelled defaults are caught.
+func (p *Profile) Validate(isFlag func(name string) bool) []string {
+	problems := []string{}
+	if p.BaseURL == "" {
+		problems = append(problems, "base_url is required")
+	} else if _, err := ParseInstanceURL(p.BaseURL); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if sources := p.tokenSources(); len(sources) > 1 {
+		sort.Strings(sources)
+		problems = append(problems, fmt.Sprintf("only one token source may be set, got %s", strings.Join(sources, ", ")))
+	}
+	if p.Auth != "" {
+		if _, err := ParseAuthType(string(p.Auth)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if p.TokenFile != "" {
+		if _, err := os.Stat(p.TokenFile); err != nil {
+			problems = append(problems, fmt.Sprintf("token_file: %v", err))
+		}
+	}
+	for _, file := range []struct{ name, path string }{
+		{"ca_cert", p.CACert},
+		{"client_cert", p.ClientCert},
+		{"client_key", p.ClientKey},
+	} {
+		if file.path != "" {
+			if _, err := os.Stat(file.path); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", file.name, err))
+			}
+		}
+	}
+	if (p.ClientCert == "") != (p.ClientKey == "") {
+		problems = append(problems, "client_cert and client_key must be set together")
+	}
+	if p.Proxy != "" {
+		if proxy, err := url.Parse(p.Proxy); err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			problems = append(problems, fmt.Sprintf("invalid proxy %q: expected a URL such as http://proxy:3128", p.Proxy))
+		}
+	}
+	names := make([]string, 0, len(p.Defaults))
+	for name := range p.Defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if instead, reserved := reservedDefaults[name]; reserved {
+			problems = append(problems, fmt.Sprintf("defaults: %s cannot be defaulted, %s", name, instead))
+		} else if isFlag != nil && !isFlag(name) {
+			problems = append(problems, fmt.Sprintf("defaults: unknown flag %q", name))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,273 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/config"
+)
+
+const configYAML = `
+default_profile: saas
+profiles:
+  saas:
+    base_url: https://gitlab.com/api/v4
+    token_env: SAAS_TOKEN
+    defaults:
+      output: json
+  onprem:
+    base_url: gitlab.internal.example.com
+    token: onprem-token
+    proxy: http://proxy.internal.example.com:3128
+  oauth:
+    base_url: https://gitlab.com/api/v4
+    auth: oauth
+    token_env: OAUTH_TOKEN
+`
+
+func env(values map[string]string) func(string) string {
+	return func(name string) string {
+		return values[name]
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	file, err := config.LoadFile(writeConfig(t, configYAML), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file.ProfileNames(), []string{"oauth", "onprem", "saas"}) {
+		t.Errorf("ProfileNames() = %v", file.ProfileNames())
+	}
+
+	if _, err := config.LoadFile(writeConfig(t, "profiles:\n  saas:\n    baseurl: x\n"), true); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if file, err := config.LoadFile(missing, false); err != nil || len(file.Profiles) != 0 {
+		t.Errorf("LoadFile(missing, false) = %v, %v, want an empty config", file, err)
+	}
+	if _, err := config.LoadFile(missing, true); err == nil {
+		t.Error("expected an error for a missing required config file")
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		flag         string
+		env          map[string]string
+		wantPath     string
+		wantRequired bool
+	}{
+		{
+			name:         "Test Flag Over Env",
+			flag:         "/flag.yaml",
+			env:          map[string]string{"GITLABCTL_CONFIG": "/env.yaml"},
+			wantPath:     "/flag.yaml",
+			wantRequired: true,
+		},
+		{
+			name:         "Test Env",
+			env:          map[string]string{"GITLABCTL_CONFIG": "/env.yaml"},
+			wantPath:     "/env.yaml",
+			wantRequired: true,
+		},
+		{
+			name:     "Test Default",
+			wantPath: config.DefaultConfigPath(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, required := config.ConfigPath(tt.flag, env(tt.env))
+			if path != tt.wantPath || required != tt.wantRequired {
+				t.Errorf("ConfigPath() = %s, %v, want %s, %v", path, required, tt.wantPath, tt.wantRequired)
+			}
+		})
+	}
+}
+
+// TestApplyPrecedence documents the precedence between the sources of each setting: command line flags win over
+// environment variables, which win over the selected profile. The token of a profile selected with --profile or
+// GITLABCTL_PROFILE wins over GITLAB_TOKEN, which only wins over the token of the default profile.
+func TestApplyPrecedence(t *testing.T) {
+	file, err := config.LoadFile(writeConfig(t, configYAML), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		flags   config.RootFlags
+		env     map[string]string
+		want    config.RootFlags
+		wantErr bool
+	}{
+		{
+			name:  "Test Default Profile",
+			flags: config.RootFlags{},
+			env:   map[string]string{"SAAS_TOKEN": "saas-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "saas", BaseURL: "https://gitlab.com/api/v4",
+				Token: "saas-token"},
+		},
+		{
+			name:  "Test Profile Env Over Default Profile",
+			flags: config.RootFlags{},
+			env:   map[string]string{"GITLABCTL_PROFILE": "onprem"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "onprem", BaseURL: "gitlab.internal.example.com",
+				Token: "onprem-token", Proxy: "http://proxy.internal.example.com:3128"},
+		},
+		{
+			name:  "Test Profile Flag Over Profile Env",
+			flags: config.RootFlags{Profile: "saas"},
+			env:   map[string]string{"GITLABCTL_PROFILE": "onprem", "SAAS_TOKEN": "saas-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "saas", BaseURL: "https://gitlab.com/api/v4",
+				Token: "saas-token"},
+		},
+		{
+			name:  "Test Token Env Over Default Profile",
+			flags: config.RootFlags{},
+			env:   map[string]string{"GITLAB_TOKEN": "env-token", "SAAS_TOKEN": "saas-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "saas", BaseURL: "https://gitlab.com/api/v4",
+				Token: "env-token"},
+		},
+		{
+			name:  "Test Profile Flag Over Token Env",
+			flags: config.RootFlags{Profile: "onprem"},
+			env:   map[string]string{"GITLAB_TOKEN": "env-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "onprem", BaseURL: "gitlab.internal.example.com",
+				Token: "onprem-token", Proxy: "http://proxy.internal.example.com:3128"},
+		},
+		{
+			name:  "Test Profile Env Over Token Env",
+			flags: config.RootFlags{},
+			env:   map[string]string{"GITLABCTL_PROFILE": "onprem", "GITLAB_TOKEN": "env-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "onprem", BaseURL: "gitlab.internal.example.com",
+				Token: "onprem-token", Proxy: "http://proxy.internal.example.com:3128"},
+		},
+		{
+			name:  "Test Token Env When Selected Profile Has No Token",
+			flags: config.RootFlags{Profile: "saas"},
+			env:   map[string]string{"GITLAB_TOKEN": "env-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "saas", BaseURL: "https://gitlab.com/api/v4",
+				Token: "env-token"},
+		},
+		{
+			name:  "Test Flags Over Env And Profile",
+			flags: config.RootFlags{Profile: "onprem", BaseURL: "https://other.example.com", Token: "flag-token", Proxy: "http://other:8080"},
+			env:   map[string]string{"GITLAB_TOKEN": "env-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "onprem", BaseURL: "https://other.example.com",
+				Token: "flag-token", Proxy: "http://other:8080"},
+		},
+		{
+			name:  "Test Token Flag Is A Private Token",
+			flags: config.RootFlags{Profile: "oauth", Token: "flag-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "oauth", BaseURL: "https://gitlab.com/api/v4",
+				Token: "flag-token"},
+		},
+		{
+			name:  "Test Token Env Is A Private Token",
+			flags: config.RootFlags{Profile: "oauth"},
+			env:   map[string]string{"GITLAB_TOKEN": "env-token"},
+			want: config.RootFlags{AuthType: config.AuthPrivateToken, Profile: "oauth", BaseURL: "https://gitlab.com/api/v4",
+				Token: "env-token"},
+		},
+		{
+			name:  "Test Profile Token Uses Profile Auth",
+			flags: config.RootFlags{Profile: "oauth"},
+			env:   map[string]string{"GITLAB_TOKEN": "env-token", "OAUTH_TOKEN": "oauth-token"},
+			want: config.RootFlags{AuthType: config.AuthOAuth, Profile: "oauth", BaseURL: "https://gitlab.com/api/v4",
+				Token: "oauth-token"},
+		},
+		{
+			name:    "Test Unknown Profile",
+			flags:   config.RootFlags{Profile: "missing"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := tt.flags
+			_, err := file.Apply(context.Background(), &flags, env(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(flags, tt.want) {
+				t.Errorf("Apply() = %+v, want %+v", flags, tt.want)
+			}
+		})
+	}
+
+	empty := &config.File{}
+	flags := config.RootFlags{BaseURL: "https://gitlab.com"}
+	if _, err := empty.Apply(context.Background(), &flags, env(map[string]string{"GITLAB_TOKEN": "env-token"})); err != nil || flags.Token != "env-token" {
+		t.Errorf("Apply() without profiles = %+v, %v", flags, err)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	isFlag := func(name string) bool { return name == "output" }
+	tests := []struct {
+		name    string
+		profile config.Profile
+		want    []string
+	}{
+		{
+			name:    "Test Valid",
+			profile: config.Profile{BaseURL: "https://gitlab.com", TokenEnv: "TOKEN", Defaults: map[string]string{"output": "json"}},
+			want:    []string{},
+		},
+		{
+			name:    "Test Missing Base URL",
+			profile: config.Profile{},
+			want:    []string{"base_url is required"},
+		},
+		{
+			name:    "Test Token Sources",
+			profile: config.Profile{BaseURL: "https://gitlab.com", Token: "t", TokenEnv: "TOKEN"},
+			want:    []string{"only one token source may be set, got token, token_env"},
+		},
+		{
+			name:    "Test Proxy",
+			profile: config.Profile{BaseURL: "https://gitlab.com", Proxy: "proxy:3128"},
+			want:    []string{`invalid proxy "proxy:3128": expected a URL such as http://proxy:3128`},
+		},
+		{
+			name:    "Test Defaults",
+			profile: config.Profile{BaseURL: "https://gitlab.com", Defaults: map[string]string{"token": "t", "outptu": "json"}},
+			want:    []string{`defaults: unknown flag "outptu"`, "defaults: token cannot be defaulted, use the profile field instead"},
+		},
+		{
+			name:    "Test Telemetry Defaults",
+			profile: config.Profile{BaseURL: "https://gitlab.com", Defaults: map[string]string{"trace-file": "trace.jsonl", "otlp-endpoint": "http://localhost:4318"}},
+			want: []string{
+				"defaults: otlp-endpoint cannot be defaulted, telemetry starts before the profile is applied, set the flag or OTEL_EXPORTER_OTLP_ENDPOINT instead",
+				"defaults: trace-file cannot be defaulted, telemetry starts before the profile is applied, set the flag instead",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.Validate(isFlag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
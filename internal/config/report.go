@@ -0,0 +1,76 @@
+package config
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// ProfileSummary describes a profile without revealing its token. Problems lists what validation found wrong with it.
+type ProfileSummary struct {
+	Name               string            `json:"name" yaml:"name"`
+	Default            bool              `json:"default" yaml:"default"`
+	Selected           bool              `json:"selected" yaml:"selected"`
+	BaseURL            string            `json:"base_url" yaml:"base_url"`
+	Auth               AuthType          `json:"auth" yaml:"auth"`
+	TokenSource        string            `json:"token_source" yaml:"token_source"`
+	CACert             string            `json:"ca_cert,omitempty" yaml:"ca_cert,omitempty"`
+	ClientCert         string            `json:"client_cert,omitempty" yaml:"client_cert,omitempty"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	Proxy              string            `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	Defaults           map[string]string `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Problems           []string          `json:"problems" yaml:"problems"`
+}
+
+// ProfileResources represents the collection of profiles in a config file.
+type ProfileResources struct {
+	Profiles []*ProfileSummary `json:"profiles" yaml:"profiles"`
+}
+
+// ProfileReport represents a report of the profiles in a config file, and any errors loading or validating it.
+type ProfileReport struct {
+	ConfigFile string           `json:"config_file" yaml:"config_file"`
+	Resources  ProfileResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
+
+// SummarizeProfiles describes every profile in the file, sorted by name, marking the default and selected profiles.
+// When validate is set, each profile's problems are recorded, and one error is reported per invalid profile.
+func SummarizeProfiles(filePath string, file *File, selected string, validate bool, isFlag func(string) bool) ProfileReport {
+	report := ProfileReport{
+		ConfigFile: filePath,
+		Resources:  ProfileResources{Profiles: []*ProfileSummary{}},
+		ErrorLog:   nonfatal.NewErrorLog(),
+	}
+	if selected != "" {
+		if _, err := file.Profile(selected); err != nil {
+			report.AddError(nonfatal.New("profile", selected, err))
+		}
+	}
+	for _, name := range file.ProfileNames() {
+		profile := file.Profiles[name]
+		summary := &ProfileSummary{
+			Name:               name,
+			Default:            name == file.DefaultProfile,
+			Selected:           name == selected,
+			BaseURL:            profile.BaseURL,
+			Auth:               profile.authType(),
+			TokenSource:        profile.TokenSource(),
+			CACert:             profile.CACert,
+			ClientCert:         profile.ClientCert,
+			InsecureSkipVerify: profile.InsecureSkipVerify,
+			Proxy:              profile.Proxy,
+			Defaults:           profile.Defaults,
+			Problems:           []string{},
+		}
+		if validate {
+			summary.Problems = profile.Validate(isFlag)
+			if len(summary.Problems) > 0 {
+				report.AddError(nonfatal.Newf("profile", name, nil, "profile %s is invalid", name))
+			}
+		}
+		report.Resources.Profiles = append(report.Resources.Profiles, summary)
+	}
+	return report
+}
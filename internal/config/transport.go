@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// InsecureSkipVerifyWarning is reported by every command run with TLS certificate verification disabled.
+const InsecureSkipVerifyWarning = "WARNING: TLS certificate verification is disabled (--insecure-skip-verify), " +
+	"the connection to Gitlab is not protected against interception"
+
+// NewHTTPClient returns the HTTP client used to reach Gitlab when the root flags require more than the default
+// client: trusting the additional certificate authorities in the CACert bundle, presenting the ClientCert and
+// ClientKey pair for mutual TLS, skipping certificate verification, or sending requests through Proxy rather than the
+// proxy named by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. It returns nil when none is set.
+func NewHTTPClient(rootFlags RootFlags) (*http.Client, error) {
+	if rootFlags.CACert == "" && rootFlags.Proxy == "" && rootFlags.ClientCert == "" && rootFlags.ClientKey == "" &&
+		!rootFlags.InsecureSkipVerify {
+		return nil, nil
+	}
+	if (rootFlags.ClientCert == "") != (rootFlags.ClientKey == "") {
+		return nil, errors.New("--client-cert and --client-key must be set together")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if rootFlags.Proxy != "" {
+		proxy, err := url.Parse(rootFlags.Proxy)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q: expected a URL such as http://proxy:3128", rootFlags.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	// Skipping verification is an explicit opt-in, and every command reports it with InsecureSkipVerifyWarning
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: rootFlags.InsecureSkipVerify} //nolint:gosec
+	if rootFlags.CACert != "" {
+		pem, err := os.ReadFile(rootFlags.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", rootFlags.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if rootFlags.ClientCert != "" {
+		certificate, err := tls.LoadX509KeyPair(rootFlags.ClientCert, rootFlags.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,171 @@
+package config_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/config"
+)
+
+// writeServerCA writes the certificate of a TLS test server to a PEM file.
+func writeServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeClientCert writes a self-signed client certificate and its key to PEM files.
+func writeClientCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gitlabctl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestNewHTTPClientTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert, MinVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+	caCert := writeServerCA(t, srv)
+	clientCert, clientKey := writeClientCert(t)
+
+	tests := []struct {
+		name       string
+		flags      config.RootFlags
+		wantErr    bool
+		wantStatus int
+	}{
+		{
+			name:    "Test Untrusted",
+			flags:   config.RootFlags{ClientCert: clientCert, ClientKey: clientKey},
+			wantErr: true,
+		},
+		{
+			name:       "Test CA Bundle",
+			flags:      config.RootFlags{CACert: caCert},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Test Client Certificate",
+			flags:      config.RootFlags{CACert: caCert, ClientCert: clientCert, ClientKey: clientKey},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Test Insecure Skip Verify",
+			flags:      config.RootFlags{InsecureSkipVerify: true},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := config.NewHTTPClient(tt.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Get(srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	proxied := ""
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := config.NewHTTPClient(config.RootFlags{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://gitlab.example.com/api/v4/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if proxied != "http://gitlab.example.com/api/v4/version" {
+		t.Errorf("proxy received %q, want the Gitlab request", proxied)
+	}
+}
+
+func TestNewHTTPClientErrors(t *testing.T) {
+	if client, err := config.NewHTTPClient(config.RootFlags{}); client != nil || err != nil {
+		t.Errorf("NewHTTPClient() = %v, %v, want the default client", client, err)
+	}
+
+	clientCert, clientKey := writeClientCert(t)
+	tests := []struct {
+		name  string
+		flags config.RootFlags
+	}{
+		{name: "Test Invalid Proxy", flags: config.RootFlags{Proxy: "proxy:3128"}},
+		{name: "Test Missing CA Bundle", flags: config.RootFlags{CACert: filepath.Join(t.TempDir(), "missing.pem")}},
+		{name: "Test CA Bundle Without Certificates", flags: config.RootFlags{CACert: clientKey}},
+		{name: "Test Client Certificate Without Key", flags: config.RootFlags{ClientCert: clientCert}},
+		{name: "Test Mismatched Client Key", flags: config.RootFlags{ClientCert: clientCert, ClientKey: clientCert}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := config.NewHTTPClient(tt.flags); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -1,17 +1,83 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"strings"
 )
 
-// NormalizeGitlabURL normalizes the Gitlab URL to ensure it is in the correct format for the Gitlab API, which requires
-// the URL to end with "/api/v4" and to start with "https".
-func NormalizeGitlabURL(url string) string {
-	if !strings.HasSuffix(url, "/api/v4") {
-		url = strings.TrimSuffix(url, "/") + "/api/v4"
+const (
+	apiPath     = "/api/v4"
+	graphQLPath = "/api/graphql"
+)
+
+// InstanceURL is the root URL of a Gitlab instance, such as https://gitlab.com, http://localhost:8080 for a local
+// instance, or https://example.com/gitlab for an instance installed under a relative URL root. The REST and GraphQL
+// API endpoints are derived from it.
+type InstanceURL struct {
+	root url.URL
+}
+
+// ParseInstanceURL parses the URL of a Gitlab instance, given either as the instance root or as its REST or GraphQL
+// API endpoint (e.g. gitlab.com, https://gitlab.com/api/v4 or https://example.com/gitlab/api/graphql). URLs without a
+// scheme default to https. Only http and https URLs are accepted, and they must not carry credentials, a query or a
+// fragment.
+func ParseInstanceURL(raw string) (InstanceURL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return InstanceURL{}, errors.New("base URL is empty")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
 	}
-	if !strings.HasPrefix(url, "https") {
-		url = "https://" + url
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return InstanceURL{}, fmt.Errorf("invalid base URL %q: %w", raw, err)
 	}
-	return url
+	switch {
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return InstanceURL{}, fmt.Errorf("invalid base URL %q: scheme must be http or https", raw)
+	case parsed.Host == "":
+		return InstanceURL{}, fmt.Errorf("invalid base URL %q: missing host", raw)
+	case parsed.User != nil:
+		return InstanceURL{}, fmt.Errorf("invalid base URL %q: credentials must not be part of the URL", parsed.Redacted())
+	case parsed.RawQuery != "" || parsed.Fragment != "":
+		return InstanceURL{}, fmt.Errorf("invalid base URL %q: must not have a query or fragment", raw)
+	}
+
+	path := strings.TrimRight(parsed.Path, "/")
+	switch {
+	case strings.HasSuffix(path, apiPath):
+		path = strings.TrimSuffix(path, apiPath)
+	case strings.HasSuffix(path, graphQLPath):
+		path = strings.TrimSuffix(path, graphQLPath)
+	case strings.HasSuffix(path, "/api"):
+		path = strings.TrimSuffix(path, "/api")
+	case strings.Contains(path+"/", "/api/"):
+		return InstanceURL{}, fmt.Errorf("invalid base URL %q: only the v4 API is supported", raw)
+	}
+
+	return InstanceURL{root: url.URL{Scheme: parsed.Scheme, Host: strings.ToLower(parsed.Host), Path: path}}, nil
+}
+
+// String returns the root URL of the instance, without a trailing slash.
+func (u InstanceURL) String() string {
+	return u.root.String()
+}
+
+// APIURL returns the URL of the instance's REST API, e.g. https://gitlab.com/api/v4.
+func (u InstanceURL) APIURL() string {
+	return u.join(apiPath)
+}
+
+// GraphQLURL returns the URL of the instance's GraphQL API, e.g. https://gitlab.com/api/graphql.
+func (u InstanceURL) GraphQLURL() string {
+	return u.join(graphQLPath)
+}
+
+func (u InstanceURL) join(path string) string {
+	endpoint := u.root
+	endpoint.Path += path
+	return endpoint.String()
 }
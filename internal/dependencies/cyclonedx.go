@@ -0,0 +1,301 @@
+package dependencies
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CycloneDXSpecVersion is the version of the CycloneDX specification BOMs are generated for.
+const CycloneDXSpecVersion = "1.5"
+
+// BOM is a CycloneDX JSON bill of materials, modelling the subset of the specification gitlabctl populates.
+type BOM struct {
+	BOMFormat       string              `json:"bomFormat"`
+	SpecVersion     string              `json:"specVersion"`
+	SerialNumber    string              `json:"serialNumber"`
+	Version         int                 `json:"version"`
+	Metadata        BOMMetadata         `json:"metadata"`
+	Components      []*BOMComponent     `json:"components"`
+	Dependencies    []*BOMDependency    `json:"dependencies"`
+	Vulnerabilities []*BOMVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// BOMMetadata describes when and by which tool a BOM was generated, and the component it describes.
+type BOMMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     BOMTools      `json:"tools"`
+	Component *BOMComponent `json:"component"`
+}
+
+// BOMTools lists the tools used to generate a BOM.
+type BOMTools struct {
+	Components []*BOMComponent `json:"components"`
+}
+
+// BOMComponent is a CycloneDX component. Projects are modelled as application components with their dependencies
+// nested beneath them.
+type BOMComponent struct {
+	BOMRef             string                 `json:"bom-ref,omitempty"`
+	Type               string                 `json:"type"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version,omitempty"`
+	PURL               string                 `json:"purl,omitempty"`
+	Licenses           []BOMLicenseChoice     `json:"licenses,omitempty"`
+	ExternalReferences []BOMExternalReference `json:"externalReferences,omitempty"`
+	Properties         []BOMProperty          `json:"properties,omitempty"`
+	Components         []*BOMComponent        `json:"components,omitempty"`
+}
+
+// BOMLicenseChoice wraps a license, as required by the CycloneDX licenses array.
+type BOMLicenseChoice struct {
+	License BOMLicense `json:"license"`
+}
+
+// BOMLicense is a license identified either by its SPDX identifier or by name.
+type BOMLicense struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// BOMExternalReference links a component to an external resource, such as its source repository.
+type BOMExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// BOMProperty is a name-value pair holding information that has no dedicated CycloneDX field.
+type BOMProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// BOMDependency records the components a component directly depends on.
+type BOMDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// BOMVulnerability is a vulnerability affecting one or more components of the BOM.
+type BOMVulnerability struct {
+	BOMRef  string                 `json:"bom-ref"`
+	ID      string                 `json:"id"`
+	Source  BOMVulnerabilitySource `json:"source"`
+	Ratings []BOMRating            `json:"ratings"`
+	Affects []BOMVulnerabilityRef  `json:"affects"`
+}
+
+// BOMVulnerabilitySource identifies where a vulnerability was reported.
+type BOMVulnerabilitySource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// BOMRating is the severity rating of a vulnerability.
+type BOMRating struct {
+	Severity string `json:"severity"`
+}
+
+// BOMVulnerabilityRef references a component affected by a vulnerability.
+type BOMVulnerabilityRef struct {
+	Ref string `json:"ref"`
+}
+
+// purlTypes maps the package managers reported by Gitlab dependency scanning to package URL types.
+var purlTypes = map[string]string{
+	"bundler":    "gem",
+	"npm":        "npm",
+	"yarn":       "npm",
+	"pnpm":       "npm",
+	"pip":        "pypi",
+	"pipenv":     "pypi",
+	"poetry":     "pypi",
+	"setuptools": "pypi",
+	"conda":      "conda",
+	"maven":      "maven",
+	"gradle":     "maven",
+	"sbt":        "maven",
+	"composer":   "composer",
+	"nuget":      "nuget",
+	"go":         "golang",
+	"conan":      "conan",
+	"cargo":      "cargo",
+	"cocoapods":  "cocoapods",
+	"swift":      "swift",
+}
+
+// PackageURL builds the package URL (purl) of a dependency. Unknown package managers use the generic purl type.
+func PackageURL(packageManager string, name string, version string) string {
+	purlType, ok := purlTypes[strings.ToLower(packageManager)]
+	if !ok {
+		purlType = "generic"
+	}
+
+	namespace := ""
+	switch purlType {
+	case "maven":
+		if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		} else if i := strings.LastIndex(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	case "npm", "golang", "composer":
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	}
+
+	purl := "pkg:" + purlType + "/"
+	if namespace != "" {
+		segments := strings.Split(namespace, "/")
+		for i, segment := range segments {
+			segments[i] = escapePURLSegment(segment)
+		}
+		purl += strings.Join(segments, "/") + "/"
+	}
+	purl += escapePURLSegment(name)
+	if version != "" {
+		purl += "@" + escapePURLSegment(version)
+	}
+	return purl
+}
+
+// escapePURLSegment percent-encodes a purl path segment. The purl specification requires @ to be encoded, which
+// url.PathEscape leaves as is.
+func escapePURLSegment(segment string) string {
+	return strings.ReplaceAll(url.PathEscape(segment), "@", "%40")
+}
+
+// NewBOM converts a dependencies report into a CycloneDX BOM. A report for a single project describes that project,
+// with its dependencies as top level components. A report for a group describes the group, with every project as a
+// component and its dependencies nested beneath it. A report for a single project that holds no project, because its
+// dependencies could not be listed, has nothing to describe and returns an error.
+func NewBOM(report *GitlabResourceReport, toolVersion string) (*BOM, error) {
+	if report.GroupID == "" && len(report.Resources.Projects) != 1 {
+		return nil, fmt.Errorf("a CycloneDX SBOM describes a single project or a group, but the report holds %d projects and no group", len(report.Resources.Projects))
+	}
+	bom := &BOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  CycloneDXSpecVersion,
+		SerialNumber: newSerialNumber(),
+		Version:      1,
+		Metadata: BOMMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: BOMTools{Components: []*BOMComponent{
+				{Type: "application", Name: "gitlabctl", Version: toolVersion},
+			}},
+		},
+		Components:   []*BOMComponent{},
+		Dependencies: []*BOMDependency{},
+	}
+	vulnerabilities := map[string]*BOMVulnerability{}
+
+	projectComponents := []*BOMComponent{}
+	for _, project := range report.Resources.Projects {
+		projectComponent := &BOMComponent{
+			BOMRef: project.ProjectPath,
+			Type:   "application",
+			Name:   project.ProjectPath,
+		}
+		if project.WebURL != "" {
+			projectComponent.ExternalReferences = []BOMExternalReference{{Type: "vcs", URL: project.WebURL}}
+		}
+		dependency := &BOMDependency{Ref: projectComponent.BOMRef, DependsOn: []string{}}
+
+		components := map[string]*BOMComponent{}
+		for _, dep := range project.Dependencies {
+			purl := PackageURL(dep.PackageManager, dep.Name, dep.Version)
+			ref := project.ProjectPath + "#" + purl
+			location := BOMProperty{Name: "gitlab:dependency_scanning:location", Value: dep.Location}
+
+			if component, ok := components[ref]; ok {
+				component.Properties = append(component.Properties, location)
+			} else {
+				component = &BOMComponent{
+					BOMRef:  ref,
+					Type:    "library",
+					Name:    dep.Name,
+					Version: dep.Version,
+					PURL:    purl,
+					Properties: []BOMProperty{
+						{Name: "gitlab:dependency_scanning:package_manager", Value: dep.PackageManager},
+						location,
+					},
+				}
+				for _, license := range dep.Licenses {
+					if license.SPDXIdentifier != "" {
+						component.Licenses = append(component.Licenses, BOMLicenseChoice{License: BOMLicense{ID: license.SPDXIdentifier, URL: license.URL}})
+					} else {
+						component.Licenses = append(component.Licenses, BOMLicenseChoice{License: BOMLicense{Name: license.Name, URL: license.URL}})
+					}
+				}
+				components[ref] = component
+				projectComponent.Components = append(projectComponent.Components, component)
+				dependency.DependsOn = append(dependency.DependsOn, ref)
+				bom.Dependencies = append(bom.Dependencies, &BOMDependency{Ref: ref, DependsOn: []string{}})
+			}
+
+			for _, vuln := range dep.Vulnerabilities {
+				vulnRef := fmt.Sprintf("%s#vulnerability-%d", project.ProjectPath, vuln.ID)
+				bomVuln, ok := vulnerabilities[vulnRef]
+				if !ok {
+					bomVuln = &BOMVulnerability{
+						BOMRef:  vulnRef,
+						ID:      vuln.Name,
+						Source:  BOMVulnerabilitySource{Name: "Gitlab", URL: vuln.URL},
+						Ratings: []BOMRating{{Severity: strings.ToLower(vuln.Severity)}},
+						Affects: []BOMVulnerabilityRef{},
+					}
+					vulnerabilities[vulnRef] = bomVuln
+					bom.Vulnerabilities = append(bom.Vulnerabilities, bomVuln)
+				}
+				if !containsRef(bomVuln.Affects, ref) {
+					bomVuln.Affects = append(bomVuln.Affects, BOMVulnerabilityRef{Ref: ref})
+				}
+			}
+		}
+
+		bom.Dependencies = append(bom.Dependencies, dependency)
+		projectComponents = append(projectComponents, projectComponent)
+	}
+
+	if report.GroupID == "" {
+		bom.Metadata.Component = projectComponents[0]
+		bom.Components = projectComponents[0].Components
+		if bom.Components == nil {
+			bom.Components = []*BOMComponent{}
+		}
+		projectComponents[0].Components = nil
+	} else {
+		bom.Metadata.Component = &BOMComponent{BOMRef: report.GroupID, Type: "application", Name: report.GroupID}
+		bom.Components = projectComponents
+		groupDependency := &BOMDependency{Ref: report.GroupID, DependsOn: []string{}}
+		for _, project := range projectComponents {
+			groupDependency.DependsOn = append(groupDependency.DependsOn, project.BOMRef)
+		}
+		bom.Dependencies = append(bom.Dependencies, groupDependency)
+	}
+
+	return bom, nil
+}
+
+func containsRef(refs []BOMVulnerabilityRef, ref string) bool {
+	for _, r := range refs {
+		if r.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// newSerialNumber generates a random (version 4) UUID URN, as required for the CycloneDX serial number.
+func newSerialNumber() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
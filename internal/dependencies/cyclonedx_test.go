@@ -0,0 +1,97 @@
+package dependencies_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/dependencies"
+)
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		packageManager string
+		packageName    string
+		version        string
+		want           string
+	}{
+		{name: "Test npm", packageManager: "yarn", packageName: "lodash", version: "4.17.21", want: "pkg:npm/lodash@4.17.21"},
+		{name: "Test npm Scoped", packageManager: "npm", packageName: "@babel/core", version: "7.0.0", want: "pkg:npm/%40babel/core@7.0.0"},
+		{name: "Test Maven Colon", packageManager: "maven", packageName: "org.apache.commons:commons-lang3", version: "3.12.0", want: "pkg:maven/org.apache.commons/commons-lang3@3.12.0"},
+		{name: "Test Go", packageManager: "go", packageName: "github.com/spf13/cobra", version: "v1.8.0", want: "pkg:golang/github.com/spf13/cobra@v1.8.0"},
+		{name: "Test Bundler", packageManager: "bundler", packageName: "rails", version: "7.1.0", want: "pkg:gem/rails@7.1.0"},
+		{name: "Test Unknown", packageManager: "mystery", packageName: "thing", version: "", want: "pkg:generic/thing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependencies.PackageURL(tt.packageManager, tt.packageName, tt.version); got != tt.want {
+				t.Errorf("PackageURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBOM(t *testing.T) {
+	lodash := &dependencies.Dependency{
+		Name:           "lodash",
+		Version:        "4.17.20",
+		PackageManager: "yarn",
+		Location:       "yarn.lock",
+		Vulnerabilities: []dependencies.Vulnerability{
+			{ID: 9, Name: "CVE-2021-23337", Severity: "High"},
+		},
+		Licenses: []dependencies.License{{Name: "MIT License", SPDXIdentifier: "MIT"}},
+	}
+	lodashNested := &dependencies.Dependency{Name: "lodash", Version: "4.17.20", PackageManager: "yarn", Location: "web/yarn.lock"}
+
+	projectReport := &dependencies.GitlabResourceReport{
+		Resources: dependencies.GitlabResources{Projects: []*dependencies.ProjectDependencies{
+			{ProjectID: 1, ProjectPath: "acme/app", Dependencies: []*dependencies.Dependency{lodash, lodashNested}},
+		}},
+	}
+	bom, err := dependencies.NewBOM(projectReport, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("NewBOM() format = %s %s, want CycloneDX 1.5", bom.BOMFormat, bom.SpecVersion)
+	}
+	if bom.Metadata.Component.Name != "acme/app" {
+		t.Errorf("NewBOM() metadata component = %s, want acme/app", bom.Metadata.Component.Name)
+	}
+	if len(bom.Components) != 1 {
+		t.Fatalf("NewBOM() returned %d components, want 1 de-duplicated component", len(bom.Components))
+	}
+	if len(bom.Components[0].Properties) != 3 {
+		t.Errorf("NewBOM() component properties = %v, want package manager and two locations", bom.Components[0].Properties)
+	}
+	if bom.Components[0].Licenses[0].License.ID != "MIT" {
+		t.Errorf("NewBOM() license = %+v, want SPDX ID MIT", bom.Components[0].Licenses[0].License)
+	}
+	if len(bom.Vulnerabilities) != 1 || bom.Vulnerabilities[0].Ratings[0].Severity != "high" || bom.Vulnerabilities[0].Affects[0].Ref != bom.Components[0].BOMRef {
+		t.Errorf("NewBOM() vulnerabilities = %+v, want one high vulnerability affecting lodash", bom.Vulnerabilities)
+	}
+
+	groupReport := &dependencies.GitlabResourceReport{
+		GroupID: "acme",
+		Resources: dependencies.GitlabResources{Projects: []*dependencies.ProjectDependencies{
+			{ProjectID: 1, ProjectPath: "acme/app", Dependencies: []*dependencies.Dependency{lodash}},
+			{ProjectID: 2, ProjectPath: "acme/api", Dependencies: []*dependencies.Dependency{}},
+		}},
+	}
+	bom, err = dependencies.NewBOM(groupReport, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bom.Metadata.Component.Name != "acme" || len(bom.Components) != 2 || len(bom.Components[0].Components) != 1 {
+		t.Errorf("NewBOM() for a group = %+v, want the group with two nested projects", bom.Metadata.Component)
+	}
+}
+
+func TestNewBOMWithoutProject(t *testing.T) {
+	// The dependencies of the selected project could not be listed
+	report := &dependencies.GitlabResourceReport{Resources: dependencies.GitlabResources{Projects: []*dependencies.ProjectDependencies{}}}
+	if bom, err := dependencies.NewBOM(report, "1.0.0"); err == nil {
+		t.Errorf("NewBOM() = %+v, want an error for a report without a project", bom.Metadata.Component)
+	}
+}
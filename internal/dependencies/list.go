@@ -0,0 +1,165 @@
+// Package dependencies holds the data structures and logic necessary to export the dependency lists Gitlab dependency
+// scanning produces for projects, either as a gitlabctl report or as a CycloneDX SBOM.
+package dependencies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/projects"
+	"github.com/Method-Security/gitlabctl/internal/telemetry"
+	"github.com/xanzy/go-gitlab"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EnumerateDependenciesOptions holds the options for enumerating dependencies.
+// The ProjectID and GroupID fields select a single project, or every project in a group and its subgroups. One of them
+// is required.
+// The Checkpoint field, when set, records the projects listed and the dependencies fetched so far, so an interrupted
+// group enumeration can resume.
+type EnumerateDependenciesOptions struct {
+	ProjectID  string                 `json:"project_id" yaml:"project_id"`
+	GroupID    string                 `json:"group_id" yaml:"group_id"`
+	Checkpoint *checkpoint.Checkpoint `json:"-" yaml:"-"`
+}
+
+// dependency is the shape of an entry returned by the Gitlab project dependencies API, which go-gitlab does not model.
+type dependency struct {
+	Name               string `json:"name"`
+	Version            string `json:"version"`
+	PackageManager     string `json:"package_manager"`
+	DependencyFilePath string `json:"dependency_file_path"`
+	Vulnerabilities    []struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Severity string `json:"severity"`
+		URL      string `json:"url"`
+	} `json:"vulnerabilities"`
+	Licenses []struct {
+		Name           string `json:"name"`
+		SPDXIdentifier string `json:"spdx_identifier"`
+		URL            string `json:"url"`
+	} `json:"licenses"`
+}
+
+// EnumerateDependencies enumerates the dependency lists of the selected project, or of every project in the selected
+// group. Failing to fetch the dependencies of the selected project is fatal. Failures to fetch the dependencies of a
+// project in a group are recorded as non-fatal errors in the report, and are not recorded in the checkpoint, so a
+// resumed enumeration fetches them again.
+func EnumerateDependencies(ctx context.Context, baseURL string, options *EnumerateDependenciesOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		GroupID:   options.GroupID,
+		Resources: GitlabResources{Projects: []*ProjectDependencies{}},
+		ErrorLog:  nonfatal.NewErrorLog(),
+		BaseURL:   baseURL,
+	}
+
+	var targets []*gitlab.Project
+	switch {
+	case options.ProjectID != "":
+		project, _, err := client.Projects.GetProject(options.ProjectID, &gitlab.GetProjectOptions{})
+		if err != nil {
+			return report, err
+		}
+		targets = []*gitlab.Project{project}
+	case options.GroupID != "":
+		projectReport, err := projects.EnumerateProjectsForGroup(ctx, baseURL, client, &projects.EnumerateProjectsOptions{
+			Mine:       false,
+			Archived:   false,
+			GroupID:    options.GroupID,
+			Checkpoint: options.Checkpoint,
+		})
+		if err != nil {
+			return report, err
+		}
+		report.Merge(projectReport.ErrorLog)
+		targets = projectReport.Resources.Projects
+	default:
+		return report, errors.New("either a project ID or a group ID is required")
+	}
+
+	for _, project := range targets {
+		id := fmt.Sprintf("projects/%d/dependencies", project.ID)
+		deps := []*Dependency{}
+		done, err := options.Checkpoint.Result(id, &deps)
+		if err != nil {
+			return report, err
+		}
+		if !done {
+			projectCtx, span := telemetry.StartSpan(ctx, "list project dependencies",
+				attribute.Int("gitlab.project.id", project.ID), attribute.String("gitlab.project.path", project.PathWithNamespace))
+			deps, err = ListProjectDependencies(projectCtx, client, project.ID)
+			telemetry.EndSpan(span, err)
+			if err != nil && options.ProjectID != "" {
+				return report, fmt.Errorf("failed to list dependencies for project %s: %w", project.PathWithNamespace, err)
+			}
+			if err != nil {
+				report.AddError(nonfatal.Newf("project", project.PathWithNamespace, err, "failed to list dependencies for project %s: %s", project.PathWithNamespace, err.Error()))
+				continue
+			}
+			if err := options.Checkpoint.Complete(id, deps); err != nil {
+				return report, err
+			}
+		}
+		report.Resources.Projects = append(report.Resources.Projects, &ProjectDependencies{
+			ProjectID:    project.ID,
+			ProjectPath:  project.PathWithNamespace,
+			WebURL:       project.WebURL,
+			Dependencies: deps,
+		})
+	}
+
+	return report, nil
+}
+
+// ListProjectDependencies lists every dependency Gitlab dependency scanning detected for a project.
+func ListProjectDependencies(ctx context.Context, client *gitlab.Client, projectID int) ([]*Dependency, error) {
+	listOptions := gitlab.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+	result := []*Dependency{}
+	for {
+		req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("projects/%d/dependencies", projectID), &listOptions, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return nil, err
+		}
+		var deps []*dependency
+		resp, err := client.Do(req, &deps)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dep := range deps {
+			result = append(result, newDependency(dep))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
+	}
+	return result, nil
+}
+
+func newDependency(dep *dependency) *Dependency {
+	result := &Dependency{
+		Name:            dep.Name,
+		Version:         dep.Version,
+		PackageManager:  dep.PackageManager,
+		Location:        dep.DependencyFilePath,
+		Vulnerabilities: []Vulnerability{},
+		Licenses:        []License{},
+	}
+	for _, vuln := range dep.Vulnerabilities {
+		result.Vulnerabilities = append(result.Vulnerabilities, Vulnerability{ID: vuln.ID, Name: vuln.Name, Severity: vuln.Severity, URL: vuln.URL})
+	}
+	for _, license := range dep.Licenses {
+		result.Licenses = append(result.Licenses, License{Name: license.Name, SPDXIdentifier: license.SPDXIdentifier, URL: license.URL})
+	}
+	return result
+}
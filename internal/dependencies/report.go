@@ -0,0 +1,56 @@
+package dependencies
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// Vulnerability represents a vulnerability Gitlab has linked to a dependency.
+type Vulnerability struct {
+	ID       int    `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Severity string `json:"severity" yaml:"severity"`
+	URL      string `json:"url" yaml:"url"`
+}
+
+// License represents a license Gitlab has detected for a dependency.
+type License struct {
+	Name           string `json:"name" yaml:"name"`
+	SPDXIdentifier string `json:"spdx_identifier,omitempty" yaml:"spdx_identifier,omitempty"`
+	URL            string `json:"url" yaml:"url"`
+}
+
+// Dependency represents a single dependency detected by Gitlab dependency scanning. The Location field is the path of
+// the dependency file (e.g. a lock file) the dependency was detected in.
+type Dependency struct {
+	Name            string          `json:"name" yaml:"name"`
+	Version         string          `json:"version" yaml:"version"`
+	PackageManager  string          `json:"package_manager" yaml:"package_manager"`
+	Location        string          `json:"location" yaml:"location"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities" yaml:"vulnerabilities"`
+	Licenses        []License       `json:"licenses" yaml:"licenses"`
+}
+
+// ProjectDependencies represents the dependency list of a single Gitlab project.
+type ProjectDependencies struct {
+	ProjectID    int           `json:"project_id" yaml:"project_id"`
+	ProjectPath  string        `json:"project_path" yaml:"project_path"`
+	WebURL       string        `json:"web_url" yaml:"web_url"`
+	Dependencies []*Dependency `json:"dependencies" yaml:"dependencies"`
+}
+
+// GitlabResources represents a collection of Gitlab project dependency lists.
+type GitlabResources struct {
+	Projects []*ProjectDependencies `json:"projects" yaml:"projects"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+// The GroupID field is set when the dependencies of a whole group were enumerated.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	GroupID   string          `json:"group_id,omitempty" yaml:"group_id,omitempty"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
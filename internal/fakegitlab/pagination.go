@@ -0,0 +1,77 @@
+package fakegitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultPerPage is the page size the Gitlab API uses when per_page is not set.
+const defaultPerPage = 20
+
+// paginate returns the requested page of a response whose body is a JSON array, along with the Gitlab pagination
+// headers. Responses whose body is not an array are returned unchanged.
+func paginate(response Response, query url.Values, maxPerPage int) Response {
+	var items []json.RawMessage
+	if err := json.Unmarshal(response.Body, &items); err != nil {
+		return response
+	}
+
+	page := positive(query.Get("page"), 1)
+	perPage := min(positive(query.Get("per_page"), defaultPerPage), max(maxPerPage, 1))
+	totalPages := max((len(items)+perPage-1)/perPage, 1)
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	pageItems := items[start:end]
+	if pageItems == nil {
+		pageItems = []json.RawMessage{}
+	}
+
+	headers := map[string]string{}
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	headers["X-Page"] = strconv.Itoa(page)
+	headers["X-Per-Page"] = strconv.Itoa(perPage)
+	headers["X-Total"] = strconv.Itoa(len(items))
+	headers["X-Total-Pages"] = strconv.Itoa(totalPages)
+	headers["X-Next-Page"] = ""
+	headers["X-Prev-Page"] = ""
+	links := []string{}
+	if page < totalPages {
+		headers["X-Next-Page"] = strconv.Itoa(page + 1)
+		links = append(links, pageLink(query, page+1, "next"))
+	}
+	if page > 1 {
+		headers["X-Prev-Page"] = strconv.Itoa(page - 1)
+		links = append(links, pageLink(query, page-1, "prev"))
+	}
+	links = append(links, pageLink(query, 1, "first"), pageLink(query, totalPages, "last"))
+	headers["Link"] = strings.Join(links, ", ")
+
+	return Response{
+		Status:  response.Status,
+		Headers: headers,
+		Body:    mustMarshal(pageItems),
+	}
+}
+
+func positive(value string, fallback int) int {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return fallback
+	}
+	return parsed
+}
+
+func pageLink(query url.Values, page int, rel string) string {
+	values := url.Values{}
+	for name, value := range query {
+		values[name] = value
+	}
+	values.Set("page", strconv.Itoa(page))
+	return fmt.Sprintf(`<?%s>; rel="%s"`, values.Encode(), rel)
+}
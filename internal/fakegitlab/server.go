@@ -0,0 +1,234 @@
+// Package fakegitlab implements a fake Gitlab API, serving fixture files from a directory, that is used to test
+// gitlabctl commands end to end and to demo the CLI offline.
+//
+// A request is answered with the fixture file named after its path relative to the API root: GET /api/v4/projects/1
+// is answered with projects/1.json, and POST /api/graphql with graphql.POST.json. Path segments are kept escaped, so
+// GET /api/v4/groups/acme%2Fplatform is answered with groups/acme%2Fplatform.json. Requests without a fixture are
+// answered with a 404, and requests without a token with a 401.
+//
+// Fixtures holding a JSON array are paginated like the Gitlab API, honouring the page and per_page query parameters
+// and setting the X-Page, X-Per-Page, X-Total, X-Total-Pages, X-Next-Page, X-Prev-Page and Link headers.
+//
+// A fixture can instead describe the response in full, to return errors such as a 403 or a 429:
+//
+//	{"$status": 403, "$headers": {"X-Reason": "..."}, "$body": {"message": "403 Forbidden"}}
+//
+// or a sequence of responses, the last of which is repeated, to model transient failures:
+//
+//	{"$responses": [{"$status": 429, "$headers": {"Retry-After": "0"}}, {"$body": [{"id": 1}]}]}
+package fakegitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxPerPage is the maximum page size of paginated responses, matching the Gitlab API.
+const DefaultMaxPerPage = 100
+
+// Response is a fake response to a request. A zero Status is a 200, and a nil Body an empty body.
+type Response struct {
+	Status  int               `json:"$status"`
+	Headers map[string]string `json:"$headers"`
+	Body    json.RawMessage   `json:"$body"`
+}
+
+// envelope is a fixture that describes its response, or sequence of responses, in full.
+type envelope struct {
+	Response
+	Responses []Response `json:"$responses"`
+}
+
+// Request is a request received by the Server. Path is relative to the API root, while URLPath is the full path.
+type Request struct {
+	Method  string
+	Path    string
+	URLPath string
+	Query   string
+	Header  http.Header
+	Body    string
+}
+
+// Server is a fake Gitlab API serving fixtures from a directory, optionally overridden by responses registered with
+// Handle. MaxPerPage caps the page size of paginated responses, so pagination can be exercised with small fixtures.
+type Server struct {
+	MaxPerPage int
+
+	dir       string
+	mu        sync.Mutex
+	overrides map[string][]Response
+	served    map[string]int
+	requests  []Request
+}
+
+// New creates a Server serving the fixtures in dir. An empty dir serves only the responses registered with Handle.
+func New(dir string) *Server {
+	return &Server{
+		MaxPerPage: DefaultMaxPerPage,
+		dir:        dir,
+		overrides:  map[string][]Response{},
+		served:     map[string]int{},
+		requests:   []Request{},
+	}
+}
+
+// NewTestServer starts an httptest.Server for a Server serving the fixtures in dir. The caller must close it.
+func NewTestServer(dir string) (*Server, *httptest.Server) {
+	server := New(dir)
+	return server, httptest.NewServer(server)
+}
+
+// Handle registers the responses to a method and path, such as GET projects/1, taking precedence over any fixture.
+// Responses are returned in order, the last one repeating.
+func (s *Server) Handle(method string, path string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[routeKey(method, path)] = responses
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+// Transport returns an http.RoundTripper that serves requests directly from the Server, without a network listener.
+func (s *Server) Transport() http.RoundTripper {
+	return roundTripper{handler: s}
+}
+
+type roundTripper struct {
+	handler http.Handler
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// ServeHTTP answers a request with its registered response or fixture.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+	}
+	path := apiPath(r)
+	key := routeKey(r.Method, path)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method:  r.Method,
+		Path:    path,
+		URLPath: r.URL.EscapedPath(),
+		Query:   r.URL.RawQuery,
+		Header:  r.Header.Clone(),
+		Body:    string(body),
+	})
+	attempt := s.served[key]
+	s.served[key]++
+	responses, overridden := s.overrides[key]
+	s.mu.Unlock()
+
+	if r.Header.Get("PRIVATE-TOKEN") == "" && r.Header.Get("JOB-TOKEN") == "" && r.Header.Get("Authorization") == "" {
+		writeResponse(w, Response{Status: http.StatusUnauthorized, Body: json.RawMessage(`{"message":"401 Unauthorized"}`)})
+		return
+	}
+
+	if !overridden {
+		var err error
+		responses, err = s.loadFixture(r.Method, path)
+		if errors.Is(err, os.ErrNotExist) {
+			writeResponse(w, Response{Status: http.StatusNotFound, Body: json.RawMessage(`{"message":"404 Not Found"}`)})
+			return
+		}
+		if err != nil {
+			writeResponse(w, Response{Status: http.StatusInternalServerError, Body: mustMarshal(map[string]string{"message": err.Error()})})
+			return
+		}
+	}
+
+	response := responses[min(attempt, len(responses)-1)]
+	if r.Method == http.MethodGet && (response.Status == 0 || response.Status == http.StatusOK) {
+		response = paginate(response, r.URL.Query(), s.MaxPerPage)
+	}
+	writeResponse(w, response)
+}
+
+// loadFixture reads the responses to a method and path from the fixture directory.
+func (s *Server) loadFixture(method string, path string) ([]Response, error) {
+	if s.dir == "" {
+		return nil, os.ErrNotExist
+	}
+	name := path + ".json"
+	if method != http.MethodGet {
+		name = path + "." + method + ".json"
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		fixture := envelope{}
+		if err := json.Unmarshal(trimmed, &fixture); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", name, err)
+		}
+		if len(fixture.Responses) > 0 {
+			return fixture.Responses, nil
+		}
+		if fixture.Status != 0 || fixture.Body != nil || fixture.Headers != nil {
+			return []Response{fixture.Response}, nil
+		}
+	}
+	return []Response{{Body: trimmed}}, nil
+}
+
+// apiPath returns the path of a request relative to the API root, e.g. projects/1 or graphql.
+func apiPath(r *http.Request) string {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/")
+	if i := strings.Index(path, "api/v4/"); i >= 0 {
+		return path[i+len("api/v4/"):]
+	}
+	if i := strings.Index(path, "api/"); i >= 0 {
+		return path[i+len("api/"):]
+	}
+	return path
+}
+
+func routeKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + strings.Trim(path, "/")
+}
+
+func writeResponse(w http.ResponseWriter, response Response) {
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	if response.Body != nil {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(response.Body)
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,227 @@
+package fakegitlab_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/fakegitlab"
+)
+
+func writeFixture(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, url string, token string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestServerFixtures(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "projects/1.json", `{"id": 1}`)
+	writeFixture(t, dir, "groups/acme%2Fplatform.json", `{"id": 11}`)
+	writeFixture(t, dir, "projects/2/dependencies.json", `{"$status": 403, "$headers": {"X-Reason": "denied"}, "$body": {"message": "403 Forbidden"}}`)
+	_, srv := fakegitlab.NewTestServer(dir)
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Test Fixture",
+			path:       "/api/v4/projects/1",
+			token:      "t",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id": 1}`,
+		},
+		{
+			name:       "Test Escaped Path",
+			path:       "/api/v4/groups/acme%2Fplatform",
+			token:      "t",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id": 11}`,
+		},
+		{
+			name:       "Test Envelope",
+			path:       "/api/v4/projects/2/dependencies",
+			token:      "t",
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"message": "403 Forbidden"}`,
+		},
+		{
+			name:       "Test Missing Fixture",
+			path:       "/api/v4/projects/404",
+			token:      "t",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"message":"404 Not Found"}`,
+		},
+		{
+			name:       "Test Missing Token",
+			path:       "/api/v4/projects/1",
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"message":"401 Unauthorized"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, srv.URL+tt.path, tt.token)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServerPagination(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "projects.json", `[{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}, {"id": 5}]`)
+	server, srv := fakegitlab.NewTestServer(dir)
+	defer srv.Close()
+	server.MaxPerPage = 2
+
+	tests := []struct {
+		name    string
+		query   string
+		wantIDs []int
+		headers map[string]string
+	}{
+		{
+			name:    "Test First Page",
+			query:   "?per_page=100",
+			wantIDs: []int{1, 2},
+			headers: map[string]string{"X-Page": "1", "X-Per-Page": "2", "X-Total": "5", "X-Total-Pages": "3", "X-Next-Page": "2", "X-Prev-Page": ""},
+		},
+		{
+			name:    "Test Middle Page",
+			query:   "?page=2&per_page=2",
+			wantIDs: []int{3, 4},
+			headers: map[string]string{"X-Page": "2", "X-Next-Page": "3", "X-Prev-Page": "1"},
+		},
+		{
+			name:    "Test Last Page",
+			query:   "?page=3&per_page=2",
+			wantIDs: []int{5},
+			headers: map[string]string{"X-Page": "3", "X-Next-Page": "", "X-Prev-Page": "2"},
+		},
+		{
+			name:    "Test Past Last Page",
+			query:   "?page=4&per_page=2",
+			wantIDs: []int{},
+			headers: map[string]string{"X-Page": "4", "X-Next-Page": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, srv.URL+"/api/v4/projects"+tt.query, "t")
+			items := []struct {
+				ID int `json:"id"`
+			}{}
+			if err := json.Unmarshal([]byte(body), &items); err != nil {
+				t.Fatal(err)
+			}
+			ids := []int{}
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("ids = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+			for name, want := range tt.headers {
+				if got := resp.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServerResponseSequence(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "user.json", `{"$responses": [{"$status": 429, "$headers": {"Retry-After": "0"}}, {"$body": {"id": 1}}]}`)
+	server, srv := fakegitlab.NewTestServer(dir)
+	defer srv.Close()
+	server.Handle(http.MethodGet, "projects/1", fakegitlab.Response{Status: http.StatusInternalServerError})
+
+	wantStatuses := []int{http.StatusTooManyRequests, http.StatusOK, http.StatusOK}
+	for i, want := range wantStatuses {
+		resp, _ := get(t, srv.URL+"/api/v4/user", "t")
+		if resp.StatusCode != want {
+			t.Errorf("attempt %d: status = %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+
+	resp, _ := get(t, srv.URL+"/api/v4/projects/1", "t")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("override status = %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 4 {
+		t.Fatalf("got %d requests, want 4", len(requests))
+	}
+	if requests[3].Method != http.MethodGet || requests[3].Path != "projects/1" {
+		t.Errorf("last request = %s %s, want GET projects/1", requests[3].Method, requests[3].Path)
+	}
+}
+
+func TestServerTransport(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "graphql.POST.json", `{"data": {}}`)
+	client := &http.Client{Transport: fakegitlab.New(dir).Transport()}
+
+	req, err := http.NewRequest(http.MethodPost, "https://gitlab.example.com/api/graphql", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"data": {}}` {
+		t.Errorf("got %d %s, want 200 {\"data\": {}}", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,71 @@
+package groups
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Baseline holds the expected values of security relevant group settings. Settings that are left unset in the
+// baseline file are not evaluated.
+// MaxTwoFactorGracePeriod is the largest acceptable two-factor grace period, in hours.
+// RequireIPRestriction requires at least one IP restriction range to be configured.
+// RequireSAMLGroupLinks requires SAML group links to be configured on the group's top-level group.
+type Baseline struct {
+	RequireTwoFactorAuthentication *bool   `json:"require_two_factor_authentication,omitempty" yaml:"require_two_factor_authentication,omitempty"`
+	MaxTwoFactorGracePeriod        *int    `json:"max_two_factor_grace_period,omitempty" yaml:"max_two_factor_grace_period,omitempty"`
+	ProjectCreationLevel           *string `json:"project_creation_level,omitempty" yaml:"project_creation_level,omitempty"`
+	ShareWithGroupLock             *bool   `json:"share_with_group_lock,omitempty" yaml:"share_with_group_lock,omitempty"`
+	PreventForkingOutsideGroup     *bool   `json:"prevent_forking_outside_group,omitempty" yaml:"prevent_forking_outside_group,omitempty"`
+	RequireIPRestriction           *bool   `json:"require_ip_restriction,omitempty" yaml:"require_ip_restriction,omitempty"`
+	RequireSAMLGroupLinks          *bool   `json:"require_saml_group_links,omitempty" yaml:"require_saml_group_links,omitempty"`
+}
+
+// LoadBaseline reads a Baseline from the YAML (or JSON) file at the provided path.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &Baseline{}
+	if err := yaml.UnmarshalStrict(data, baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline file %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// EvaluateBaseline compares the settings of an audited group against the baseline, returning a Drift for every
+// setting that does not match. A nil baseline yields no drift.
+func EvaluateBaseline(group *GroupAudit, baseline *Baseline) []Drift {
+	drift := []Drift{}
+	if baseline == nil {
+		return drift
+	}
+
+	checkBool := func(setting string, expected *bool, actual bool) {
+		if expected != nil && *expected != actual {
+			drift = append(drift, Drift{Setting: setting, Expected: strconv.FormatBool(*expected), Actual: strconv.FormatBool(actual)})
+		}
+	}
+
+	checkBool("require_two_factor_authentication", baseline.RequireTwoFactorAuthentication, group.RequireTwoFactorAuthentication)
+	if baseline.MaxTwoFactorGracePeriod != nil && group.TwoFactorGracePeriod > *baseline.MaxTwoFactorGracePeriod {
+		drift = append(drift, Drift{
+			Setting:  "two_factor_grace_period",
+			Expected: fmt.Sprintf("<= %d", *baseline.MaxTwoFactorGracePeriod),
+			Actual:   strconv.Itoa(group.TwoFactorGracePeriod),
+		})
+	}
+	if baseline.ProjectCreationLevel != nil && *baseline.ProjectCreationLevel != group.ProjectCreationLevel {
+		drift = append(drift, Drift{Setting: "project_creation_level", Expected: *baseline.ProjectCreationLevel, Actual: group.ProjectCreationLevel})
+	}
+	checkBool("share_with_group_lock", baseline.ShareWithGroupLock, group.ShareWithGroupLock)
+	checkBool("prevent_forking_outside_group", baseline.PreventForkingOutsideGroup, group.PreventForkingOutsideGroup)
+	checkBool("ip_restriction", baseline.RequireIPRestriction, len(group.IPRestrictionRanges) > 0)
+	checkBool("saml_group_links", baseline.RequireSAMLGroupLinks, group.SAMLGroupLinksConfigured)
+
+	return drift
+}
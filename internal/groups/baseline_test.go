@@ -0,0 +1,86 @@
+package groups_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/groups"
+)
+
+func TestLoadBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.yaml")
+	content := "require_two_factor_authentication: true\nmax_two_factor_grace_period: 48\nproject_creation_level: maintainer\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err := groups.LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() returned error: %v", err)
+	}
+	if baseline.RequireTwoFactorAuthentication == nil || !*baseline.RequireTwoFactorAuthentication {
+		t.Errorf("LoadBaseline() require_two_factor_authentication = %v, want true", baseline.RequireTwoFactorAuthentication)
+	}
+	if baseline.MaxTwoFactorGracePeriod == nil || *baseline.MaxTwoFactorGracePeriod != 48 {
+		t.Errorf("LoadBaseline() max_two_factor_grace_period = %v, want 48", baseline.MaxTwoFactorGracePeriod)
+	}
+	if baseline.ShareWithGroupLock != nil {
+		t.Errorf("LoadBaseline() share_with_group_lock = %v, want nil", *baseline.ShareWithGroupLock)
+	}
+
+	if err := os.WriteFile(path, []byte("require_2fa: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := groups.LoadBaseline(path); err == nil {
+		t.Errorf("LoadBaseline() with an unknown setting returned no error")
+	}
+}
+
+func TestEvaluateBaseline(t *testing.T) {
+	enabled := true
+	gracePeriod := 48
+	maintainer := "maintainer"
+
+	tests := []struct {
+		name     string
+		group    *groups.GroupAudit
+		baseline *groups.Baseline
+		want     []groups.Drift
+	}{
+		{
+			name:     "Test Nil Baseline",
+			group:    &groups.GroupAudit{},
+			baseline: nil,
+			want:     []groups.Drift{},
+		},
+		{
+			name:     "Test Compliant Group",
+			group:    &groups.GroupAudit{RequireTwoFactorAuthentication: true, TwoFactorGracePeriod: 24, ProjectCreationLevel: "maintainer"},
+			baseline: &groups.Baseline{RequireTwoFactorAuthentication: &enabled, MaxTwoFactorGracePeriod: &gracePeriod, ProjectCreationLevel: &maintainer},
+			want:     []groups.Drift{},
+		},
+		{
+			name:     "Test Drifted Group",
+			group:    &groups.GroupAudit{TwoFactorGracePeriod: 72, ProjectCreationLevel: "developer", IPRestrictionRanges: []string{}},
+			baseline: &groups.Baseline{RequireTwoFactorAuthentication: &enabled, MaxTwoFactorGracePeriod: &gracePeriod, ProjectCreationLevel: &maintainer, RequireIPRestriction: &enabled, RequireSAMLGroupLinks: &enabled},
+			want: []groups.Drift{
+				{Setting: "require_two_factor_authentication", Expected: "true", Actual: "false"},
+				{Setting: "two_factor_grace_period", Expected: "<= 48", Actual: "72"},
+				{Setting: "project_creation_level", Expected: "maintainer", Actual: "developer"},
+				{Setting: "ip_restriction", Expected: "true", Actual: "false"},
+				{Setting: "saml_group_links", Expected: "true", Actual: "false"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groups.EvaluateBaseline(tt.group, tt.baseline)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluateBaseline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package groups holds the data structures and logic necessary to audit the security relevant settings of Gitlab
+// groups and their subgroups, comparing them against a baseline.
+package groups
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/projects"
+	"github.com/xanzy/go-gitlab"
+)
+
+// EnumerateGroupsOptions holds the options for auditing groups.
+// The GroupID field is the ID or full path of the group to audit, which may be a subgroup.
+// The GroupName field is the name of the top-level group to audit, resolved with projects.FindGroupByName.
+// If neither is set, every top-level group visible to the authenticated user is audited.
+// The Baseline field holds the expected group settings. If nil, no drift is reported.
+type EnumerateGroupsOptions struct {
+	GroupID   string    `json:"group_id" yaml:"group_id"`
+	GroupName string    `json:"group_name" yaml:"group_name"`
+	Baseline  *Baseline `json:"baseline" yaml:"baseline"`
+}
+
+// EnumerateGroups audits the settings of the selected top-level groups and all of their subgroups. The function
+// returns a GitlabResourceReport containing the audited groups and non-fatal errors encountered during enumeration.
+func EnumerateGroups(ctx context.Context, baseURL string, options *EnumerateGroupsOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		Resources: GitlabResources{Groups: []*GroupAudit{}},
+		ErrorLog:  nonfatal.NewErrorLog(),
+		BaseURL:   baseURL,
+	}
+
+	topLevelGroups, err := findTopLevelGroups(ctx, client, options)
+	if err != nil {
+		return report, err
+	}
+
+	for _, topLevelGroup := range topLevelGroups {
+		root, err := auditGroup(client, fmt.Sprintf("%d", topLevelGroup.ID), nil)
+		if err != nil {
+			report.AddError(nonfatal.New("group", topLevelGroup.FullPath, err))
+			continue
+		}
+		if err := lookupSAMLGroupLinks(ctx, client, root); err != nil {
+			report.AddError(nonfatal.Newf("group", root.FullPath, err, "failed to list SAML group links for %s: %s", root.FullPath, err.Error()))
+		}
+		root.Drift = EvaluateBaseline(root, options.Baseline)
+		report.Resources.Groups = append(report.Resources.Groups, root)
+
+		err = projects.WalkSubgroups(ctx, client, fmt.Sprintf("%d", root.ID), nil, func(subgroup *gitlab.Group) error {
+			audit, err := auditGroup(client, fmt.Sprintf("%d", subgroup.ID), root)
+			if err != nil {
+				return err
+			}
+			audit.Drift = EvaluateBaseline(audit, options.Baseline)
+			report.Resources.Groups = append(report.Resources.Groups, audit)
+			return nil
+		}, func(err error) {
+			report.AddError(nonfatal.New("group", root.FullPath, err))
+		})
+		if err != nil {
+			report.AddError(nonfatal.New("group", root.FullPath, err))
+		}
+	}
+
+	return report, nil
+}
+
+func findTopLevelGroups(ctx context.Context, client *gitlab.Client, options *EnumerateGroupsOptions) ([]*gitlab.Group, error) {
+	if options.GroupName != "" {
+		group, err := projects.FindGroupByName(ctx, client, options.GroupName)
+		if err != nil {
+			return nil, err
+		}
+		return []*gitlab.Group{group}, nil
+	}
+	if options.GroupID != "" {
+		group, _, err := client.Groups.GetGroup(options.GroupID, &gitlab.GetGroupOptions{WithProjects: gitlab.Ptr(false)})
+		if err != nil {
+			return nil, err
+		}
+		return []*gitlab.Group{group}, nil
+	}
+
+	listOptions := gitlab.ListGroupsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+		TopLevelOnly: gitlab.Ptr(true),
+	}
+	groups := []*gitlab.Group{}
+	for {
+		page, resp, err := client.Groups.ListGroups(&listOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.ListOptions.Page = resp.NextPage
+	}
+	return groups, nil
+}
+
+// lookupSAMLGroupLinks records the SAML group links that apply to an audited group. SAML group links can only be
+// configured on top-level groups and apply to all of their subgroups, so when the group is a subgroup its ancestors
+// are walked up to the top-level group the links are read from.
+func lookupSAMLGroupLinks(ctx context.Context, client *gitlab.Client, audit *GroupAudit) error {
+	topLevelID, parentID := audit.ID, audit.ParentID
+	for parentID != 0 {
+		parent, _, err := client.Groups.GetGroup(parentID, &gitlab.GetGroupOptions{WithProjects: gitlab.Ptr(false)}, gitlab.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to get parent group %d: %w", parentID, err)
+		}
+		topLevelID, parentID = parent.ID, parent.ParentID
+	}
+	links, _, err := client.Groups.ListGroupSAMLLinks(topLevelID, gitlab.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	audit.SAMLGroupLinks = len(links)
+	audit.SAMLGroupLinksConfigured = len(links) > 0
+	return nil
+}
+
+// auditGroup fetches the full details of a group, which unlike the list endpoints include the IP restriction and
+// forking settings, and converts them into a GroupAudit. Subgroups inherit the SAML group links of the provided root.
+func auditGroup(client *gitlab.Client, groupID string, root *GroupAudit) (*GroupAudit, error) {
+	group, _, err := client.Groups.GetGroup(groupID, &gitlab.GetGroupOptions{WithProjects: gitlab.Ptr(false)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group %s: %w", groupID, err)
+	}
+
+	audit := NewGroupAudit(group)
+	if root != nil {
+		audit.SAMLGroupLinks = root.SAMLGroupLinks
+		audit.SAMLGroupLinksConfigured = root.SAMLGroupLinksConfigured
+	}
+	return audit, nil
+}
+
+// NewGroupAudit converts a Gitlab group into a GroupAudit, without any SAML group links or drift.
+func NewGroupAudit(group *gitlab.Group) *GroupAudit {
+	audit := &GroupAudit{
+		ID:                             group.ID,
+		Name:                           group.Name,
+		FullPath:                       group.FullPath,
+		ParentID:                       group.ParentID,
+		WebURL:                         group.WebURL,
+		Visibility:                     string(group.Visibility),
+		RequireTwoFactorAuthentication: group.RequireTwoFactorAuth,
+		TwoFactorGracePeriod:           group.TwoFactorGracePeriod,
+		ProjectCreationLevel:           string(group.ProjectCreationLevel),
+		ShareWithGroupLock:             group.ShareWithGroupLock,
+		PreventForkingOutsideGroup:     group.PreventForkingOutsideGroup,
+		IPRestrictionRanges:            []string{},
+		Drift:                          []Drift{},
+	}
+	for _, ipRange := range strings.Split(group.IPRestrictionRanges, ",") {
+		if ipRange = strings.TrimSpace(ipRange); ipRange != "" {
+			audit.IPRestrictionRanges = append(audit.IPRestrictionRanges, ipRange)
+		}
+	}
+	return audit
+}
@@ -0,0 +1,49 @@
+package groups
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// Drift represents a group setting that does not match the value required by the baseline.
+type Drift struct {
+	Setting  string `json:"setting" yaml:"setting"`
+	Expected string `json:"expected" yaml:"expected"`
+	Actual   string `json:"actual" yaml:"actual"`
+}
+
+// GroupAudit represents the security relevant settings of a single Gitlab group, along with any drift from the baseline.
+// SAML group links are configured on top-level groups only, so subgroups report the links of their top-level group.
+// Group links synchronize group membership from the identity provider; the Gitlab API does not expose whether SAML
+// single sign-on is enforced, so it is not audited.
+type GroupAudit struct {
+	ID                             int      `json:"id" yaml:"id"`
+	Name                           string   `json:"name" yaml:"name"`
+	FullPath                       string   `json:"full_path" yaml:"full_path"`
+	ParentID                       int      `json:"parent_id" yaml:"parent_id"`
+	WebURL                         string   `json:"web_url" yaml:"web_url"`
+	Visibility                     string   `json:"visibility" yaml:"visibility"`
+	RequireTwoFactorAuthentication bool     `json:"require_two_factor_authentication" yaml:"require_two_factor_authentication"`
+	TwoFactorGracePeriod           int      `json:"two_factor_grace_period" yaml:"two_factor_grace_period"`
+	ProjectCreationLevel           string   `json:"project_creation_level" yaml:"project_creation_level"`
+	ShareWithGroupLock             bool     `json:"share_with_group_lock" yaml:"share_with_group_lock"`
+	PreventForkingOutsideGroup     bool     `json:"prevent_forking_outside_group" yaml:"prevent_forking_outside_group"`
+	IPRestrictionRanges            []string `json:"ip_restriction_ranges" yaml:"ip_restriction_ranges"`
+	SAMLGroupLinks                 int      `json:"saml_group_links" yaml:"saml_group_links"`
+	SAMLGroupLinksConfigured       bool     `json:"saml_group_links_configured" yaml:"saml_group_links_configured"`
+	Drift                          []Drift  `json:"drift" yaml:"drift"`
+}
+
+// GitlabResources represents a collection of audited Gitlab groups.
+type GitlabResources struct {
+	Groups []*GroupAudit `json:"groups" yaml:"groups"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
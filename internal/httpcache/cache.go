@@ -0,0 +1,189 @@
+// Package httpcache implements an on-disk cache of Gitlab API responses, used to speed up repeated enumerations and to
+// rerun them offline.
+//
+// Only successful GET responses are cached, keyed by URL and by a hash of the credentials the request was sent with,
+// so tokens are never written to disk and one token's responses are never served to another. Responses younger than
+// the TTL are served without contacting Gitlab. Older responses are revalidated with If-None-Match when Gitlab sent an
+// ETag, and refetched otherwise. In offline mode every GET is served from the cache regardless of age, and everything
+// else fails.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// StatusHeader is set on every response passing through the cache, to hit, revalidated, miss or bypass.
+const StatusHeader = "X-Gitlabctl-Cache"
+
+// ErrNotCached is returned in offline mode for requests that cannot be served from the cache.
+var ErrNotCached = errors.New("not in the cache (running with --offline)")
+
+// credentialHeaders are the headers that identify who a request was sent as.
+var credentialHeaders = []string{"PRIVATE-TOKEN", "JOB-TOKEN", "Authorization"}
+
+// Transport is an http.RoundTripper that caches GET responses in Dir.
+type Transport struct {
+	Dir     string
+	TTL     time.Duration
+	Offline bool
+	Base    http.RoundTripper
+	now     func() time.Time
+}
+
+// entry is a cached response as stored on disk.
+type entry struct {
+	URL      string      `json:"url"`
+	StoredAt time.Time   `json:"stored_at"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+}
+
+// New creates a Transport caching the responses of base, or of http.DefaultTransport if base is nil, in dir.
+func New(dir string, ttl time.Duration, offline bool, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Dir: dir, TTL: ttl, Offline: offline, Base: base, now: time.Now}
+}
+
+// RoundTrip serves a request from the cache when possible, and otherwise sends it, caching the response.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if t.Offline {
+			return nil, fmt.Errorf("%s %s: only GET requests are cached: %w", req.Method, req.URL.Redacted(), ErrNotCached)
+		}
+		resp, err := t.Base.RoundTrip(req)
+		if resp != nil {
+			resp.Header.Set(StatusHeader, "bypass")
+		}
+		return resp, err
+	}
+
+	path := t.path(req)
+	cached, err := t.load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if cached != nil && (t.Offline || t.now().Sub(cached.StoredAt) < t.TTL) {
+		return cached.response(req, "hit"), nil
+	}
+	if t.Offline {
+		return nil, fmt.Errorf("GET %s: %w", req.URL.Redacted(), ErrNotCached)
+	}
+
+	if cached != nil && cached.Header.Get("ETag") != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.Header.Get("ETag"))
+	}
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		_ = resp.Body.Close()
+		cached.StoredAt = t.now()
+		if err := t.store(path, cached); err != nil {
+			return nil, err
+		}
+		return cached.response(req, "revalidated"), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Header.Set(StatusHeader, "miss")
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	fresh := &entry{URL: req.URL.Redacted(), StoredAt: t.now(), Status: resp.StatusCode, Header: header, Body: body}
+	if err := t.store(path, fresh); err != nil {
+		return nil, err
+	}
+	return fresh.response(req, "miss"), nil
+}
+
+// path returns the file caching the response to a request, named after a hash of its URL and credentials.
+func (t *Transport) path(req *http.Request) string {
+	hash := sha256.New()
+	for _, name := range credentialHeaders {
+		fmt.Fprintf(hash, "%s=%s\n", name, req.Header.Get(name))
+	}
+	fmt.Fprintf(hash, "GET %s", req.URL.String())
+	key := hex.EncodeToString(hash.Sum(nil))
+	return filepath.Join(t.Dir, key[:2], key+".json")
+}
+
+func (t *Transport) load(path string) (*entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cached := &entry{}
+	if err := json.Unmarshal(data, cached); err != nil {
+		// A corrupt entry is treated as missing, and replaced by the next successful response
+		return nil, nil
+	}
+	return cached, nil
+}
+
+// store writes an entry atomically, readable only by the current user as it holds private Gitlab data.
+func (t *Transport) store(path string, cached *entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// response rebuilds the cached response to req, marking it with the cache status.
+func (e *entry) response(req *http.Request, status string) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(StatusHeader, status)
+	header.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,201 @@
+package httpcache_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/httpcache"
+)
+
+// newServer starts a server answering every request with its path and the ETag "v1", and 304 when revalidated
+// against that ETag. It returns the server and a counter of the requests that reached it.
+func newServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, r.Method+" "+r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// get sends a request through the transport as token, returning the body and the cache status of the response.
+func get(t *testing.T, transport http.RoundTripper, method, url, token string) (string, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body), resp.Header.Get(httpcache.StatusHeader), nil
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		method     string
+		path       string
+		token      string
+		wantBody   string
+		wantStatus string
+		wantHits   int
+	}{
+		{
+			name:       "Test Fresh",
+			ttl:        time.Hour,
+			method:     http.MethodGet,
+			path:       "/projects",
+			token:      "token",
+			wantBody:   "GET /projects",
+			wantStatus: "hit",
+			wantHits:   1,
+		},
+		{
+			name:       "Test Revalidated",
+			ttl:        0,
+			method:     http.MethodGet,
+			path:       "/projects",
+			token:      "token",
+			wantBody:   "GET /projects",
+			wantStatus: "revalidated",
+			wantHits:   2,
+		},
+		{
+			name:       "Test Other Token",
+			ttl:        time.Hour,
+			method:     http.MethodGet,
+			path:       "/projects",
+			token:      "other",
+			wantBody:   "GET /projects",
+			wantStatus: "miss",
+			wantHits:   2,
+		},
+		{
+			name:       "Test Not Found",
+			ttl:        time.Hour,
+			method:     http.MethodGet,
+			path:       "/missing",
+			token:      "token",
+			wantStatus: "miss",
+			wantHits:   2,
+		},
+		{
+			name:       "Test Post",
+			ttl:        time.Hour,
+			method:     http.MethodPost,
+			path:       "/projects",
+			token:      "token",
+			wantBody:   "POST /projects",
+			wantStatus: "bypass",
+			wantHits:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := newServer(t)
+			transport := httpcache.New(t.TempDir(), tt.ttl, false, nil)
+			if _, _, err := get(t, transport, tt.method, srv.URL+tt.path, "token"); err != nil {
+				t.Fatal(err)
+			}
+			body, status, err := get(t, transport, tt.method, srv.URL+tt.path, tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("cache status = %q, want %q", status, tt.wantStatus)
+			}
+			if *hits != tt.wantHits {
+				t.Errorf("server received %d requests, want %d", *hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestTransportOffline(t *testing.T) {
+	srv, hits := newServer(t)
+	dir := t.TempDir()
+	if _, _, err := get(t, httpcache.New(dir, 0, false, nil), http.MethodGet, srv.URL+"/projects", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	offline := httpcache.New(dir, 0, true, nil)
+	body, status, err := get(t, offline, http.MethodGet, srv.URL+"/projects", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "GET /projects" || status != "hit" {
+		t.Errorf("got %q (%s), want the cached response", body, status)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if _, _, err := get(t, offline, method, srv.URL+"/groups", "token"); !errors.Is(err, httpcache.ErrNotCached) {
+			t.Errorf("%s error = %v, want ErrNotCached", method, err)
+		}
+	}
+	if *hits != 1 {
+		t.Errorf("server received %d requests, want 1", *hits)
+	}
+}
+
+func TestTransportStorage(t *testing.T) {
+	srv, _ := newServer(t)
+	dir := t.TempDir()
+	if _, _, err := get(t, httpcache.New(dir, time.Hour, false, nil), http.MethodGet, srv.URL+"/projects", "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	files := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files++
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("%s has mode %v, want 0600", path, info.Mode().Perm())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(data), "secret-token") {
+			t.Errorf("%s contains the token", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 1 {
+		t.Errorf("found %d cache files, want 1", files)
+	}
+}
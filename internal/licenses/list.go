@@ -0,0 +1,112 @@
+// Package licenses holds the data structures and logic necessary to aggregate the licenses of the dependencies detected
+// in Gitlab projects and evaluate them against an allow/deny license policy.
+package licenses
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
+	"github.com/Method-Security/gitlabctl/internal/dependencies"
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+// EnumerateLicensesOptions holds the options for the license compliance report.
+// The ProjectID and GroupID fields select a single project, or every project in a group and its subgroups.
+// The Policy field holds the license policy. If nil, licenses are aggregated without reporting violations.
+// The Checkpoint field, when set, records the progress of the underlying dependency enumeration.
+type EnumerateLicensesOptions struct {
+	ProjectID  string                 `json:"project_id" yaml:"project_id"`
+	GroupID    string                 `json:"group_id" yaml:"group_id"`
+	Policy     *Policy                `json:"policy" yaml:"policy"`
+	Checkpoint *checkpoint.Checkpoint `json:"-" yaml:"-"`
+}
+
+// EnumerateLicenses aggregates the dependency licenses of the selected projects, evaluating each dependency against the
+// license policy and building a license histogram across all projects.
+func EnumerateLicenses(ctx context.Context, baseURL string, options *EnumerateLicensesOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		Resources: GitlabResources{
+			Projects:  []*ProjectLicenses{},
+			Histogram: []LicenseCount{},
+		},
+		ErrorLog: nonfatal.NewErrorLog(),
+		BaseURL:  baseURL,
+	}
+
+	dependencyReport, err := dependencies.EnumerateDependencies(ctx, baseURL, &dependencies.EnumerateDependenciesOptions{
+		ProjectID:  options.ProjectID,
+		GroupID:    options.GroupID,
+		Checkpoint: options.Checkpoint,
+	}, client)
+	if err != nil {
+		return report, err
+	}
+	report.Merge(dependencyReport.ErrorLog)
+
+	for _, project := range dependencyReport.Resources.Projects {
+		report.Resources.Projects = append(report.Resources.Projects, EvaluateProject(project, options.Policy))
+	}
+	report.Resources.Histogram = BuildHistogram(report.Resources.Projects)
+
+	return report, nil
+}
+
+// EvaluateProject counts the licenses used by a project's dependencies and evaluates them against the policy.
+func EvaluateProject(project *dependencies.ProjectDependencies, policy *Policy) *ProjectLicenses {
+	result := &ProjectLicenses{
+		ProjectID:   project.ProjectID,
+		ProjectPath: project.ProjectPath,
+		Licenses:    []LicenseCount{},
+		Violations:  []Violation{},
+	}
+
+	counts := map[string]int{}
+	for _, dep := range project.Dependencies {
+		if len(dep.Licenses) == 0 {
+			counts[UnknownLicense]++
+		}
+		for _, license := range dep.Licenses {
+			counts[LicenseKey(license)]++
+		}
+		result.Violations = append(result.Violations, policy.Evaluate(dep)...)
+	}
+	for license, count := range counts {
+		result.Licenses = append(result.Licenses, LicenseCount{License: license, Dependencies: count, Projects: 1})
+	}
+	sortLicenseCounts(result.Licenses)
+	return result
+}
+
+// BuildHistogram combines the per-project license counts into a histogram across all projects.
+func BuildHistogram(projects []*ProjectLicenses) []LicenseCount {
+	totals := map[string]*LicenseCount{}
+	for _, project := range projects {
+		for _, count := range project.Licenses {
+			total, ok := totals[count.License]
+			if !ok {
+				total = &LicenseCount{License: count.License}
+				totals[count.License] = total
+			}
+			total.Dependencies += count.Dependencies
+			total.Projects++
+		}
+	}
+
+	histogram := make([]LicenseCount, 0, len(totals))
+	for _, total := range totals {
+		histogram = append(histogram, *total)
+	}
+	sortLicenseCounts(histogram)
+	return histogram
+}
+
+func sortLicenseCounts(counts []LicenseCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Dependencies != counts[j].Dependencies {
+			return counts[i].Dependencies > counts[j].Dependencies
+		}
+		return counts[i].License < counts[j].License
+	})
+}
@@ -0,0 +1,115 @@
+package licenses
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Method-Security/gitlabctl/internal/dependencies"
+	"gopkg.in/yaml.v2"
+)
+
+// UnknownLicense is the license reported for dependencies Gitlab could not detect a license for.
+const UnknownLicense = "unknown"
+
+// Policy holds the allow and deny lists licenses are evaluated against. Entries are matched case-insensitively against
+// both the SPDX identifier and the name of a license, and may contain * wildcards (e.g. GPL-*).
+// A license matching the Deny list is always a violation. If the Allow list is not empty, any license not matching it
+// is also a violation. DenyUnknown makes dependencies without a detected license a violation.
+type Policy struct {
+	Allow       []string `json:"allow" yaml:"allow"`
+	Deny        []string `json:"deny" yaml:"deny"`
+	DenyUnknown bool     `json:"deny_unknown" yaml:"deny_unknown"`
+}
+
+// LoadPolicy reads a Policy from the YAML (or JSON) file at the provided path.
+func LoadPolicy(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse license policy file %s: %w", filePath, err)
+	}
+	for _, pattern := range append(policy.Allow, policy.Deny...) {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return nil, fmt.Errorf("invalid license pattern %q in %s: %w", pattern, filePath, err)
+		}
+	}
+	return policy, nil
+}
+
+// Evaluate returns the policy violations of a dependency. A dependency with several licenses (e.g. dual licensed) only
+// violates the allow list if none of its licenses are allowed, but violates the deny list if any of them are denied.
+// A nil policy yields no violations.
+func (p *Policy) Evaluate(dep *dependencies.Dependency) []Violation {
+	violations := []Violation{}
+	if p == nil {
+		return violations
+	}
+
+	newViolation := func(license string, reason ViolationReason) Violation {
+		return Violation{
+			Dependency:     dep.Name,
+			Version:        dep.Version,
+			PackageManager: dep.PackageManager,
+			Location:       dep.Location,
+			License:        license,
+			Reason:         reason,
+		}
+	}
+
+	if len(dep.Licenses) == 0 {
+		if p.DenyUnknown {
+			violations = append(violations, newViolation(UnknownLicense, ViolationUnknown))
+		}
+		return violations
+	}
+
+	allowed := len(p.Allow) == 0
+	for _, license := range dep.Licenses {
+		if matchesAny(p.Deny, license) {
+			violations = append(violations, newViolation(LicenseKey(license), ViolationDenied))
+		}
+		if matchesAny(p.Allow, license) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		keys := make([]string, 0, len(dep.Licenses))
+		for _, license := range dep.Licenses {
+			keys = append(keys, LicenseKey(license))
+		}
+		violations = append(violations, newViolation(strings.Join(keys, " OR "), ViolationNotAllowed))
+	}
+	return violations
+}
+
+// LicenseKey returns the identifier a license is reported under, preferring its SPDX identifier over its name.
+func LicenseKey(license dependencies.License) string {
+	if license.SPDXIdentifier != "" {
+		return license.SPDXIdentifier
+	}
+	if license.Name != "" {
+		return license.Name
+	}
+	return UnknownLicense
+}
+
+func matchesAny(patterns []string, license dependencies.License) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		for _, value := range []string{license.SPDXIdentifier, license.Name} {
+			if value == "" {
+				continue
+			}
+			if matched, _ := path.Match(pattern, strings.ToLower(value)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
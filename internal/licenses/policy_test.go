@@ -0,0 +1,71 @@
+package licenses_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/dependencies"
+	"github.com/Method-Security/gitlabctl/internal/licenses"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := &licenses.Policy{
+		Allow:       []string{"MIT", "Apache-2.0", "BSD-*"},
+		Deny:        []string{"GPL-*", "AGPL-*"},
+		DenyUnknown: true,
+	}
+	mit := dependencies.License{Name: "MIT License", SPDXIdentifier: "MIT"}
+	gpl := dependencies.License{Name: "GNU General Public License v3.0 only", SPDXIdentifier: "GPL-3.0-only"}
+	mpl := dependencies.License{Name: "Mozilla Public License 2.0", SPDXIdentifier: "MPL-2.0"}
+	bsd := dependencies.License{Name: "BSD-3-Clause"}
+
+	tests := []struct {
+		name    string
+		policy  *licenses.Policy
+		dep     *dependencies.Dependency
+		reasons []licenses.ViolationReason
+	}{
+		{name: "Test Allowed", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{mit}}, reasons: []licenses.ViolationReason{}},
+		{name: "Test Allowed By Name Wildcard", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{bsd}}, reasons: []licenses.ViolationReason{}},
+		{name: "Test Denied", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{gpl}}, reasons: []licenses.ViolationReason{licenses.ViolationDenied, licenses.ViolationNotAllowed}},
+		{name: "Test Not Allowed", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{mpl}}, reasons: []licenses.ViolationReason{licenses.ViolationNotAllowed}},
+		{name: "Test Dual Licensed", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{mpl, mit}}, reasons: []licenses.ViolationReason{}},
+		{name: "Test Dual Licensed Denied", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{gpl, mit}}, reasons: []licenses.ViolationReason{licenses.ViolationDenied}},
+		{name: "Test Unknown", policy: policy, dep: &dependencies.Dependency{Licenses: []dependencies.License{}}, reasons: []licenses.ViolationReason{licenses.ViolationUnknown}},
+		{name: "Test Nil Policy", policy: nil, dep: &dependencies.Dependency{Licenses: []dependencies.License{gpl}}, reasons: []licenses.ViolationReason{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons := []licenses.ViolationReason{}
+			for _, violation := range tt.policy.Evaluate(tt.dep) {
+				reasons = append(reasons, violation.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("Evaluate() reasons = %v, want %v", reasons, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestBuildHistogram(t *testing.T) {
+	mit := dependencies.License{SPDXIdentifier: "MIT"}
+	projects := []*licenses.ProjectLicenses{
+		licenses.EvaluateProject(&dependencies.ProjectDependencies{ProjectPath: "acme/app", Dependencies: []*dependencies.Dependency{
+			{Name: "a", Licenses: []dependencies.License{mit}},
+			{Name: "b", Licenses: []dependencies.License{mit}},
+			{Name: "c", Licenses: []dependencies.License{}},
+		}}, nil),
+		licenses.EvaluateProject(&dependencies.ProjectDependencies{ProjectPath: "acme/api", Dependencies: []*dependencies.Dependency{
+			{Name: "a", Licenses: []dependencies.License{mit}},
+		}}, nil),
+	}
+
+	want := []licenses.LicenseCount{
+		{License: "MIT", Dependencies: 3, Projects: 2},
+		{License: licenses.UnknownLicense, Dependencies: 1, Projects: 1},
+	}
+	if got := licenses.BuildHistogram(projects); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildHistogram() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package licenses
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// ViolationReason describes why a dependency license violates the license policy.
+type ViolationReason string
+
+const (
+	ViolationDenied     ViolationReason = "denied"
+	ViolationNotAllowed ViolationReason = "not_allowed"
+	ViolationUnknown    ViolationReason = "unknown_license"
+)
+
+// Violation represents a dependency whose license violates the license policy.
+type Violation struct {
+	Dependency     string          `json:"dependency" yaml:"dependency"`
+	Version        string          `json:"version" yaml:"version"`
+	PackageManager string          `json:"package_manager" yaml:"package_manager"`
+	Location       string          `json:"location" yaml:"location"`
+	License        string          `json:"license" yaml:"license"`
+	Reason         ViolationReason `json:"reason" yaml:"reason"`
+}
+
+// LicenseCount counts the dependencies, and the projects they belong to, that use a license.
+type LicenseCount struct {
+	License      string `json:"license" yaml:"license"`
+	Dependencies int    `json:"dependencies" yaml:"dependencies"`
+	Projects     int    `json:"projects" yaml:"projects"`
+}
+
+// ProjectLicenses represents the licenses used by the dependencies of a single project and its policy violations.
+type ProjectLicenses struct {
+	ProjectID   int            `json:"project_id" yaml:"project_id"`
+	ProjectPath string         `json:"project_path" yaml:"project_path"`
+	Licenses    []LicenseCount `json:"licenses" yaml:"licenses"`
+	Violations  []Violation    `json:"violations" yaml:"violations"`
+}
+
+// GitlabResources represents the per-project license usage and the license histogram across all projects.
+type GitlabResources struct {
+	Projects  []*ProjectLicenses `json:"projects" yaml:"projects"`
+	Histogram []LicenseCount     `json:"histogram" yaml:"histogram"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
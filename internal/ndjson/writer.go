@@ -0,0 +1,180 @@
+// Package ndjson implements the ndjson output format, which writes a command's report as newline delimited JSON
+// records so very large enumerations can be consumed while they run.
+//
+// Every resource is written as its own record as soon as it is available, followed once the command completes by a
+// single trailer record holding the rest of the report, such as its errors, along with the status and timing of the
+// run. Commands that stream pass the Writer to their enumeration, which emits resources as they are fetched rather
+// than collecting them in the report. The resources of other commands are written from their report when they
+// complete, so every command supports the format.
+package ndjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// RecordTypeResource is the type of the records holding a single resource.
+	RecordTypeResource = "resource"
+	// RecordTypeTrailer is the type of the record written last, once the command completed.
+	RecordTypeTrailer = "trailer"
+)
+
+// Record is a single resource as written to the output. Kind is the name of the report's list of resources the
+// resource belongs to, such as projects or vulnerabilities.
+type Record struct {
+	Type     string      `json:"type"`
+	Kind     string      `json:"kind"`
+	Resource interface{} `json:"resource"`
+}
+
+// Trailer holds the status and timing of a run, written in the trailer record alongside the rest of the report.
+type Trailer struct {
+	Status       int
+	ErrorMessage *string
+	StartedAt    time.Time
+	CompletedAt  time.Time
+}
+
+// Writer writes records to an output, one JSON document per line. It is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	out    io.Writer
+	closer io.Closer
+	counts map[string]int
+	err    error
+}
+
+// NewWriter creates a Writer writing to out.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out, counts: map[string]int{}}
+}
+
+// Create creates a Writer writing to the file at path, or to standard output if path is nil. The file is created
+// immediately, so the records written before a crash are kept.
+func Create(path *string) (*Writer, error) {
+	if path == nil {
+		return NewWriter(os.Stdout), nil
+	}
+	file, err := os.OpenFile(*path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	w := NewWriter(file)
+	w.closer = file
+	return w, nil
+}
+
+// Emit writes a resource of the given kind as a record. It returns false when w is nil, in which case the caller keeps
+// the resource in its report instead. Write errors are returned by Finish, so enumerations are not interrupted by them.
+func (w *Writer) Emit(kind string, resource interface{}) bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.write(Record{Type: RecordTypeResource, Kind: kind, Resource: resource})
+	w.counts[kind]++
+	return true
+}
+
+// Finish writes the resources remaining in report, followed by the trailer record, and closes the output. The trailer
+// holds every field of the report but its resources, the number of resources written of each kind, and the status
+// and timing of the run. report may be nil when the command failed before producing one.
+func (w *Writer) Finish(report interface{}, trailer Trailer) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fields := map[string]json.RawMessage{}
+	if report != nil {
+		data, err := json.Marshal(report)
+		if err == nil {
+			err = json.Unmarshal(data, &fields)
+		}
+		if err != nil && w.err == nil {
+			w.err = fmt.Errorf("failed to write the report as ndjson: %w", err)
+		}
+	}
+	if resources, ok := fields["resources"]; ok {
+		w.writeResources(resources)
+		delete(fields, "resources")
+	}
+
+	record := map[string]interface{}{}
+	for name, value := range fields {
+		record[name] = value
+	}
+	record["resource_counts"] = w.counts
+	record["status"] = trailer.Status
+	record["error_message"] = trailer.ErrorMessage
+	record["started_at"] = trailer.StartedAt
+	record["completed_at"] = trailer.CompletedAt
+	record["duration_seconds"] = trailer.CompletedAt.Sub(trailer.StartedAt).Seconds()
+	w.writeTrailer(record)
+
+	if w.closer != nil {
+		if err := w.closer.Close(); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
+
+// writeResources writes the resources collected in a report: every element of each list of resources, or the value
+// itself for resources that are a single object.
+func (w *Writer) writeResources(resources json.RawMessage) {
+	kinds := map[string]json.RawMessage{}
+	if err := json.Unmarshal(resources, &kinds); err != nil {
+		return
+	}
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var list []json.RawMessage
+		if err := json.Unmarshal(kinds[name], &list); err != nil {
+			if string(kinds[name]) != "null" {
+				list = []json.RawMessage{kinds[name]}
+			}
+		}
+		for _, resource := range list {
+			w.write(Record{Type: RecordTypeResource, Kind: name, Resource: resource})
+			w.counts[name]++
+		}
+	}
+}
+
+// writeTrailer writes the trailer record, with its type first like every other record so consumers can dispatch on it
+// before decoding the rest.
+func (w *Writer) writeTrailer(record map[string]interface{}) {
+	if w.err != nil {
+		return
+	}
+	delete(record, "type")
+	data, err := json.Marshal(record)
+	if err != nil {
+		w.err = err
+		return
+	}
+	line := append([]byte(`{"type":"`+RecordTypeTrailer+`",`), data[1:]...)
+	_, w.err = w.out.Write(append(line, '\n'))
+}
+
+func (w *Writer) write(record interface{}) {
+	if w.err != nil {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		w.err = err
+		return
+	}
+	_, w.err = w.out.Write(append(data, '\n'))
+}
@@ -0,0 +1,152 @@
+package ndjson_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/ndjson"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+type report struct {
+	BaseURL   string `json:"base_url"`
+	Resources struct {
+		Items []item `json:"items"`
+		User  *item  `json:"user"`
+	} `json:"resources"`
+	Errors []string `json:"errors"`
+}
+
+// decode splits NDJSON output into its records.
+func decode(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWriter(t *testing.T) {
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		streamed   []string
+		report     func() interface{}
+		wantKinds  []string
+		wantCounts map[string]interface{}
+		wantErrors []interface{}
+	}{
+		{
+			name:     "Test Streamed",
+			streamed: []string{"a", "b"},
+			report: func() interface{} {
+				return &report{BaseURL: "https://gitlab.com/api/v4", Errors: []string{"403 Forbidden"}}
+			},
+			wantKinds:  []string{"items", "items"},
+			wantCounts: map[string]interface{}{"items": float64(2)},
+			wantErrors: []interface{}{"403 Forbidden"},
+		},
+		{
+			name: "Test Collected",
+			report: func() interface{} {
+				r := &report{BaseURL: "https://gitlab.com/api/v4", Errors: []string{}}
+				r.Resources.Items = []item{{Name: "a"}}
+				r.Resources.User = &item{Name: "root"}
+				return r
+			},
+			wantKinds:  []string{"items", "user"},
+			wantCounts: map[string]interface{}{"items": float64(1), "user": float64(1)},
+			wantErrors: []interface{}{},
+		},
+		{
+			name:       "Test No Report",
+			report:     func() interface{} { return nil },
+			wantCounts: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			w := ndjson.NewWriter(out)
+			for _, name := range tt.streamed {
+				if !w.Emit("items", item{Name: name}) {
+					t.Fatal("Emit() = false, want true")
+				}
+			}
+			message := "failed"
+			err := w.Finish(tt.report(), ndjson.Trailer{Status: 1, ErrorMessage: &message, StartedAt: started, CompletedAt: started.Add(90 * time.Second)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			records := decode(t, out.Bytes())
+			if len(records) != len(tt.wantKinds)+1 {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.wantKinds)+1)
+			}
+			for i, kind := range tt.wantKinds {
+				if records[i]["type"] != ndjson.RecordTypeResource || records[i]["kind"] != kind {
+					t.Errorf("record %d = %v, want a %s resource", i, records[i], kind)
+				}
+			}
+			trailer := records[len(records)-1]
+			if trailer["type"] != ndjson.RecordTypeTrailer {
+				t.Fatalf("last record = %v, want the trailer", trailer)
+			}
+			if _, ok := trailer["resources"]; ok {
+				t.Error("trailer holds the resources")
+			}
+			if got, _ := json.Marshal(trailer["resource_counts"]); string(got) != mustMarshal(t, tt.wantCounts) {
+				t.Errorf("resource_counts = %s, want %s", got, mustMarshal(t, tt.wantCounts))
+			}
+			if got, _ := json.Marshal(trailer["errors"]); tt.wantErrors != nil && string(got) != mustMarshal(t, tt.wantErrors) {
+				t.Errorf("errors = %s, want %s", got, mustMarshal(t, tt.wantErrors))
+			}
+			if trailer["status"] != float64(1) || trailer["error_message"] != "failed" || trailer["duration_seconds"] != float64(90) {
+				t.Errorf("trailer = %v, want the status and timing of the run", trailer)
+			}
+		})
+	}
+}
+
+func TestWriterNil(t *testing.T) {
+	var w *ndjson.Writer
+	if w.Emit("items", item{}) {
+		t.Error("Emit() on a nil writer = true, want false")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriterError(t *testing.T) {
+	w := ndjson.NewWriter(failingWriter{})
+	w.Emit("items", item{Name: "a"})
+	if err := w.Finish(nil, ndjson.Trailer{}); err == nil || err.Error() != "disk full" {
+		t.Errorf("Finish() error = %v, want the write error", err)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,154 @@
+// Package nonfatal holds the structured non-fatal errors commands record in their reports when part of an
+// enumeration fails, such as a project the token cannot read, while the rest of it succeeds.
+//
+// Every error is classified from the failure it wraps, so downstream tooling can tell a permission problem apart
+// from a transient failure worth retrying. Reports keep the string rendering of each error in their errors list for
+// compatibility, alongside the structured errors and their counts by class.
+package nonfatal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Class is the class of a non-fatal error, derived from the HTTP status of the failed request or the kind of
+// failure that prevented a response.
+type Class string
+
+const (
+	ClassUnauthorized Class = "unauthorized"
+	ClassForbidden    Class = "forbidden"
+	ClassNotFound     Class = "not_found"
+	ClassRateLimited  Class = "rate_limited"
+	ClassClientError  Class = "client_error"
+	ClassServerError  Class = "server_error"
+	ClassTimeout      Class = "timeout"
+	ClassNetwork      Class = "network"
+	ClassOther        Class = "other"
+)
+
+// Retryable reports whether errors of the class are transient, so the failed request may succeed if retried.
+func (c Class) Retryable() bool {
+	switch c {
+	case ClassRateLimited, ClassServerError, ClassTimeout, ClassNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// Error is a non-fatal error encountered while enumerating a resource. ResourceKind and ResourceID identify the
+// resource, such as a project by its ID or path, and are empty when the error is not specific to one resource.
+// HTTPStatus and GitlabMessage are set when Gitlab answered the request with an error. Message is the string
+// rendering of the error, as listed in the errors of the report.
+type Error struct {
+	Class         Class     `json:"class" yaml:"class"`
+	ResourceKind  string    `json:"resource_kind,omitempty" yaml:"resource_kind,omitempty"`
+	ResourceID    string    `json:"resource_id,omitempty" yaml:"resource_id,omitempty"`
+	HTTPStatus    int       `json:"http_status,omitempty" yaml:"http_status,omitempty"`
+	GitlabMessage string    `json:"gitlab_message,omitempty" yaml:"gitlab_message,omitempty"`
+	Message       string    `json:"message" yaml:"message"`
+	Retryable     bool      `json:"retryable" yaml:"retryable"`
+	Timestamp     time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// String returns the string rendering of the error.
+func (e *Error) String() string {
+	return e.Message
+}
+
+// New creates the Error of a failure to enumerate a resource, classified from err and rendered as err's message.
+func New(kind string, id string, err error) *Error {
+	return Newf(kind, id, err, "%s", err.Error())
+}
+
+// Newf creates the Error of a failure to enumerate a resource, classified from err, which may be nil for failures
+// that are not caused by a request, and rendered according to a format specifier.
+func Newf(kind string, id string, err error, format string, args ...interface{}) *Error {
+	e := &Error{
+		Class:        ClassOther,
+		ResourceKind: kind,
+		ResourceID:   id,
+		Message:      fmt.Sprintf(format, args...),
+		Timestamp:    time.Now().UTC(),
+	}
+	classify(e, err)
+	e.Retryable = e.Class.Retryable()
+	return e
+}
+
+func classify(e *Error, err error) {
+	if err == nil {
+		return
+	}
+	var response *gitlab.ErrorResponse
+	if errors.As(err, &response) && response.Response != nil {
+		e.HTTPStatus = response.Response.StatusCode
+		e.GitlabMessage = response.Message
+		e.Class = statusClass(e.HTTPStatus)
+		return
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		e.Class = ClassTimeout
+	case errors.As(err, new(*url.Error)), errors.As(err, new(*net.OpError)):
+		e.Class = ClassNetwork
+	}
+}
+
+func statusClass(status int) Class {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ClassUnauthorized
+	case status == http.StatusForbidden:
+		return ClassForbidden
+	case status == http.StatusNotFound:
+		return ClassNotFound
+	case status == http.StatusTooManyRequests:
+		return ClassRateLimited
+	case status >= 500:
+		return ClassServerError
+	case status >= 400:
+		return ClassClientError
+	default:
+		return ClassOther
+	}
+}
+
+// ErrorLog holds the non-fatal errors of a report, embedded in every report. Errors lists the string rendering of
+// each error, ErrorDetails the structured errors, and ErrorCounts the number of errors of each class.
+type ErrorLog struct {
+	Errors       []string      `json:"errors" yaml:"errors"`
+	ErrorDetails []*Error      `json:"error_details" yaml:"error_details"`
+	ErrorCounts  map[Class]int `json:"error_counts" yaml:"error_counts"`
+}
+
+// NewErrorLog creates an empty ErrorLog.
+func NewErrorLog() ErrorLog {
+	return ErrorLog{Errors: []string{}, ErrorDetails: []*Error{}, ErrorCounts: map[Class]int{}}
+}
+
+// AddError records a non-fatal error.
+func (l *ErrorLog) AddError(err *Error) {
+	if l.ErrorCounts == nil {
+		l.ErrorCounts = map[Class]int{}
+	}
+	l.Errors = append(l.Errors, err.Message)
+	l.ErrorDetails = append(l.ErrorDetails, err)
+	l.ErrorCounts[err.Class]++
+}
+
+// Merge records the non-fatal errors of another report, such as the report of a nested enumeration.
+func (l *ErrorLog) Merge(other ErrorLog) {
+	for _, err := range other.ErrorDetails {
+		l.AddError(err)
+	}
+}
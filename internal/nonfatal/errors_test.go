@@ -0,0 +1,85 @@
+package nonfatal_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+func errorResponse(status int, message string) error {
+	request, _ := http.NewRequest(http.MethodGet, "https://gitlab.example.com/api/v4/projects/2", nil)
+	return &gitlab.ErrorResponse{Response: &http.Response{StatusCode: status, Request: request}, Message: message}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantClass     nonfatal.Class
+		wantStatus    int
+		wantMessage   string
+		wantRetryable bool
+	}{
+		{name: "Test Forbidden", err: errorResponse(403, "{message: 403 Forbidden}"), wantClass: nonfatal.ClassForbidden, wantStatus: 403, wantMessage: "{message: 403 Forbidden}"},
+		{name: "Test Not Found", err: errorResponse(404, "{message: 404 Project Not Found}"), wantClass: nonfatal.ClassNotFound, wantStatus: 404, wantMessage: "{message: 404 Project Not Found}"},
+		{name: "Test Rate Limited", err: errorResponse(429, ""), wantClass: nonfatal.ClassRateLimited, wantStatus: 429, wantRetryable: true},
+		{name: "Test Server Error", err: errorResponse(502, ""), wantClass: nonfatal.ClassServerError, wantStatus: 502, wantRetryable: true},
+		{name: "Test Client Error", err: errorResponse(422, ""), wantClass: nonfatal.ClassClientError, wantStatus: 422},
+		{name: "Test Wrapped", err: fmt.Errorf("failed to list: %w", errorResponse(401, "")), wantClass: nonfatal.ClassUnauthorized, wantStatus: 401},
+		{name: "Test Timeout", err: fmt.Errorf("failed to list: %w", context.DeadlineExceeded), wantClass: nonfatal.ClassTimeout, wantRetryable: true},
+		{name: "Test Network", err: &url.Error{Op: "Get", URL: "https://gitlab.example.com", Err: errors.New("connection refused")}, wantClass: nonfatal.ClassNetwork, wantRetryable: true},
+		{name: "Test Other", err: errors.New("invalid response"), wantClass: nonfatal.ClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nonfatal.New("project", "group/project", tt.err)
+			if got.Class != tt.wantClass || got.HTTPStatus != tt.wantStatus || got.GitlabMessage != tt.wantMessage || got.Retryable != tt.wantRetryable {
+				t.Errorf("New() = %+v, want class %s, status %d, message %q, retryable %v", got, tt.wantClass, tt.wantStatus, tt.wantMessage, tt.wantRetryable)
+			}
+			if got.String() != tt.err.Error() || got.ResourceKind != "project" || got.ResourceID != "group/project" || got.Timestamp.IsZero() {
+				t.Errorf("New() = %+v, want the rendering, resource and timestamp of the error", got)
+			}
+		})
+	}
+}
+
+func TestNewfWithoutError(t *testing.T) {
+	got := nonfatal.Newf("profile", "ci", nil, "profile %s is invalid", "ci")
+	if got.Class != nonfatal.ClassOther || got.HTTPStatus != 0 || got.Retryable || got.String() != "profile ci is invalid" {
+		t.Errorf("Newf() = %+v, want an unclassified error rendered from the format", got)
+	}
+}
+
+func TestErrorLog(t *testing.T) {
+	nested := nonfatal.NewErrorLog()
+	nested.AddError(nonfatal.New("group", "10", errorResponse(403, "")))
+	nested.AddError(nonfatal.New("group", "11", errorResponse(500, "")))
+
+	log := nonfatal.NewErrorLog()
+	log.AddError(nonfatal.New("project", "2", errorResponse(403, "")))
+	log.Merge(nested)
+
+	if len(log.Errors) != 3 || len(log.ErrorDetails) != 3 {
+		t.Fatalf("got %d errors and %d details, want 3", len(log.Errors), len(log.ErrorDetails))
+	}
+	if log.Errors[1] != log.ErrorDetails[1].Message {
+		t.Errorf("Errors[1] = %q, want the rendering of ErrorDetails[1] %q", log.Errors[1], log.ErrorDetails[1].Message)
+	}
+	want := map[nonfatal.Class]int{nonfatal.ClassForbidden: 2, nonfatal.ClassServerError: 1}
+	if len(log.ErrorCounts) != len(want) || log.ErrorCounts[nonfatal.ClassForbidden] != 2 || log.ErrorCounts[nonfatal.ClassServerError] != 1 {
+		t.Errorf("ErrorCounts = %v, want %v", log.ErrorCounts, want)
+	}
+
+	var zero nonfatal.ErrorLog
+	zero.AddError(nonfatal.New("", "", errors.New("failed")))
+	if zero.ErrorCounts[nonfatal.ClassOther] != 1 {
+		t.Errorf("ErrorCounts of a zero ErrorLog = %v, want one other error", zero.ErrorCounts)
+	}
+}
@@ -0,0 +1,232 @@
+// Package packages holds the data structures and logic necessary to inventory the packages published to Gitlab package
+// registries across a group, flagging the packages that carry a dependency confusion risk.
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+// SupportedPackageTypes lists the package types enumerated by default.
+var SupportedPackageTypes = []string{"npm", "maven", "pypi", "nuget", "golang", "conan", "generic"}
+
+// EnumeratePackagesOptions holds the options for enumerating packages.
+// The GroupID field is the ID or full path of the group whose packages are enumerated, including its subgroups.
+// The PackageTypes field filters the enumerated package types. If empty, SupportedPackageTypes is used.
+// The NamespacePrefixes field lists the organisation's scopes and name prefixes (e.g. @acme, com.acme, acme-) used to
+// decide whether a package name collides with a public registry namespace.
+type EnumeratePackagesOptions struct {
+	GroupID           string   `json:"group_id" yaml:"group_id"`
+	PackageTypes      []string `json:"package_types" yaml:"package_types"`
+	NamespacePrefixes []string `json:"namespace_prefixes" yaml:"namespace_prefixes"`
+}
+
+// groupPackage extends the go-gitlab GroupPackage with the pipeline that published the package, which the Gitlab API
+// returns but go-gitlab does not model.
+type groupPackage struct {
+	gitlab.GroupPackage
+	Pipeline *struct {
+		ID     int    `json:"id"`
+		Ref    string `json:"ref"`
+		SHA    string `json:"sha"`
+		WebURL string `json:"web_url"`
+		User   *struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"pipeline"`
+}
+
+// protectedRefs holds the protected branch and tag name patterns of a project.
+type protectedRefs struct {
+	patterns []string
+	err      error
+}
+
+// EnumeratePackages enumerates the packages published across a group and its subgroups, resolving whether the pipeline
+// that published each package ran on a protected ref. The function returns a GitlabResourceReport containing the
+// packages and non-fatal errors encountered during the enumeration process.
+func EnumeratePackages(ctx context.Context, baseURL string, options *EnumeratePackagesOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		Resources: GitlabResources{Packages: []*Package{}},
+		ErrorLog:  nonfatal.NewErrorLog(),
+		BaseURL:   baseURL,
+	}
+	if options.GroupID == "" {
+		return report, errors.New("group ID is required")
+	}
+	packageTypes := options.PackageTypes
+	if len(packageTypes) == 0 {
+		packageTypes = SupportedPackageTypes
+	}
+
+	filterOptions := gitlab.ListGroupPackagesOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	protected := map[int]*protectedRefs{}
+	for {
+		pkgs, resp, err := listGroupPackages(client, options.GroupID, &filterOptions)
+		if err != nil {
+			report.AddError(nonfatal.New("group", options.GroupID, err))
+			break
+		}
+
+		for _, pkg := range pkgs {
+			if !containsType(packageTypes, pkg.PackageType) {
+				continue
+			}
+			result := newPackage(pkg)
+			if result.Pipeline != nil {
+				refs, ok := protected[result.ProjectID]
+				if !ok {
+					refs = fetchProtectedRefs(client, result.ProjectID)
+					protected[result.ProjectID] = refs
+					if refs.err != nil {
+						report.AddError(nonfatal.Newf("project", result.ProjectPath, refs.err, "failed to list protected refs for project %s: %s", result.ProjectPath, refs.err.Error()))
+					}
+				}
+				if refs.err == nil {
+					result.Pipeline.RefProtected = gitlab.Ptr(isProtected(refs.patterns, result.Pipeline.Ref))
+				}
+			}
+			result.Findings = EvaluatePackage(result, options.NamespacePrefixes)
+			report.Resources.Packages = append(report.Resources.Packages, result)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		filterOptions.ListOptions.Page = resp.NextPage
+	}
+
+	return report, nil
+}
+
+// EvaluatePackage returns the dependency confusion findings for a single package.
+func EvaluatePackage(pkg *Package, prefixes []string) []Finding {
+	findings := []Finding{}
+	if CollidesWithPublicNamespace(pkg.Type, pkg.Name, prefixes) {
+		findings = append(findings, FindingPublicNamespaceCollision)
+	}
+	if pkg.Pipeline == nil {
+		findings = append(findings, FindingManuallyPublished)
+	} else if pkg.Pipeline.RefProtected != nil && !*pkg.Pipeline.RefProtected {
+		findings = append(findings, FindingUnprotectedRef)
+	}
+	return findings
+}
+
+func listGroupPackages(client *gitlab.Client, groupID string, options *gitlab.ListGroupPackagesOptions) ([]*groupPackage, *gitlab.Response, error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("groups/%s/packages", gitlab.PathEscape(groupID)), options, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pkgs []*groupPackage
+	resp, err := client.Do(req, &pkgs)
+	if err != nil {
+		return nil, resp, err
+	}
+	return pkgs, resp, nil
+}
+
+func newPackage(pkg *groupPackage) *Package {
+	result := &Package{
+		ID:          pkg.ID,
+		Name:        pkg.Name,
+		Version:     pkg.Version,
+		Type:        pkg.PackageType,
+		Status:      pkg.Status,
+		ProjectID:   pkg.ProjectID,
+		ProjectPath: pkg.ProjectPath,
+		CreatedAt:   pkg.CreatedAt,
+		Findings:    []Finding{},
+	}
+	if pkg.Links != nil {
+		result.WebPath = pkg.Links.WebPath
+	}
+	if pkg.Pipeline != nil {
+		result.Pipeline = &Pipeline{
+			ID:     pkg.Pipeline.ID,
+			Ref:    pkg.Pipeline.Ref,
+			SHA:    pkg.Pipeline.SHA,
+			WebURL: pkg.Pipeline.WebURL,
+		}
+		if pkg.Pipeline.User != nil {
+			result.Pipeline.Username = pkg.Pipeline.User.Username
+		}
+	}
+	return result
+}
+
+func fetchProtectedRefs(client *gitlab.Client, projectID int) *protectedRefs {
+	refs := &protectedRefs{patterns: []string{}}
+
+	branchOptions := gitlab.ListProtectedBranchesOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	for {
+		branches, resp, err := client.ProtectedBranches.ListProtectedBranches(projectID, &branchOptions)
+		if err != nil {
+			refs.err = err
+			return refs
+		}
+		for _, branch := range branches {
+			refs.patterns = append(refs.patterns, branch.Name)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		branchOptions.ListOptions.Page = resp.NextPage
+	}
+
+	tagOptions := gitlab.ListProtectedTagsOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+	for {
+		tags, resp, err := client.ProtectedTags.ListProtectedTags(projectID, &tagOptions)
+		if err != nil {
+			refs.err = err
+			return refs
+		}
+		for _, tag := range tags {
+			refs.patterns = append(refs.patterns, tag.Name)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		tagOptions.Page = resp.NextPage
+	}
+
+	return refs
+}
+
+func isProtected(patterns []string, ref string) bool {
+	for _, pattern := range patterns {
+		if MatchesRefPattern(pattern, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(packageTypes []string, packageType string) bool {
+	for _, t := range packageTypes {
+		if strings.EqualFold(t, packageType) {
+			return true
+		}
+	}
+	return false
+}
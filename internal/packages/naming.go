@@ -0,0 +1,68 @@
+package packages
+
+import (
+	"regexp"
+	"strings"
+)
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// CollidesWithPublicNamespace reports whether a package name follows the naming convention of the package type's
+// public registry without an organisation specific scope or prefix, meaning a package with the same name could be
+// published to the public registry and resolved in its place.
+// npm packages collide when they are unscoped, or when prefixes are given and their scope is not one of them.
+// PyPI, NuGet and Conan have flat public namespaces, so their packages collide unless their name starts with one of the
+// prefixes. Maven Central verifies group ID ownership, so Maven packages only collide when prefixes are given and the
+// group ID does not start with one of them. Go modules are resolved by their import path, and generic packages are not
+// resolved from a public registry, so they never collide.
+func CollidesWithPublicNamespace(packageType string, name string, prefixes []string) bool {
+	switch strings.ToLower(packageType) {
+	case "npm":
+		if !strings.HasPrefix(name, "@") || !strings.Contains(name, "/") {
+			return true
+		}
+		if len(prefixes) == 0 {
+			return false
+		}
+		scope := strings.SplitN(name, "/", 2)[0]
+		for _, prefix := range prefixes {
+			if strings.EqualFold(scope, "@"+strings.TrimPrefix(prefix, "@")) {
+				return false
+			}
+		}
+		return true
+	case "pypi":
+		return !hasPrefix(normalizePyPIName(name), prefixes, normalizePyPIName)
+	case "nuget", "conan":
+		return !hasPrefix(strings.ToLower(name), prefixes, strings.ToLower)
+	case "maven":
+		if len(prefixes) == 0 {
+			return false
+		}
+		return !hasPrefix(strings.ToLower(strings.ReplaceAll(name, "/", ".")), prefixes, strings.ToLower)
+	}
+	return false
+}
+
+func hasPrefix(name string, prefixes []string, normalize func(string) string) bool {
+	for _, prefix := range prefixes {
+		if p := normalize(prefix); p != "" && strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePyPIName(name string) string {
+	return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// MatchesRefPattern reports whether a ref matches a protected branch or tag name, which may contain * wildcards.
+func MatchesRefPattern(pattern string, ref string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == ref
+	}
+	expression := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expression, ref)
+	return err == nil && matched
+}
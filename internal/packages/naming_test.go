@@ -0,0 +1,63 @@
+package packages_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/packages"
+)
+
+func TestCollidesWithPublicNamespace(t *testing.T) {
+	prefixes := []string{"@acme", "com.acme", "acme-"}
+	tests := []struct {
+		name        string
+		packageType string
+		packageName string
+		prefixes    []string
+		want        bool
+	}{
+		{name: "Test npm Unscoped", packageType: "npm", packageName: "internal-utils", prefixes: nil, want: true},
+		{name: "Test npm Scoped", packageType: "npm", packageName: "@acme/internal-utils", prefixes: nil, want: false},
+		{name: "Test npm Foreign Scope", packageType: "npm", packageName: "@other/internal-utils", prefixes: prefixes, want: true},
+		{name: "Test npm Matching Scope", packageType: "npm", packageName: "@acme/internal-utils", prefixes: prefixes, want: false},
+		{name: "Test PyPI No Prefix", packageType: "pypi", packageName: "internal_utils", prefixes: prefixes, want: true},
+		{name: "Test PyPI Normalized Prefix", packageType: "pypi", packageName: "Acme_Internal.Utils", prefixes: prefixes, want: false},
+		{name: "Test NuGet Without Prefixes", packageType: "nuget", packageName: "Acme.Utils", prefixes: nil, want: true},
+		{name: "Test Maven Without Prefixes", packageType: "maven", packageName: "org/example/utils", prefixes: nil, want: false},
+		{name: "Test Maven Foreign Group", packageType: "maven", packageName: "org/example/utils", prefixes: prefixes, want: true},
+		{name: "Test Maven Matching Group", packageType: "maven", packageName: "com/acme/utils", prefixes: prefixes, want: false},
+		{name: "Test Go Module", packageType: "golang", packageName: "utils", prefixes: prefixes, want: false},
+		{name: "Test Generic", packageType: "generic", packageName: "utils", prefixes: prefixes, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := packages.CollidesWithPublicNamespace(tt.packageType, tt.packageName, tt.prefixes)
+			if got != tt.want {
+				t.Errorf("CollidesWithPublicNamespace(%s, %s) = %t, want %t", tt.packageType, tt.packageName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesRefPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		ref     string
+		want    bool
+	}{
+		{name: "Test Exact Match", pattern: "main", ref: "main", want: true},
+		{name: "Test Exact Mismatch", pattern: "main", ref: "main-old", want: false},
+		{name: "Test Wildcard Match", pattern: "release/*", ref: "release/1.0", want: true},
+		{name: "Test Wildcard Mismatch", pattern: "release/*", ref: "feature/release", want: false},
+		{name: "Test Wildcard Tag", pattern: "v*", ref: "v1.2.3", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := packages.MatchesRefPattern(tt.pattern, tt.ref); got != tt.want {
+				t.Errorf("MatchesRefPattern(%s, %s) = %t, want %t", tt.pattern, tt.ref, got, tt.want)
+			}
+		})
+	}
+}
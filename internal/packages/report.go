@@ -0,0 +1,57 @@
+package packages
+
+import (
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// Finding represents a dependency confusion risk detected during the packages audit.
+type Finding string
+
+const (
+	FindingPublicNamespaceCollision Finding = "public_namespace_collision"
+	FindingUnprotectedRef           Finding = "unprotected_ref"
+	FindingManuallyPublished        Finding = "manually_published"
+)
+
+// Pipeline represents the CI pipeline that published a package. RefProtected is nil when the protection status of the
+// ref could not be determined.
+type Pipeline struct {
+	ID           int    `json:"id" yaml:"id"`
+	Ref          string `json:"ref" yaml:"ref"`
+	SHA          string `json:"sha" yaml:"sha"`
+	WebURL       string `json:"web_url" yaml:"web_url"`
+	Username     string `json:"username" yaml:"username"`
+	RefProtected *bool  `json:"ref_protected" yaml:"ref_protected"`
+}
+
+// Package represents a package published to a Gitlab package registry, along with the findings raised for it.
+type Package struct {
+	ID          int        `json:"id" yaml:"id"`
+	Name        string     `json:"name" yaml:"name"`
+	Version     string     `json:"version" yaml:"version"`
+	Type        string     `json:"type" yaml:"type"`
+	Status      string     `json:"status" yaml:"status"`
+	ProjectID   int        `json:"project_id" yaml:"project_id"`
+	ProjectPath string     `json:"project_path" yaml:"project_path"`
+	WebPath     string     `json:"web_path" yaml:"web_path"`
+	CreatedAt   *time.Time `json:"created_at" yaml:"created_at"`
+	Pipeline    *Pipeline  `json:"pipeline" yaml:"pipeline"`
+	Findings    []Finding  `json:"findings" yaml:"findings"`
+}
+
+// GitlabResources represents a collection of Gitlab packages.
+type GitlabResources struct {
+	Packages []*Package `json:"packages" yaml:"packages"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
@@ -0,0 +1,60 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
+	"github.com/xanzy/go-gitlab"
+)
+
+// WalkSubgroups recursively visits every subgroup beneath the provided group, depth first, calling visit for each one.
+// If visit returns an error for a subgroup, that error is passed to onError and the subgroup's own subgroups are not
+// walked. Errors listing the subgroups of a nested subgroup are also passed to onError, while an error listing the
+// subgroups of the provided group is returned.
+// When a checkpoint is provided, the subgroups listed by a previous run are visited again without listing them, and
+// listing continues from the page the previous run stopped at.
+func WalkSubgroups(ctx context.Context, client *gitlab.Client, groupID string, cp *checkpoint.Checkpoint, visit func(subgroup *gitlab.Group) error, onError func(err error)) error {
+	subGroupOptions := gitlab.ListSubGroupsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	walk := func(subgroups []*gitlab.Group) {
+		for _, subgroup := range subgroups {
+			if err := visit(subgroup); err != nil {
+				onError(err)
+				continue
+			}
+			err := WalkSubgroups(ctx, client, fmt.Sprintf("%d", subgroup.ID), cp, visit, onError)
+			if err != nil {
+				onError(err)
+			}
+		}
+	}
+
+	listing := fmt.Sprintf("groups/%s/subgroups", groupID)
+	var listed []*gitlab.Group
+	page, err := cp.Position(listing, &listed)
+	if err != nil {
+		return err
+	}
+	walk(listed)
+
+	for page != 0 {
+		subGroupOptions.Page = page
+		subgroups, resp, err := client.Groups.ListSubGroups(groupID, &subGroupOptions, gitlab.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if err := cp.Advance(listing, subgroups, resp.NextPage); err != nil {
+			return err
+		}
+
+		walk(subgroups)
+		page = resp.NextPage
+	}
+
+	return nil
+}
@@ -5,7 +5,12 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
+	"github.com/Method-Security/gitlabctl/internal/ndjson"
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/telemetry"
 	"github.com/xanzy/go-gitlab"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // EnumerateProjectsOptions holds the options for enumerating projects.
@@ -13,10 +18,14 @@ import (
 // by the authenticated user when set to true.
 // The Archived field is used to filter for archived projects, including archived when set to true.
 // The GroupID field is used to filter projects by group ID, only returning projects that are part of the specified group.
+// The Stream field, when set, receives the projects as they are fetched instead of the report.
+// The Checkpoint field, when set, records the progress of a group enumeration so an interrupted run can resume it.
 type EnumerateProjectsOptions struct {
-	Mine     bool   `json:"mine"`
-	Archived bool   `json:"archived"`
-	GroupID  string `json:"group_id"`
+	Mine       bool                   `json:"mine" yaml:"mine"`
+	Archived   bool                   `json:"archived" yaml:"archived"`
+	GroupID    string                 `json:"group_id" yaml:"group_id"`
+	Stream     *ndjson.Writer         `json:"-" yaml:"-"`
+	Checkpoint *checkpoint.Checkpoint `json:"-" yaml:"-"`
 }
 
 // FindGroupByName searches for a group by name using the provided Gitlab client. If the group is found, it is returned.
@@ -45,7 +54,7 @@ func FindGroupByName(ctx context.Context, client *gitlab.Client, groupName strin
 func EnumerateProjects(ctx context.Context, baseURL string, options *EnumerateProjectsOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
 	report := &GitlabResourceReport{
 		Resources: GitlabResources{},
-		Errors:    []string{},
+		ErrorLog:  nonfatal.NewErrorLog(),
 		BaseURL:   baseURL,
 	}
 	filterOptions := gitlab.ListProjectsOptions{
@@ -64,11 +73,11 @@ func EnumerateProjects(ctx context.Context, baseURL string, options *EnumeratePr
 	for {
 		projects, resp, err := client.Projects.ListProjects(&filterOptions)
 		if err != nil {
-			report.Errors = append(report.Errors, err.Error())
+			report.AddError(nonfatal.New("", "", err))
 			break
 		}
 
-		report.Resources.Projects = append(report.Resources.Projects, projects...)
+		collectProjects(report, options, projects)
 		if resp.NextPage == 0 {
 			break
 		}
@@ -83,19 +92,36 @@ func EnumerateProjects(ctx context.Context, baseURL string, options *EnumeratePr
 func EnumerateProjectsForGroup(ctx context.Context, baseURL string, client *gitlab.Client, options *EnumerateProjectsOptions) (*GitlabResourceReport, error) {
 	report := &GitlabResourceReport{
 		Resources: GitlabResources{},
-		Errors:    []string{},
+		ErrorLog:  nonfatal.NewErrorLog(),
 		BaseURL:   baseURL,
 	}
 
 	err := fetchGroupAndSubgroupProjects(ctx, client, options.GroupID, options, report)
 	if err != nil {
-		report.Errors = append(report.Errors, err.Error())
+		report.AddError(nonfatal.New("group", options.GroupID, err))
 	}
 
 	return report, nil
 }
 
 func fetchGroupAndSubgroupProjects(ctx context.Context, client *gitlab.Client, groupID string, options *EnumerateProjectsOptions, report *GitlabResourceReport) error {
+	err := fetchGroupProjects(ctx, client, groupID, options, report)
+	if err != nil {
+		return err
+	}
+
+	return WalkSubgroups(ctx, client, groupID, options.Checkpoint, func(subgroup *gitlab.Group) error {
+		return fetchGroupProjects(ctx, client, fmt.Sprintf("%d", subgroup.ID), options, report)
+	}, func(err error) {
+		report.AddError(nonfatal.New("group", groupID, err))
+	})
+}
+
+// fetchGroupProjects lists the projects of a single group, traced as a span so slow groups stand out.
+func fetchGroupProjects(ctx context.Context, client *gitlab.Client, groupID string, options *EnumerateProjectsOptions, report *GitlabResourceReport) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "list group projects", attribute.String("gitlab.group.id", groupID))
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	filterOptions := gitlab.ListGroupProjectsOptions{
 		ListOptions: gitlab.ListOptions{
 			Page:    1,
@@ -109,45 +135,36 @@ func fetchGroupAndSubgroupProjects(ctx context.Context, client *gitlab.Client, g
 		filterOptions.Owned = gitlab.Ptr(options.Mine)
 	}
 
-	for {
-		projects, resp, err := client.Groups.ListGroupProjects(groupID, &filterOptions)
-		if err != nil {
-			return err
-		}
-
-		report.Resources.Projects = append(report.Resources.Projects, projects...)
-		if resp.NextPage == 0 {
-			break
-		}
-		filterOptions.ListOptions.Page = resp.NextPage
-	}
-
-	// Fetch subgroups
-	subGroupOptions := gitlab.ListSubGroupsOptions{
-		ListOptions: gitlab.ListOptions{
-			Page:    1,
-			PerPage: 100,
-		},
+	listing := fmt.Sprintf("groups/%s/projects", groupID)
+	var listed []*gitlab.Project
+	page, err := options.Checkpoint.Position(listing, &listed)
+	if err != nil {
+		return err
 	}
+	collectProjects(report, options, listed)
 
-	for {
-		subgroups, resp, err := client.Groups.ListSubGroups(groupID, &subGroupOptions)
+	for page != 0 {
+		filterOptions.ListOptions.Page = page
+		projects, resp, err := client.Groups.ListGroupProjects(groupID, &filterOptions, gitlab.WithContext(ctx))
 		if err != nil {
 			return err
 		}
-
-		for _, subgroup := range subgroups {
-			err := fetchGroupAndSubgroupProjects(ctx, client, fmt.Sprintf("%d", subgroup.ID), options, report)
-			if err != nil {
-				report.Errors = append(report.Errors, err.Error())
-			}
+		if err := options.Checkpoint.Advance(listing, projects, resp.NextPage); err != nil {
+			return err
 		}
 
-		if resp.NextPage == 0 {
-			break
-		}
-		subGroupOptions.Page = resp.NextPage
+		collectProjects(report, options, projects)
+		page = resp.NextPage
 	}
 
 	return nil
 }
+
+// collectProjects streams projects when the options hold a stream, and adds them to the report otherwise.
+func collectProjects(report *GitlabResourceReport, options *EnumerateProjectsOptions, projects []*gitlab.Project) {
+	for _, project := range projects {
+		if !options.Stream.Emit("projects", project) {
+			report.Resources.Projects = append(report.Resources.Projects, project)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package projects
 
 import (
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
 	"github.com/xanzy/go-gitlab"
 )
 
@@ -13,5 +15,7 @@ type GitlabResources struct {
 type GitlabResourceReport struct {
 	BaseURL   string          `json:"base_url" yaml:"base_url"`
 	Resources GitlabResources `json:"resources" yaml:"resources"`
-	Errors    []string        `json:"errors" yaml:"errors"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
 }
@@ -0,0 +1,208 @@
+// Package registry holds the data structures and logic necessary to inventory Gitlab container registry repositories
+// and their image tags, flagging public registries, stale tags and mutable tags.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+// EnumerateRegistryOptions holds the options for enumerating container registry repositories.
+// The ProjectID and GroupID fields select the project or group whose registry repositories are enumerated. One of
+// them is required.
+// The StaleDays field is the age in days after which a tag is considered stale, and StaleTagThreshold is the number of
+// stale tags at which a repository is flagged.
+// The MutableTags field lists tag names that are expected to be overwritten (e.g. latest) and so are flagged.
+// The TagDetails field fetches the digest, size and creation date of every tag, which requires one request per tag.
+type EnumerateRegistryOptions struct {
+	ProjectID         string   `json:"project_id" yaml:"project_id"`
+	GroupID           string   `json:"group_id" yaml:"group_id"`
+	StaleDays         int      `json:"stale_days" yaml:"stale_days"`
+	StaleTagThreshold int      `json:"stale_tag_threshold" yaml:"stale_tag_threshold"`
+	MutableTags       []string `json:"mutable_tags" yaml:"mutable_tags"`
+	TagDetails        bool     `json:"tag_details" yaml:"tag_details"`
+}
+
+// EnumerateRegistry enumerates the container registry repositories of a project or group along with their tags. The
+// function returns a GitlabResourceReport containing the repositories and non-fatal errors encountered during the
+// enumeration process.
+func EnumerateRegistry(ctx context.Context, baseURL string, options *EnumerateRegistryOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		Resources: GitlabResources{Repositories: []*Repository{}},
+		ErrorLog:  nonfatal.NewErrorLog(),
+		BaseURL:   baseURL,
+	}
+	if options.ProjectID == "" && options.GroupID == "" {
+		return report, errors.New("either a project ID or a group ID is required")
+	}
+
+	listOptions := gitlab.ListRegistryRepositoriesOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	projects := map[int]*gitlab.Project{}
+	now := time.Now()
+	for {
+		var repositories []*gitlab.RegistryRepository
+		var resp *gitlab.Response
+		var err error
+		if options.ProjectID != "" {
+			repositories, resp, err = client.ContainerRegistry.ListProjectRegistryRepositories(options.ProjectID, &listOptions)
+		} else {
+			repositories, resp, err = client.ContainerRegistry.ListGroupRegistryRepositories(options.GroupID, &listOptions)
+		}
+		if err != nil {
+			if options.ProjectID != "" {
+				report.AddError(nonfatal.New("project", options.ProjectID, err))
+			} else {
+				report.AddError(nonfatal.New("group", options.GroupID, err))
+			}
+			break
+		}
+
+		for _, repository := range repositories {
+			project, ok := projects[repository.ProjectID]
+			if !ok {
+				project, _, err = client.Projects.GetProject(repository.ProjectID, &gitlab.GetProjectOptions{})
+				if err != nil {
+					report.AddError(nonfatal.Newf("project", fmt.Sprintf("%d", repository.ProjectID), err, "failed to get project %d: %s", repository.ProjectID, err.Error()))
+				}
+				projects[repository.ProjectID] = project
+			}
+
+			audit, err := auditRepository(client, repository, project, options, now)
+			if err != nil {
+				report.AddError(nonfatal.New("registry_repository", repository.Path, err))
+			}
+			report.Resources.Repositories = append(report.Resources.Repositories, audit)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.ListOptions.Page = resp.NextPage
+	}
+
+	return report, nil
+}
+
+func auditRepository(client *gitlab.Client, repository *gitlab.RegistryRepository, project *gitlab.Project, options *EnumerateRegistryOptions, now time.Time) (*Repository, error) {
+	audit := &Repository{
+		ID:                     repository.ID,
+		Name:                   repository.Name,
+		Path:                   repository.Path,
+		Location:               repository.Location,
+		ProjectID:              repository.ProjectID,
+		CreatedAt:              repository.CreatedAt,
+		CleanupPolicyStartedAt: repository.CleanupPolicyStartedAt,
+		Tags:                   []*Tag{},
+		Findings:               []Finding{},
+	}
+	if project != nil {
+		audit.ProjectPath = project.PathWithNamespace
+		audit.Public = IsPublicRegistry(project)
+	}
+
+	tagOptions := gitlab.ListRegistryRepositoryTagsOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+	var tagErr error
+	for {
+		tags, resp, err := client.ContainerRegistry.ListRegistryRepositoryTags(repository.ProjectID, repository.ID, &tagOptions)
+		if err != nil {
+			tagErr = fmt.Errorf("failed to list tags for registry repository %s: %w", repository.Location, err)
+			break
+		}
+
+		for _, tag := range tags {
+			if options.TagDetails {
+				detail, _, err := client.ContainerRegistry.GetRegistryRepositoryTagDetail(repository.ProjectID, repository.ID, tag.Name)
+				if err != nil {
+					tagErr = errors.Join(tagErr, fmt.Errorf("failed to get tag %s: %w", tag.Location, err))
+				} else {
+					tag = detail
+				}
+			}
+			audit.Tags = append(audit.Tags, NewTag(tag, options, now))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		tagOptions.Page = resp.NextPage
+	}
+
+	audit.TagsCount = len(audit.Tags)
+	audit.Findings = EvaluateRepository(audit, options)
+	return audit, tagErr
+}
+
+// IsPublicRegistry reports whether a project's container registry can be pulled anonymously, which is the case for
+// public projects whose registry access level is not restricted to project members.
+func IsPublicRegistry(project *gitlab.Project) bool {
+	if project.Visibility != gitlab.PublicVisibility {
+		return false
+	}
+	if project.ContainerRegistryAccessLevel != "" {
+		return project.ContainerRegistryAccessLevel == gitlab.EnabledAccessControl
+	}
+	return project.ContainerRegistryEnabled
+}
+
+// NewTag converts a Gitlab registry tag into a Tag, marking it as stale when it is older than the configured number of
+// days and as mutable when its name is one of the configured mutable tags.
+func NewTag(tag *gitlab.RegistryRepositoryTag, options *EnumerateRegistryOptions, now time.Time) *Tag {
+	result := &Tag{
+		Name:      tag.Name,
+		Location:  tag.Location,
+		Digest:    tag.Digest,
+		Revision:  tag.Revision,
+		TotalSize: tag.TotalSize,
+		CreatedAt: tag.CreatedAt,
+	}
+	if tag.Digest != "" {
+		result.Reference = strings.TrimSuffix(tag.Location, ":"+tag.Name) + "@" + tag.Digest
+	}
+	if tag.CreatedAt != nil && options.StaleDays > 0 {
+		result.Stale = tag.CreatedAt.Before(now.AddDate(0, 0, -options.StaleDays))
+	}
+	for _, mutable := range options.MutableTags {
+		if tag.Name == mutable {
+			result.Mutable = true
+		}
+	}
+	return result
+}
+
+// EvaluateRepository counts the stale tags of a repository and returns its findings.
+func EvaluateRepository(repository *Repository, options *EnumerateRegistryOptions) []Finding {
+	findings := []Finding{}
+	if repository.Public {
+		findings = append(findings, FindingPublicRegistry)
+	}
+
+	repository.StaleTagsCount = 0
+	mutable := false
+	for _, tag := range repository.Tags {
+		if tag.Stale {
+			repository.StaleTagsCount++
+		}
+		mutable = mutable || tag.Mutable
+	}
+	if options.StaleTagThreshold > 0 && repository.StaleTagsCount >= options.StaleTagThreshold {
+		findings = append(findings, FindingStaleTags)
+	}
+	if mutable {
+		findings = append(findings, FindingMutableTag)
+	}
+	return findings
+}
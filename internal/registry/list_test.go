@@ -0,0 +1,76 @@
+package registry_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/registry"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestIsPublicRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		project *gitlab.Project
+		want    bool
+	}{
+		{
+			name:    "Test Public Enabled",
+			project: &gitlab.Project{Visibility: gitlab.PublicVisibility, ContainerRegistryAccessLevel: gitlab.EnabledAccessControl},
+			want:    true,
+		},
+		{
+			name:    "Test Public Private Registry",
+			project: &gitlab.Project{Visibility: gitlab.PublicVisibility, ContainerRegistryAccessLevel: gitlab.PrivateAccessControl},
+			want:    false,
+		},
+		{
+			name:    "Test Private Project",
+			project: &gitlab.Project{Visibility: gitlab.PrivateVisibility, ContainerRegistryAccessLevel: gitlab.EnabledAccessControl},
+			want:    false,
+		},
+		{
+			name:    "Test Public Legacy Enabled",
+			project: &gitlab.Project{Visibility: gitlab.PublicVisibility, ContainerRegistryEnabled: true},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.IsPublicRegistry(tt.project); got != tt.want {
+				t.Errorf("IsPublicRegistry() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRepository(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(-1, 0, 0)
+	recent := now.AddDate(0, 0, -1)
+	options := &registry.EnumerateRegistryOptions{StaleDays: 90, StaleTagThreshold: 2, MutableTags: []string{"latest"}}
+
+	tags := []*registry.Tag{
+		registry.NewTag(&gitlab.RegistryRepositoryTag{Name: "v1", Location: "registry.example.com/acme/app:v1", Digest: "sha256:aaa", CreatedAt: &old}, options, now),
+		registry.NewTag(&gitlab.RegistryRepositoryTag{Name: "v2", Location: "registry.example.com/acme/app:v2", CreatedAt: &old}, options, now),
+		registry.NewTag(&gitlab.RegistryRepositoryTag{Name: "latest", Location: "registry.example.com/acme/app:latest", CreatedAt: &recent}, options, now),
+	}
+	if tags[0].Reference != "registry.example.com/acme/app@sha256:aaa" {
+		t.Errorf("NewTag() reference = %s, want registry.example.com/acme/app@sha256:aaa", tags[0].Reference)
+	}
+	if !tags[0].Stale || tags[2].Stale || !tags[2].Mutable || tags[0].Mutable {
+		t.Errorf("NewTag() stale/mutable flags incorrect: %+v %+v", tags[0], tags[2])
+	}
+
+	repository := &registry.Repository{Public: true, Tags: tags}
+	got := registry.EvaluateRepository(repository, options)
+	want := []registry.Finding{registry.FindingPublicRegistry, registry.FindingStaleTags, registry.FindingMutableTag}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EvaluateRepository() = %v, want %v", got, want)
+	}
+	if repository.StaleTagsCount != 2 {
+		t.Errorf("EvaluateRepository() stale tags count = %d, want 2", repository.StaleTagsCount)
+	}
+}
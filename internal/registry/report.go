@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// Finding represents a container registry issue detected during the registry audit.
+type Finding string
+
+const (
+	FindingPublicRegistry Finding = "public_registry"
+	FindingStaleTags      Finding = "stale_tags"
+	FindingMutableTag     Finding = "mutable_tag"
+)
+
+// Tag represents a single image tag in a container registry repository. The Reference field is the digest pinned
+// image reference (e.g. registry.gitlab.com/group/project@sha256:...) that can be passed to container scanners.
+type Tag struct {
+	Name      string     `json:"name" yaml:"name"`
+	Location  string     `json:"location" yaml:"location"`
+	Digest    string     `json:"digest" yaml:"digest"`
+	Reference string     `json:"reference" yaml:"reference"`
+	Revision  string     `json:"revision" yaml:"revision"`
+	TotalSize int        `json:"total_size" yaml:"total_size"`
+	CreatedAt *time.Time `json:"created_at" yaml:"created_at"`
+	Stale     bool       `json:"stale" yaml:"stale"`
+	Mutable   bool       `json:"mutable" yaml:"mutable"`
+}
+
+// Repository represents a container registry repository, its tags and the findings raised for it.
+type Repository struct {
+	ID                     int        `json:"id" yaml:"id"`
+	Name                   string     `json:"name" yaml:"name"`
+	Path                   string     `json:"path" yaml:"path"`
+	Location               string     `json:"location" yaml:"location"`
+	ProjectID              int        `json:"project_id" yaml:"project_id"`
+	ProjectPath            string     `json:"project_path" yaml:"project_path"`
+	Public                 bool       `json:"public" yaml:"public"`
+	CreatedAt              *time.Time `json:"created_at" yaml:"created_at"`
+	CleanupPolicyStartedAt *time.Time `json:"cleanup_policy_started_at" yaml:"cleanup_policy_started_at"`
+	TagsCount              int        `json:"tags_count" yaml:"tags_count"`
+	StaleTagsCount         int        `json:"stale_tags_count" yaml:"stale_tags_count"`
+	Tags                   []*Tag     `json:"tags" yaml:"tags"`
+	Findings               []Finding  `json:"findings" yaml:"findings"`
+}
+
+// GitlabResources represents a collection of Gitlab container registry repositories.
+type GitlabResources struct {
+	Repositories []*Repository `json:"repositories" yaml:"repositories"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
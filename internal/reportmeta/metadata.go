@@ -0,0 +1,193 @@
+// Package reportmeta holds the metadata embedded in every report, recording how the report was produced so a scan can be
+// reproduced and compared with others: the gitlabctl version and the options it ran with, the Gitlab instance and user
+// it ran against, the API calls it made and how long each phase of the run took.
+package reportmeta
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/httpcache"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	// PhaseSetup covers loading the configuration, creating the client and looking up the instance and user.
+	PhaseSetup = "setup"
+	// PhaseRun covers running the command itself.
+	PhaseRun = "run"
+)
+
+// Instance is the version and edition of the Gitlab instance a report was produced from.
+type Instance struct {
+	Version  string `json:"version" yaml:"version"`
+	Revision string `json:"revision" yaml:"revision"`
+	Edition  string `json:"edition" yaml:"edition"`
+}
+
+// APIStats counts the Gitlab API requests made while producing a report. Calls counts every request attempt, including
+// retries and requests served from the response cache. Pages counts the responses that were a page of a paginated
+// listing, and CacheHits the responses served from the cache without contacting Gitlab. IdentityCalls counts the
+// request attempts made to look up the instance and user, which are not counted in the other statistics.
+type APIStats struct {
+	Calls         int `json:"calls" yaml:"calls"`
+	Pages         int `json:"pages" yaml:"pages"`
+	Retries       int `json:"retries" yaml:"retries"`
+	CacheHits     int `json:"cache_hits" yaml:"cache_hits"`
+	IdentityCalls int `json:"identity_calls" yaml:"identity_calls"`
+}
+
+// identityKey is the context key marking the requests that look up the instance and user.
+type identityKey struct{}
+
+// IdentityContext returns a context marking the requests made with it as looking up the instance and user, so they
+// are counted in IdentityCalls rather than in the statistics of the scan.
+func IdentityContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, identityKey{}, true)
+}
+
+func isIdentity(req *http.Request) bool {
+	return req != nil && req.Context().Value(identityKey{}) != nil
+}
+
+// Phase is a phase of a run and how long it took.
+type Phase struct {
+	Name            string    `json:"name" yaml:"name"`
+	StartedAt       time.Time `json:"started_at" yaml:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds" yaml:"duration_seconds"`
+}
+
+// Metadata describes how a report was produced. Options holds the effective options of the command, and Instance and
+// Username are empty when they could not be looked up. Every method of a nil Metadata is a no-op, and it is safe for
+// concurrent use.
+type Metadata struct {
+	ToolVersion string      `json:"tool_version" yaml:"tool_version"`
+	Command     string      `json:"command" yaml:"command"`
+	Options     interface{} `json:"options" yaml:"options"`
+	Instance    *Instance   `json:"instance" yaml:"instance"`
+	Username    string      `json:"username" yaml:"username"`
+	API         APIStats    `json:"api" yaml:"api"`
+	Phases      []*Phase    `json:"phases" yaml:"phases"`
+
+	mu      sync.Mutex
+	current *Phase
+	now     func() time.Time
+}
+
+// New creates the Metadata of a run of a command, such as "gitlabctl projects", by a version of gitlabctl.
+func New(toolVersion string, command string) *Metadata {
+	return &Metadata{ToolVersion: toolVersion, Command: command, Phases: []*Phase{}, now: time.Now}
+}
+
+// SetOptions records the effective options of the command.
+func (m *Metadata) SetOptions(options interface{}) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Options = options
+}
+
+// SetIdentity records the Gitlab instance and the authenticated user the command ran against.
+func (m *Metadata) SetIdentity(instance *Instance, username string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Instance = instance
+	m.Username = username
+}
+
+// Enter starts a phase of the run, ending the phase in progress.
+func (m *Metadata) Enter(name string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.end(now)
+	m.current = &Phase{Name: name, StartedAt: now.UTC()}
+	m.Phases = append(m.Phases, m.current)
+}
+
+// Finish ends the phase in progress.
+func (m *Metadata) Finish() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.end(m.now())
+}
+
+func (m *Metadata) end(now time.Time) {
+	if m.current == nil {
+		return
+	}
+	m.current.DurationSeconds = now.Sub(m.current.StartedAt).Seconds()
+	m.current = nil
+}
+
+// ClientOptions returns the options of a Gitlab client counting its requests into the API statistics.
+func (m *Metadata) ClientOptions() []gitlab.ClientOptionFunc {
+	if m == nil {
+		return nil
+	}
+	return []gitlab.ClientOptionFunc{
+		gitlab.WithRequestLogHook(func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if isIdentity(req) {
+				m.API.IdentityCalls++
+				return
+			}
+			m.API.Calls++
+			if attempt > 0 {
+				m.API.Retries++
+			}
+		}),
+		gitlab.WithResponseLogHook(func(_ retryablehttp.Logger, resp *http.Response) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if isIdentity(resp.Request) {
+				return
+			}
+			// Offset pagination sets X-Page, while keyset pagination only sets Link
+			if resp.Header.Get("X-Page") != "" || resp.Header.Get("Link") != "" {
+				m.API.Pages++
+			}
+			if resp.Header.Get(httpcache.StatusHeader) == "hit" {
+				m.API.CacheHits++
+			}
+		}),
+	}
+}
+
+// Section holds the metadata of a report, and is embedded in every report.
+type Section struct {
+	Metadata *Metadata `json:"metadata" yaml:"metadata"`
+}
+
+// SetMetadata sets the metadata of the report.
+func (s *Section) SetMetadata(m *Metadata) {
+	s.Metadata = m
+}
+
+// Attach sets the metadata of a report embedding a Section. Reports without one, and nil reports, are left as is.
+func Attach(report interface{}, m *Metadata) {
+	r, ok := report.(interface{ SetMetadata(*Metadata) })
+	if !ok {
+		return
+	}
+	if v := reflect.ValueOf(report); v.Kind() == reflect.Ptr && v.IsNil() {
+		return
+	}
+	r.SetMetadata(m)
+}
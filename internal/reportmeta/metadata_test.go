@@ -0,0 +1,61 @@
+package reportmeta_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+type report struct {
+	Name string `json:"name"`
+
+	reportmeta.Section `yaml:",inline"`
+}
+
+func TestPhases(t *testing.T) {
+	m := reportmeta.New("1.0.0", "gitlabctl projects")
+	m.Enter(reportmeta.PhaseSetup)
+	m.Enter(reportmeta.PhaseRun)
+	m.Finish()
+	m.Finish()
+
+	if len(m.Phases) != 2 || m.Phases[0].Name != reportmeta.PhaseSetup || m.Phases[1].Name != reportmeta.PhaseRun {
+		t.Fatalf("Phases = %v, want setup and run", m.Phases)
+	}
+	if m.Phases[1].StartedAt.Before(m.Phases[0].StartedAt) || m.Phases[0].DurationSeconds < 0 || m.Phases[1].DurationSeconds < 0 {
+		t.Errorf("Phases = %+v, %+v, want consecutive phases", *m.Phases[0], *m.Phases[1])
+	}
+}
+
+func TestAttach(t *testing.T) {
+	m := reportmeta.New("1.0.0", "gitlabctl projects")
+	tests := []struct {
+		name   string
+		report interface{}
+	}{
+		{name: "Test Report", report: &report{}},
+		{name: "Test Nil Report", report: (*report)(nil)},
+		{name: "Test Other Content", report: map[string]string{}},
+		{name: "Test No Content", report: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportmeta.Attach(tt.report, m)
+			if r, ok := tt.report.(*report); ok && r != nil && r.Metadata != m {
+				t.Errorf("Metadata = %v, want the attached metadata", r.Metadata)
+			}
+		})
+	}
+}
+
+func TestNilMetadata(t *testing.T) {
+	var m *reportmeta.Metadata
+	m.SetOptions(struct{}{})
+	m.SetIdentity(&reportmeta.Instance{Version: "17.4.0-ee"}, "root")
+	m.Enter(reportmeta.PhaseSetup)
+	m.Finish()
+	if options := m.ClientOptions(); options != nil {
+		t.Errorf("ClientOptions() = %v, want none", options)
+	}
+}
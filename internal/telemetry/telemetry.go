@@ -0,0 +1,251 @@
+// Package telemetry instruments gitlabctl with OpenTelemetry, so runs inside long-lived automation can be observed: every
+// run is traced as a span, with a child span for each of its phases, the groups and projects it scans, and every
+// request sent to the Gitlab API, while metrics count the requests by endpoint and status code, their latency and how
+// often Gitlab throttled them.
+//
+// Traces and metrics are exported over OTLP/HTTP, configured by an endpoint or the standard OTEL_EXPORTER_OTLP_*
+// environment variables, and to a local file of JSON lines. Without either, the global OpenTelemetry providers are
+// left as no-ops, so instrumented code costs nothing.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName names the tracer and meter gitlabctl is instrumented with.
+const instrumentationName = "github.com/Method-Security/gitlabctl"
+
+// shutdownTimeout bounds how long exporting the remaining telemetry may delay the end of a run.
+const shutdownTimeout = 5 * time.Second
+
+// otlpEnvironment lists the environment variables that enable exporting over OTLP without an explicit endpoint.
+var otlpEnvironment = []string{
+	"OTEL_EXPORTER_OTLP_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT",
+}
+
+// Options configures where telemetry is exported. TraceFile is the path of a file the spans and metrics are written
+// to as JSON lines. OTLPEndpoint is the URL of an OTLP/HTTP collector, such as http://localhost:4318; when it is empty,
+// the standard OTEL_EXPORTER_OTLP_* environment variables configure the exporter, which is enabled when they set an
+// endpoint.
+type Options struct {
+	ServiceVersion string
+	TraceFile      string
+	OTLPEndpoint   string
+}
+
+// Telemetry traces a run of a gitlabctl command and exports its spans and metrics. Every method of a nil Telemetry is
+// a no-op, so runs without telemetry need no special handling.
+type Telemetry struct {
+	tracer         trace.Tracer
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	file           *os.File
+	phaseDuration  metric.Float64Histogram
+
+	mu        sync.Mutex
+	rootCtx   context.Context
+	root      trace.Span
+	phase     trace.Span
+	phaseName string
+	phaseCtx  context.Context
+	started   time.Time
+	done      bool
+}
+
+// Start sets up the exporters configured by options and starts the span of a run of a command, such as
+// "gitlabctl projects", returning nil when no exporter is configured.
+func Start(ctx context.Context, command string, options Options, getenv func(string) string) (*Telemetry, error) {
+	otlp := options.OTLPEndpoint != ""
+	for _, name := range otlpEnvironment {
+		otlp = otlp || getenv(name) != ""
+	}
+	if !otlp && options.TraceFile == "" {
+		return nil, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "gitlabctl"),
+			attribute.String("service.version", options.ServiceVersion),
+		),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence over the defaults above
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create telemetry resource: %w", err)
+	}
+	traceOptions := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	metricOptions := []sdkmetric.Option{sdkmetric.WithResource(res)}
+
+	t := &Telemetry{}
+	if options.TraceFile != "" {
+		t.file, err = os.OpenFile(options.TraceFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		// Spans and metrics are exported concurrently, so their lines are written one at a time
+		out := &lockedWriter{w: t.file}
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return nil, err
+		}
+		metricExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(out))
+		if err != nil {
+			return nil, err
+		}
+		traceOptions = append(traceOptions, sdktrace.WithBatcher(spanExporter))
+		metricOptions = append(metricOptions, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	}
+	if otlp {
+		spanOptions := []otlptracehttp.Option{}
+		metricExporterOptions := []otlpmetrichttp.Option{}
+		if options.OTLPEndpoint != "" {
+			spanOptions = append(spanOptions, otlptracehttp.WithEndpointURL(options.OTLPEndpoint))
+			metricExporterOptions = append(metricExporterOptions, otlpmetrichttp.WithEndpointURL(options.OTLPEndpoint))
+		}
+		spanExporter, err := otlptracehttp.New(ctx, spanOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		metricExporter, err := otlpmetrichttp.New(ctx, metricExporterOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		traceOptions = append(traceOptions, sdktrace.WithBatcher(spanExporter))
+		metricOptions = append(metricOptions, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	}
+
+	t.tracerProvider = sdktrace.NewTracerProvider(traceOptions...)
+	t.meterProvider = sdkmetric.NewMeterProvider(metricOptions...)
+	otel.SetTracerProvider(t.tracerProvider)
+	otel.SetMeterProvider(t.meterProvider)
+
+	t.tracer = t.tracerProvider.Tracer(instrumentationName)
+	t.phaseDuration, err = t.meterProvider.Meter(instrumentationName).Float64Histogram("gitlabctl.phase.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of the phases of gitlabctl runs"))
+	if err != nil {
+		return nil, err
+	}
+	t.rootCtx, t.root = t.tracer.Start(ctx, command, trace.WithAttributes(attribute.String("gitlabctl.command", command)))
+	t.phaseCtx = t.rootCtx
+	return t, nil
+}
+
+// Enter starts the span of a phase of the run, ending the phase in progress, and returns ctx carrying the span so the
+// work done during the phase is traced beneath it.
+func (t *Telemetry) Enter(ctx context.Context, name string) context.Context {
+	if t == nil {
+		return ctx
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endPhase()
+	t.phaseName = name
+	t.started = time.Now()
+	_, t.phase = t.tracer.Start(t.rootCtx, name, trace.WithTimestamp(t.started))
+	t.phaseCtx = trace.ContextWithSpan(t.rootCtx, t.phase)
+	return trace.ContextWithSpan(ctx, t.phase)
+}
+
+// phaseContext returns the context of the phase in progress, parenting the spans of requests sent without one.
+func (t *Telemetry) phaseContext() context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phaseCtx
+}
+
+func (t *Telemetry) endPhase() {
+	if t.phase == nil {
+		return
+	}
+	t.phase.End()
+	t.phaseDuration.Record(t.rootCtx, time.Since(t.started).Seconds(),
+		metric.WithAttributes(attribute.String("gitlabctl.phase", t.phaseName)))
+	t.phase = nil
+	t.phaseCtx = t.rootCtx
+}
+
+// Shutdown ends the run, marking its span as failed when err is set, and exports the remaining spans and metrics. The
+// global OpenTelemetry providers are reset to no-ops.
+func (t *Telemetry) Shutdown(ctx context.Context, err error) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return nil
+	}
+	t.done = true
+
+	t.endPhase()
+	if err != nil {
+		t.root.RecordError(err)
+		t.root.SetStatus(codes.Error, err.Error())
+	}
+	t.root.End()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	errs := []error{t.tracerProvider.Shutdown(ctx), t.meterProvider.Shutdown(ctx)}
+	if t.file != nil {
+		errs = append(errs, t.file.Close())
+	}
+	otel.SetTracerProvider(tracenoop.NewTracerProvider())
+	otel.SetMeterProvider(metricnoop.NewMeterProvider())
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to export telemetry: %w", err)
+	}
+	return nil
+}
+
+// StartSpan starts a span beneath the span carried by ctx, such as the span of a group being scanned, with the global
+// tracer. The returned context carries the span, to trace the requests made for it beneath it.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan ends a span started with StartSpan, marking it as failed when err is set.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// lockedWriter serializes the writes of the exporters sharing a trace file.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,196 @@
+package telemetry_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/telemetry"
+)
+
+// span is the part of a span written to a trace file the tests check.
+type span struct {
+	Name   string `json:"Name"`
+	Parent struct {
+		SpanID string `json:"SpanID"`
+	} `json:"Parent"`
+	SpanContext struct {
+		SpanID string `json:"SpanID"`
+	} `json:"SpanContext"`
+	Attributes []struct {
+		Key   string `json:"Key"`
+		Value struct {
+			Value interface{} `json:"Value"`
+		} `json:"Value"`
+	} `json:"Attributes"`
+	Status struct {
+		Code string `json:"Code"`
+	} `json:"Status"`
+}
+
+func (s span) attribute(key string) interface{} {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value.Value
+		}
+	}
+	return nil
+}
+
+// readTraceFile splits a trace file into its spans, by name, and the names of the metrics it holds.
+func readTraceFile(t *testing.T, path string) (map[string][]span, map[string]bool) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	spans := map[string][]span{}
+	metrics := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		record := struct {
+			span
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name string `json:"Name"`
+				} `json:"Metrics"`
+			} `json:"ScopeMetrics"`
+		}{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid trace file line %s: %v", scanner.Bytes(), err)
+		}
+		for _, scope := range record.ScopeMetrics {
+			for _, m := range scope.Metrics {
+				metrics[m.Name] = true
+			}
+		}
+		if record.Name != "" {
+			spans[record.Name] = append(spans[record.Name], record.span)
+		}
+	}
+	return spans, metrics
+}
+
+func TestTraceFile(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	tel, err := telemetry.Start(context.Background(), "gitlabctl dependencies", telemetry.Options{ServiceVersion: "test", TraceFile: path}, func(string) string { return "" })
+	if err != nil || tel == nil {
+		t.Fatalf("Start() = %v, %v, want telemetry", tel, err)
+	}
+	tel.Enter(context.Background(), "setup")
+	ctx := tel.Enter(context.Background(), "run")
+	ctx, group := telemetry.StartSpan(ctx, "list group projects")
+
+	client := &http.Client{Transport: tel.Transport(nil)}
+	for _, path := range []string{"/api/v4/projects/1/dependencies", "/api/v4/projects/2/dependencies"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	telemetry.EndSpan(group, nil)
+	// Requests sent without a traced context belong to the phase in progress
+	resp, err := client.Get(srv.URL + "/api/v4/user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := tel.Shutdown(context.Background(), errors.New("1 unsuppressed vulnerabilities")); err != nil {
+		t.Fatal(err)
+	}
+
+	spans, metrics := readTraceFile(t, path)
+	root, run, groups := spans["gitlabctl dependencies"], spans["run"], spans["list group projects"]
+	if len(root) != 1 || root[0].Status.Code != "Error" || len(spans["setup"]) != 1 || len(run) != 1 || len(groups) != 1 {
+		t.Fatalf("spans = %v, want a failed run with setup and run phases and a group", spans)
+	}
+	if run[0].Parent.SpanID != root[0].SpanContext.SpanID || groups[0].Parent.SpanID != run[0].SpanContext.SpanID {
+		t.Error("group span is not beneath the run phase beneath the run")
+	}
+	requestSpans := spans["GET projects/:id/dependencies"]
+	if len(requestSpans) != 2 {
+		t.Fatalf("%d request spans, want 2", len(requestSpans))
+	}
+	for i, wantStatus := range []float64{http.StatusTooManyRequests, http.StatusForbidden} {
+		s := requestSpans[i]
+		if s.Parent.SpanID != groups[0].SpanContext.SpanID || s.Status.Code != "Error" || s.attribute("http.response.status_code") != wantStatus {
+			t.Errorf("request span %d = %+v, want a failed %v request beneath the group", i, s, wantStatus)
+		}
+	}
+	if user := spans["GET user"]; len(user) != 1 || user[0].Parent.SpanID != run[0].SpanContext.SpanID {
+		t.Errorf("user request spans = %+v, want one beneath the run phase", user)
+	}
+	for _, name := range []string{"gitlabctl.api.requests", "gitlabctl.api.duration", "gitlabctl.api.throttled", "gitlabctl.phase.duration"} {
+		if !metrics[name] {
+			t.Errorf("metric %s was not exported, got %v", name, metrics)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	tel, err := telemetry.Start(context.Background(), "gitlabctl projects", telemetry.Options{}, func(string) string { return "" })
+	if tel != nil || err != nil {
+		t.Fatalf("Start() = %v, %v, want no telemetry", tel, err)
+	}
+	ctx := context.Background()
+	if got := tel.Enter(ctx, "run"); got != ctx {
+		t.Error("Enter() on nil telemetry changed the context")
+	}
+	if got := tel.Transport(http.DefaultTransport); got != http.DefaultTransport {
+		t.Error("Transport() on nil telemetry wrapped the transport")
+	}
+	if err := tel.Shutdown(ctx, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "Test Numeric ID", url: "https://gitlab.com/api/v4/projects/42/dependencies?page=2", want: "projects/:id/dependencies"},
+		{name: "Test Encoded Path", url: "https://gitlab.com/api/v4/groups/acme%2Fplatform/subgroups", want: "groups/:id/subgroups"},
+		{name: "Test Named Group", url: "https://gitlab.com/api/v4/groups/acme/projects", want: "groups/:id/projects"},
+		{name: "Test Relative URL Root", url: "https://example.com/gitlab/api/v4/users/7/keys", want: "users/:id/keys"},
+		{name: "Test Collection", url: "https://gitlab.com/api/v4/projects", want: "projects"},
+		{name: "Test GraphQL", url: "https://example.com/gitlab/api/graphql", want: "graphql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := telemetry.Endpoint(u); got != tt.want {
+				t.Errorf("Endpoint(%s) = %s, want %s", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/httpcache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transport traces every request sent to Gitlab as a client span and records its metrics. Each attempt of a retried
+// request is a request of its own. Latency is measured until the response headers are received.
+type transport struct {
+	telemetry *Telemetry
+	base      http.RoundTripper
+	requests  metric.Int64Counter
+	duration  metric.Float64Histogram
+	throttled metric.Int64Counter
+}
+
+// Transport wraps base, or http.DefaultTransport when base is nil, to trace the requests sent through it and record
+// their metrics: gitlabctl.api.requests and gitlabctl.api.duration by method, endpoint and status code, and
+// gitlabctl.api.throttled counting the requests Gitlab rate limited. Endpoints are request paths relative to the API
+// with project, group and other IDs replaced by :id, such as projects/:id/dependencies. A nil Telemetry returns base.
+func (t *Telemetry) Transport(base http.RoundTripper) http.RoundTripper {
+	if t == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	meter := t.meterProvider.Meter(instrumentationName)
+	// Instruments only fail to be created for invalid names, which are constant here
+	requests, _ := meter.Int64Counter("gitlabctl.api.requests", metric.WithUnit("{request}"),
+		metric.WithDescription("Requests sent to the Gitlab API"))
+	duration, _ := meter.Float64Histogram("gitlabctl.api.duration", metric.WithUnit("s"),
+		metric.WithDescription("Latency of requests sent to the Gitlab API"))
+	throttled, _ := meter.Int64Counter("gitlabctl.api.throttled", metric.WithUnit("{request}"),
+		metric.WithDescription("Requests rate limited by the Gitlab API"))
+	return &transport{telemetry: t, base: base, requests: requests, duration: duration, throttled: throttled}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := Endpoint(req.URL)
+	parent := req.Context()
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		// Requests sent without a traced context are traced beneath the phase in progress
+		parent = t.telemetry.phaseContext()
+	}
+	attributes := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("gitlab.endpoint", endpoint),
+	}
+	ctx, span := t.telemetry.tracer.Start(parent, req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+		trace.WithAttributes(attribute.String("server.address", req.URL.Host), attribute.String("url.path", req.URL.Path)),
+	)
+	defer span.End()
+
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(started).Seconds()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		attributes = append(attributes, attribute.String("error.type", "transport"))
+	} else {
+		status := attribute.Int("http.response.status_code", resp.StatusCode)
+		attributes = append(attributes, status)
+		span.SetAttributes(status)
+		if cache := resp.Header.Get(httpcache.StatusHeader); cache != "" {
+			span.SetAttributes(attribute.String("gitlab.cache", cache))
+		}
+		if remaining := resp.Header.Get("RateLimit-Remaining"); remaining != "" {
+			span.SetAttributes(attribute.String("gitlab.ratelimit.remaining", remaining))
+		}
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			span.AddEvent("throttled", trace.WithAttributes(
+				attribute.String("http.response.header.retry-after", resp.Header.Get("Retry-After")),
+				attribute.String("gitlab.ratelimit.reset", resp.Header.Get("RateLimit-Reset")),
+			))
+			t.throttled.Add(ctx, 1, metric.WithAttributes(attributes[:2]...))
+		}
+	}
+	t.requests.Add(ctx, 1, metric.WithAttributes(attributes...))
+	t.duration.Record(ctx, elapsed, metric.WithAttributes(attributes...))
+	return resp, err
+}
+
+// idCollections are the API collections whose members are addressed by ID or URL-encoded path, so the path segment
+// following them is always an ID.
+var idCollections = map[string]bool{"projects": true, "groups": true, "users": true, "vulnerabilities": true}
+
+// Endpoint returns the endpoint of a Gitlab API request URL: its path relative to the REST API, with IDs replaced by
+// :id, or graphql for the GraphQL API. Replacing the IDs keeps requests for different projects or groups on the same
+// endpoint, so failing or slow endpoints stand out.
+func Endpoint(u *url.URL) string {
+	path := u.EscapedPath()
+	if strings.HasSuffix(path, "/api/graphql") {
+		return "graphql"
+	}
+	if i := strings.Index(path, "/api/v4/"); i >= 0 {
+		path = path[i+len("/api/v4/"):]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if isID(segment) || (i > 0 && idCollections[segments[i-1]]) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isID reports whether a path segment is a numeric ID or a URL-encoded path, such as acme%2Fapi.
+func isID(segment string) bool {
+	if strings.Contains(segment, "%") {
+		return true
+	}
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+// Package users holds the data structures and logic necessary to audit the users of a self-managed Gitlab instance,
+// reporting on two-factor authentication, administrator accounts, dormant accounts and linked identities.
+package users
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+// EnumerateUsersOptions holds the options for auditing users.
+// The DormantDays field is the number of days without sign-in or activity after which an account is flagged as dormant.
+// The IncludeBlocked field includes blocked and deactivated users, which are otherwise excluded.
+// The IncludeBots field includes bot and internal users, which are otherwise excluded.
+// The Providers field restricts which identity providers satisfy the linked identity check. If empty, any provider does.
+type EnumerateUsersOptions struct {
+	DormantDays    int      `json:"dormant_days" yaml:"dormant_days"`
+	IncludeBlocked bool     `json:"include_blocked" yaml:"include_blocked"`
+	IncludeBots    bool     `json:"include_bots" yaml:"include_bots"`
+	Providers      []string `json:"providers" yaml:"providers"`
+}
+
+// EnumerateUsers audits all users of the Gitlab instance. An administrator token is required, as Gitlab only returns
+// two-factor, sign-in and identity information to administrators, so a non-administrator token is returned as an error.
+// Failures to fetch the SSH keys of a single user are recorded as non-fatal errors in the report.
+func EnumerateUsers(ctx context.Context, baseURL string, options *EnumerateUsersOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		Resources: GitlabResources{Users: []*UserAudit{}},
+		ErrorLog:  nonfatal.NewErrorLog(),
+		BaseURL:   baseURL,
+	}
+
+	currentUser, _, err := client.Users.CurrentUser()
+	if err != nil {
+		return report, err
+	}
+	if !currentUser.IsAdmin {
+		return report, errors.New("the users command requires an administrator token")
+	}
+
+	filterOptions := gitlab.ListUsersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	if !options.IncludeBlocked {
+		filterOptions.Active = gitlab.Ptr(true)
+	}
+	if !options.IncludeBots {
+		filterOptions.ExcludeInternal = gitlab.Ptr(true)
+		filterOptions.WithoutProjectBots = gitlab.Ptr(true)
+	}
+
+	now := time.Now()
+	for {
+		users, resp, err := client.Users.ListUsers(&filterOptions)
+		if err != nil {
+			report.AddError(nonfatal.New("", "", err))
+			break
+		}
+
+		for _, user := range users {
+			if user.Bot && !options.IncludeBots {
+				continue
+			}
+			audit := NewUserAudit(user)
+			sshKeyCount, err := countSSHKeys(client, user.ID)
+			if err != nil {
+				report.AddError(nonfatal.Newf("user", user.Username, err, "failed to list SSH keys for user %s: %s", user.Username, err.Error()))
+			}
+			audit.SSHKeyCount = sshKeyCount
+			audit.Findings = EvaluateUser(audit, options, now)
+			report.Resources.Users = append(report.Resources.Users, audit)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		filterOptions.ListOptions.Page = resp.NextPage
+	}
+
+	return report, nil
+}
+
+// NewUserAudit converts a Gitlab user into a UserAudit, without any findings or SSH key count.
+func NewUserAudit(user *gitlab.User) *UserAudit {
+	audit := &UserAudit{
+		ID:               user.ID,
+		Username:         user.Username,
+		Name:             user.Name,
+		Email:            user.Email,
+		State:            user.State,
+		Admin:            user.IsAdmin,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		External:         user.External,
+		Bot:              user.Bot,
+		CreatedAt:        user.CreatedAt,
+		LastSignInAt:     user.LastSignInAt,
+		Identities:       []Identity{},
+		Findings:         []Finding{},
+	}
+	if user.CurrentSignInAt != nil && (audit.LastSignInAt == nil || user.CurrentSignInAt.After(*audit.LastSignInAt)) {
+		audit.LastSignInAt = user.CurrentSignInAt
+	}
+	if user.LastActivityOn != nil {
+		lastActivity := time.Time(*user.LastActivityOn)
+		audit.LastActivityOn = &lastActivity
+	}
+	for _, identity := range user.Identities {
+		audit.Identities = append(audit.Identities, Identity{Provider: identity.Provider, ExternUID: identity.ExternUID})
+	}
+	return audit
+}
+
+// EvaluateUser returns the findings for a single audited user. Administrators without two-factor authentication,
+// active accounts with no sign-in or activity within the dormant window, and human users with no linked identity from
+// the accepted providers are flagged.
+func EvaluateUser(audit *UserAudit, options *EnumerateUsersOptions, now time.Time) []Finding {
+	findings := []Finding{}
+	if audit.Admin && !audit.TwoFactorEnabled {
+		findings = append(findings, FindingAdminWithoutTwoFactor)
+	}
+
+	if audit.State == "active" && options.DormantDays > 0 {
+		cutoff := now.AddDate(0, 0, -options.DormantDays)
+		lastSeen := audit.CreatedAt
+		for _, t := range []*time.Time{audit.LastSignInAt, audit.LastActivityOn} {
+			if t != nil && (lastSeen == nil || t.After(*lastSeen)) {
+				lastSeen = t
+			}
+		}
+		if lastSeen == nil || lastSeen.Before(cutoff) {
+			findings = append(findings, FindingDormant)
+		}
+	}
+
+	if !audit.Bot && !hasIdentity(audit.Identities, options.Providers) {
+		findings = append(findings, FindingNoIdentity)
+	}
+	return findings
+}
+
+func hasIdentity(identities []Identity, providers []string) bool {
+	if len(providers) == 0 {
+		return len(identities) > 0
+	}
+	for _, identity := range identities {
+		for _, provider := range providers {
+			if strings.EqualFold(identity.Provider, provider) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func countSSHKeys(client *gitlab.Client, userID int) (int, error) {
+	options := gitlab.ListSSHKeysForUserOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+	count := 0
+	for {
+		keys, resp, err := client.Users.ListSSHKeysForUser(userID, &options)
+		if err != nil {
+			return count, err
+		}
+		count += len(keys)
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	return count, nil
+}
@@ -0,0 +1,82 @@
+package users_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/users"
+)
+
+func TestEvaluateUser(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -10)
+	old := now.AddDate(0, 0, -200)
+	saml := []users.Identity{{Provider: "saml", ExternUID: "alice@example.com"}}
+	options := &users.EnumerateUsersOptions{DormantDays: 90}
+
+	tests := []struct {
+		name    string
+		audit   *users.UserAudit
+		options *users.EnumerateUsersOptions
+		want    []users.Finding
+	}{
+		{
+			name:    "Test Healthy User",
+			audit:   &users.UserAudit{State: "active", TwoFactorEnabled: true, LastSignInAt: &recent, Identities: saml},
+			options: options,
+			want:    []users.Finding{},
+		},
+		{
+			name:    "Test Admin Without 2FA",
+			audit:   &users.UserAudit{State: "active", Admin: true, LastSignInAt: &recent, Identities: saml},
+			options: options,
+			want:    []users.Finding{users.FindingAdminWithoutTwoFactor},
+		},
+		{
+			name:    "Test Dormant User",
+			audit:   &users.UserAudit{State: "active", CreatedAt: &old, LastSignInAt: &old, Identities: saml},
+			options: options,
+			want:    []users.Finding{users.FindingDormant},
+		},
+		{
+			name:    "Test Recent Activity Not Dormant",
+			audit:   &users.UserAudit{State: "active", LastSignInAt: &old, LastActivityOn: &recent, Identities: saml},
+			options: options,
+			want:    []users.Finding{},
+		},
+		{
+			name:    "Test Blocked User Not Dormant",
+			audit:   &users.UserAudit{State: "blocked", LastSignInAt: &old, Identities: saml},
+			options: options,
+			want:    []users.Finding{},
+		},
+		{
+			name:    "Test No Identity",
+			audit:   &users.UserAudit{State: "active", LastSignInAt: &recent, Identities: []users.Identity{}},
+			options: options,
+			want:    []users.Finding{users.FindingNoIdentity},
+		},
+		{
+			name:    "Test Identity From Other Provider",
+			audit:   &users.UserAudit{State: "active", LastSignInAt: &recent, Identities: saml},
+			options: &users.EnumerateUsersOptions{DormantDays: 90, Providers: []string{"ldapmain"}},
+			want:    []users.Finding{users.FindingNoIdentity},
+		},
+		{
+			name:    "Test Bot Without Identity",
+			audit:   &users.UserAudit{State: "active", Bot: true, LastSignInAt: &recent, Identities: []users.Identity{}},
+			options: options,
+			want:    []users.Finding{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := users.EvaluateUser(tt.audit, tt.options, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluateUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package users
+
+import (
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+)
+
+// Finding represents a user hygiene issue detected during the users audit.
+type Finding string
+
+const (
+	FindingAdminWithoutTwoFactor Finding = "admin_without_2fa"
+	FindingDormant               Finding = "dormant"
+	FindingNoIdentity            Finding = "no_idp_identity"
+)
+
+// Identity represents an external identity (e.g. SAML or LDAP) linked to a Gitlab user.
+type Identity struct {
+	Provider  string `json:"provider" yaml:"provider"`
+	ExternUID string `json:"extern_uid" yaml:"extern_uid"`
+}
+
+// UserAudit represents the security relevant attributes of a single Gitlab user, along with the findings raised for it.
+type UserAudit struct {
+	ID               int        `json:"id" yaml:"id"`
+	Username         string     `json:"username" yaml:"username"`
+	Name             string     `json:"name" yaml:"name"`
+	Email            string     `json:"email" yaml:"email"`
+	State            string     `json:"state" yaml:"state"`
+	Admin            bool       `json:"admin" yaml:"admin"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled" yaml:"two_factor_enabled"`
+	External         bool       `json:"external" yaml:"external"`
+	Bot              bool       `json:"bot" yaml:"bot"`
+	CreatedAt        *time.Time `json:"created_at" yaml:"created_at"`
+	LastSignInAt     *time.Time `json:"last_sign_in_at" yaml:"last_sign_in_at"`
+	LastActivityOn   *time.Time `json:"last_activity_on" yaml:"last_activity_on"`
+	Identities       []Identity `json:"identities" yaml:"identities"`
+	SSHKeyCount      int        `json:"ssh_key_count" yaml:"ssh_key_count"`
+	Findings         []Finding  `json:"findings" yaml:"findings"`
+}
+
+// GitlabResources represents a collection of audited Gitlab users.
+type GitlabResources struct {
+	Users []*UserAudit `json:"users" yaml:"users"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
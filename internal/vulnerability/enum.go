@@ -0,0 +1,51 @@
+package vulnerability
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseEnum matches a user provided value case-insensitively against the valid values of an enumeration. Unrecognized
+// values return an error listing the valid values, suggesting the closest one when the value looks like a typo.
+func parseEnum(kind string, value string, valid []string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	for _, v := range valid {
+		if normalized == v {
+			return v, nil
+		}
+	}
+
+	suggestion := ""
+	best := 3
+	for _, v := range valid {
+		if distance := editDistance(normalized, v); distance < best {
+			best = distance
+			suggestion = v
+		}
+	}
+	if suggestion != "" {
+		return "", fmt.Errorf("invalid %s %q, did you mean %q? Valid values are '%s'", kind, value, suggestion, strings.Join(valid, "', '"))
+	}
+	return "", fmt.Errorf("invalid %s %q, valid values are '%s'", kind, value, strings.Join(valid, "', '"))
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,157 @@
+package vulnerability
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// VulnerabilityFilter selects vulnerabilities by their attributes. Empty fields match every vulnerability.
+// The ReportTypes field matches the report type exactly, while the Scanners field matches the scanner ID or name, or
+// the report type (e.g. semgrep, dependency_scanning). ParseFilter only accepts the Scanners Gitlab ships and report
+// types.
+// The Identifiers field matches identifier names or values (e.g. CVE-2021-44228, CWE-79) and may contain * wildcards.
+// The Paths field holds glob patterns matched against the file the vulnerability was found in, where * matches within a
+// path segment and ** matches across segments.
+type VulnerabilityFilter struct {
+	States      []State      `json:"states" yaml:"states"`
+	Severities  []Severity   `json:"severities" yaml:"severities"`
+	ReportTypes []ReportType `json:"report_types" yaml:"report_types"`
+	Scanners    []string     `json:"scanners" yaml:"scanners"`
+	Identifiers []string     `json:"identifiers" yaml:"identifiers"`
+	Paths       []string     `json:"paths" yaml:"paths"`
+}
+
+// ParseFilter strictly parses the state, severity, minimum severity, report type and scanner filter values shared by
+// the vulnerabilities commands into a VulnerabilityFilter, returning an error for any unrecognized value. A minimum
+// severity selects every severity at or above it, and cannot be combined with severities.
+func ParseFilter(states []string, severities []string, minSeverity string, reportTypes []string, scanners []string) (*VulnerabilityFilter, error) {
+	if len(severities) > 0 && minSeverity != "" {
+		return nil, errors.New("severities and a minimum severity cannot be combined")
+	}
+	filter := &VulnerabilityFilter{}
+	var err error
+	if filter.States, err = ParseStates(states); err != nil {
+		return nil, err
+	}
+	if filter.Severities, err = ParseSeverities(severities); err != nil {
+		return nil, err
+	}
+	if minSeverity != "" {
+		minimum, err := ParseSeverity(minSeverity)
+		if err != nil {
+			return nil, err
+		}
+		filter.Severities = SeveritiesAtLeast(minimum)
+	}
+	if filter.ReportTypes, err = ParseReportTypes(reportTypes); err != nil {
+		return nil, err
+	}
+	if filter.Scanners, err = ParseScanners(scanners); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// Matches reports whether a vulnerability matches every populated field of the filter.
+func (f *VulnerabilityFilter) Matches(vuln *gitlab.ProjectVulnerability) bool {
+	if len(f.States) > 0 && !ContainsState(ToState(vuln.State), f.States) {
+		return false
+	}
+	if len(f.Severities) > 0 && !ContainsSeverity(ToSeverity(vuln.Severity), f.Severities) {
+		return false
+	}
+	if len(f.ReportTypes) > 0 && !ContainsReportType(ReportType(vuln.ReportType), f.ReportTypes) {
+		return false
+	}
+
+	metadata := ParseFindingMetadata(vuln)
+	if len(f.Scanners) > 0 && !matchesScanner(f.Scanners, vuln.ReportType, metadata) {
+		return false
+	}
+	if len(f.Identifiers) > 0 && !MatchesIdentifier(f.Identifiers, metadata.Identifiers) {
+		return false
+	}
+	if len(f.Paths) > 0 && !MatchesAnyPath(f.Paths, metadata.File) {
+		return false
+	}
+	return true
+}
+
+func matchesScanner(scanners []string, reportType string, metadata FindingMetadata) bool {
+	for _, scanner := range scanners {
+		for _, value := range []string{reportType, metadata.ScannerID, metadata.ScannerName} {
+			if value != "" && strings.EqualFold(scanner, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchesIdentifier reports whether any of the identifiers match one of the patterns, comparing case-insensitively
+// against both the identifier name and value.
+func MatchesIdentifier(patterns []string, identifiers []Identifier) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		for _, identifier := range identifiers {
+			for _, value := range []string{identifier.Name, identifier.Value} {
+				if value == "" {
+					continue
+				}
+				if matched, _ := path.Match(pattern, strings.ToLower(value)); matched {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// MatchesAnyPath reports whether a file path matches any of the glob patterns. An empty file path matches nothing.
+func MatchesAnyPath(patterns []string, file string) bool {
+	if file == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if MatchPathGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPathGlob reports whether a file path matches a glob pattern, where * and ? match within a single path segment
+// and ** matches any number of segments.
+func MatchPathGlob(pattern string, file string) bool {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ matches zero or more leading directories
+					i++
+					expression.WriteString("(.*/)?")
+				} else {
+					expression.WriteString(".*")
+				}
+			} else {
+				expression.WriteString("[^/]*")
+			}
+		case '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expression.WriteString("$")
+
+	matched, err := regexp.MatchString(expression.String(), strings.TrimPrefix(file, "/"))
+	return err == nil && matched
+}
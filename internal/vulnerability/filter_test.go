@@ -0,0 +1,135 @@
+package vulnerability_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"github.com/xanzy/go-gitlab"
+)
+
+const rawMetadata = `{
+  "scanner": {"id": "gemnasium", "name": "Gemnasium"},
+  "location": {"file": "services/api/package-lock.json"},
+  "identifiers": [
+    {"type": "gemnasium", "name": "Gemnasium-1234", "value": "1234"},
+    {"type": "cve", "name": "CVE-2021-44228", "value": "CVE-2021-44228"}
+  ]
+}`
+
+func newVulnerability(state string, severity string) *gitlab.ProjectVulnerability {
+	return &gitlab.ProjectVulnerability{
+		ID:         1,
+		State:      state,
+		Severity:   severity,
+		ReportType: "dependency_scanning",
+		Finding:    &gitlab.Finding{RawMetadata: rawMetadata},
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		file    string
+		want    bool
+	}{
+		{name: "Exact", pattern: "go.sum", file: "go.sum", want: true},
+		{name: "Star within segment", pattern: "src/*.js", file: "src/app.js", want: true},
+		{name: "Star does not cross segments", pattern: "src/*.js", file: "src/lib/app.js", want: false},
+		{name: "Double star crosses segments", pattern: "src/**/*.js", file: "src/lib/deep/app.js", want: true},
+		{name: "Double star matches zero segments", pattern: "**/test/**", file: "test/fixtures/key.pem", want: true},
+		{name: "Leading slash is ignored", pattern: "vendor/**", file: "/vendor/github.com/x/y.go", want: true},
+		{name: "Dots are literal", pattern: "*.go", file: "main_go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vulnerability.MatchPathGlob(tt.pattern, tt.file); got != tt.want {
+				t.Errorf("MatchPathGlob(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFindingMetadata(t *testing.T) {
+	metadata := vulnerability.ParseFindingMetadata(newVulnerability("detected", "high"))
+	if metadata.File != "services/api/package-lock.json" {
+		t.Errorf("File = %q", metadata.File)
+	}
+	if metadata.ScannerID != "gemnasium" {
+		t.Errorf("ScannerID = %q", metadata.ScannerID)
+	}
+	if got := metadata.PrimaryIdentifier(); got != "CVE-2021-44228" {
+		t.Errorf("PrimaryIdentifier() = %q, want CVE-2021-44228", got)
+	}
+
+	empty := vulnerability.ParseFindingMetadata(&gitlab.ProjectVulnerability{})
+	if len(empty.Identifiers) != 0 || empty.PrimaryIdentifier() != "" {
+		t.Errorf("expected empty metadata, got %+v", empty)
+	}
+}
+
+func TestVulnerabilityFilterMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter vulnerability.VulnerabilityFilter
+		want   bool
+	}{
+		{name: "Empty filter", filter: vulnerability.VulnerabilityFilter{}, want: true},
+		{name: "Severity", filter: vulnerability.VulnerabilityFilter{Severities: []vulnerability.Severity{vulnerability.SeverityCritical}}, want: false},
+		{name: "Report type", filter: vulnerability.VulnerabilityFilter{Scanners: []string{"dependency_scanning"}}, want: true},
+		{name: "Scanner name", filter: vulnerability.VulnerabilityFilter{Scanners: []string{"gemnasium"}}, want: true},
+		{name: "Other scanner", filter: vulnerability.VulnerabilityFilter{Scanners: []string{"semgrep"}}, want: false},
+		{name: "Identifier wildcard", filter: vulnerability.VulnerabilityFilter{Identifiers: []string{"cve-2021-*"}}, want: true},
+		{name: "Other identifier", filter: vulnerability.VulnerabilityFilter{Identifiers: []string{"CWE-79"}}, want: false},
+		{name: "Path", filter: vulnerability.VulnerabilityFilter{Paths: []string{"services/**"}}, want: true},
+		{name: "All fields", filter: vulnerability.VulnerabilityFilter{
+			States:      []vulnerability.State{vulnerability.StateDetected},
+			Severities:  []vulnerability.Severity{vulnerability.SeverityHigh},
+			Scanners:    []string{"Gemnasium"},
+			Identifiers: []string{"CVE-2021-44228"},
+			Paths:       []string{"**/package-lock.json"},
+		}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(newVulnerability("detected", "high")); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := vulnerability.ParseFilter([]string{"Confirmed"}, nil, "high", []string{"sast"}, []string{"Semgrep", "secret_detection"})
+	if err != nil {
+		t.Fatalf("ParseFilter() error = %v", err)
+	}
+	if len(filter.States) != 1 || len(filter.Severities) != 2 || len(filter.ReportTypes) != 1 {
+		t.Errorf("ParseFilter() = %+v, want confirmed, high and above, and sast", filter)
+	}
+	if len(filter.Scanners) != 2 || filter.Scanners[0] != "semgrep" || filter.Scanners[1] != "secret_detection" {
+		t.Errorf("Scanners = %v, want semgrep and secret_detection", filter.Scanners)
+	}
+
+	invalid := []struct {
+		name        string
+		states      []string
+		severities  []string
+		minSeverity string
+		reportTypes []string
+		scanners    []string
+	}{
+		{name: "Unknown state", states: []string{"dismised"}},
+		{name: "Unknown severity", severities: []string{"severe"}},
+		{name: "Severities and minimum", severities: []string{"high"}, minSeverity: "high"},
+		{name: "Unknown report type", reportTypes: []string{"fuzzing"}},
+		{name: "Unknown scanner", scanners: []string{"semgrp"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := vulnerability.ParseFilter(tt.states, tt.severities, tt.minSeverity, tt.reportTypes, tt.scanners); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package vulnerability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Method-Security/gitlabctl/internal/config"
+	"github.com/xanzy/go-gitlab"
+)
+
+// graphQLRequest is the body of a Gitlab GraphQL API request.
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphQLResponse is the envelope of a Gitlab GraphQL API response.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQL executes a query against the Gitlab GraphQL API, decoding the response data into out. go-gitlab only models
+// the REST API, so the request is built with the client, to reuse its authentication and retries, and then pointed at
+// the GraphQL endpoint of the instance the client's REST API base URL belongs to.
+func graphQL(ctx context.Context, client *gitlab.Client, query string, variables map[string]interface{}, out interface{}) error {
+	req, err := client.NewRequest(http.MethodPost, "", &graphQLRequest{Query: query, Variables: variables}, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return err
+	}
+	instanceURL, err := config.ParseInstanceURL(client.BaseURL().String())
+	if err != nil {
+		return err
+	}
+	if req.URL, err = url.Parse(instanceURL.GraphQLURL()); err != nil {
+		return err
+	}
+
+	response := graphQLResponse{}
+	if _, err := client.Do(req, &response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors))
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+	if out == nil || len(response.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+	return nil
+}
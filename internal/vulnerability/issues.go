@@ -0,0 +1,556 @@
+package vulnerability
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+// IssueGrouping controls how vulnerabilities are grouped into issues.
+type IssueGrouping string
+
+const (
+	IssueGroupingVulnerability IssueGrouping = "vulnerability"
+	IssueGroupingIdentifier    IssueGrouping = "identifier"
+)
+
+// IssueAction represents what was done, or is planned in a dry run, to the issue of a group of vulnerabilities.
+type IssueAction string
+
+const (
+	IssueActionCreate IssueAction = "create"
+	IssueActionUpdate IssueAction = "update"
+	IssueActionReopen IssueAction = "reopen"
+	IssueActionClose  IssueAction = "close"
+)
+
+// fingerprintMarker prefixes the fingerprint stored in the description of every issue gitlabctl creates.
+const fingerprintMarker = "gitlabctl-fingerprint:"
+
+// DefaultIssueTemplate is the text/template used to render issue descriptions when no template is provided. Templates
+// are executed with an IssueTemplateData.
+const DefaultIssueTemplate = `## {{ .Title }}
+
+| | |
+|---|---|
+| **Project** | {{ .ProjectPath }} |
+| **Severity** | {{ .Severity }} |
+| **Report type** | {{ .ReportType }} |
+{{- if .Identifiers }}
+| **Identifiers** | {{ join .Identifiers ", " }} |
+{{- end }}
+
+### Vulnerabilities
+{{ range .Vulnerabilities }}
+- **{{ .Severity }}** [{{ .Title }}]({{ .URL }}) ({{ .State }}){{ if .File }} in ` + "`{{ .File }}`" + `{{ end }}
+{{- end }}
+{{- if .Description }}
+
+### Description
+
+{{ .Description }}
+{{- end }}
+`
+
+// IssueVulnerability describes a single vulnerability to an issue template.
+type IssueVulnerability struct {
+	ID       int
+	Title    string
+	Severity Severity
+	State    State
+	File     string
+	URL      string
+}
+
+// IssueTemplateData is the data issue templates are executed with. Severity is the highest severity of the
+// vulnerabilities, while ReportType and Description are those of the first vulnerability.
+type IssueTemplateData struct {
+	Title           string
+	ProjectPath     string
+	Severity        Severity
+	ReportType      string
+	Identifiers     []string
+	Description     string
+	Vulnerabilities []IssueVulnerability
+}
+
+// AssigneeRules maps a severity, a report type, or * for any vulnerability, to the username issues are assigned to.
+// Severity rules take precedence over report type rules, which take precedence over the * rule.
+type AssigneeRules map[string]string
+
+// NewAssigneeRules creates AssigneeRules from key=username pairs, such as critical=alice or sast=bob.
+func NewAssigneeRules(pairs []string) (AssigneeRules, error) {
+	rules := AssigneeRules{}
+	for _, pair := range pairs {
+		key, username, found := strings.Cut(pair, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if !found || key == "" || username == "" {
+			return nil, fmt.Errorf("invalid assignee rule %q, expected key=username", pair)
+		}
+		if key != "*" {
+			_, severityErr := ParseSeverity(key)
+			_, reportTypeErr := ParseReportType(key)
+			if severityErr != nil && reportTypeErr != nil {
+				return nil, fmt.Errorf("invalid assignee rule %q, the key must be a severity, a report type or *", pair)
+			}
+		}
+		rules[key] = username
+	}
+	return rules, nil
+}
+
+// Assignee returns the username of the assignee for vulnerabilities of the provided severity and report type, or an
+// empty string if no rule applies.
+func (r AssigneeRules) Assignee(severity Severity, reportType string) string {
+	for _, key := range []string{string(severity), strings.ToLower(reportType), "*"} {
+		if username, ok := r[key]; ok {
+			return username
+		}
+	}
+	return ""
+}
+
+// IssueGroup is a set of vulnerabilities of a single project tracked by one issue, identified by a stable fingerprint.
+type IssueGroup struct {
+	Key             string
+	Fingerprint     string
+	Project         *gitlab.Project
+	Vulnerabilities []*gitlab.ProjectVulnerability
+}
+
+// Fingerprint returns the stable fingerprint of the issue tracking a group key within a project.
+func Fingerprint(projectID int, grouping IssueGrouping, key string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%s/%s", projectID, grouping, strings.ToLower(key))))
+	return hex.EncodeToString(sum[:16])
+}
+
+// GroupForIssues groups vulnerabilities into IssueGroups, one per vulnerability or one per primary identifier within
+// each project. Vulnerabilities without an identifier are grouped on their own, while vulnerabilities without a project
+// are skipped, as an issue belongs to a project. Groups are returned sorted by project and key.
+func GroupForIssues(vulns []*gitlab.ProjectVulnerability, grouping IssueGrouping) []*IssueGroup {
+	groups := map[string]*IssueGroup{}
+	for _, vuln := range vulns {
+		if vuln.Project == nil {
+			continue
+		}
+		key := fmt.Sprintf("vulnerability-%d", vuln.ID)
+		effectiveGrouping := IssueGroupingVulnerability
+		if grouping == IssueGroupingIdentifier {
+			if identifier := ParseFindingMetadata(vuln).PrimaryIdentifier(); identifier != "" {
+				key = identifier
+				effectiveGrouping = IssueGroupingIdentifier
+			}
+		}
+
+		fingerprint := Fingerprint(vuln.Project.ID, effectiveGrouping, key)
+		if groups[fingerprint] == nil {
+			groups[fingerprint] = &IssueGroup{
+				Key:         key,
+				Fingerprint: fingerprint,
+				Project:     vuln.Project,
+			}
+		}
+		groups[fingerprint].Vulnerabilities = append(groups[fingerprint].Vulnerabilities, vuln)
+	}
+
+	result := make([]*IssueGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Vulnerabilities, func(i, j int) bool {
+			return group.Vulnerabilities[i].ID < group.Vulnerabilities[j].ID
+		})
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Project.ID != result[j].Project.ID {
+			return result[i].Project.ID < result[j].Project.ID
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// Open reports whether any vulnerability of the group is still open.
+func (g *IssueGroup) Open() bool {
+	for _, vuln := range g.Vulnerabilities {
+		if ContainsState(ToState(vuln.State), OpenStates) {
+			return true
+		}
+	}
+	return false
+}
+
+// Severity returns the highest severity among the vulnerabilities of the group.
+func (g *IssueGroup) Severity() Severity {
+	highest := SeverityUnknown
+	for _, vuln := range g.Vulnerabilities {
+		if severity := ToSeverity(vuln.Severity); severity.AtLeast(highest) {
+			highest = severity
+		}
+	}
+	return highest
+}
+
+// Title returns the title of the group's issue.
+func (g *IssueGroup) Title() string {
+	first := g.Vulnerabilities[0]
+	if len(g.Vulnerabilities) == 1 || strings.HasPrefix(g.Key, "vulnerability-") {
+		return "Security: " + first.Title
+	}
+	return fmt.Sprintf("Security: %s (%d vulnerabilities)", g.Key, len(g.Vulnerabilities))
+}
+
+// NewIssueTemplate parses an issue description template. An empty template returns the DefaultIssueTemplate.
+func NewIssueTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultIssueTemplate
+	}
+	tmpl, err := template.New("issue").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse issue template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderIssueDescription renders the description of the group's issue, appending the fingerprint used to find the
+// issue on later runs.
+func RenderIssueDescription(tmpl *template.Template, group *IssueGroup) (string, error) {
+	first := group.Vulnerabilities[0]
+	data := IssueTemplateData{
+		Title:       group.Title(),
+		ProjectPath: group.Project.PathWithNamespace,
+		Severity:    group.Severity(),
+		ReportType:  first.ReportType,
+		Identifiers: []string{},
+		Description: first.Description,
+	}
+	seen := map[string]bool{}
+	for _, vuln := range group.Vulnerabilities {
+		metadata := ParseFindingMetadata(vuln)
+		for _, identifier := range metadata.Identifiers {
+			if !seen[identifier.Name] {
+				seen[identifier.Name] = true
+				data.Identifiers = append(data.Identifiers, identifier.Name)
+			}
+		}
+		data.Vulnerabilities = append(data.Vulnerabilities, IssueVulnerability{
+			ID:       vuln.ID,
+			Title:    vuln.Title,
+			Severity: ToSeverity(vuln.Severity),
+			State:    ToState(vuln.State),
+			File:     metadata.File,
+			URL:      fmt.Sprintf("%s/-/security/vulnerabilities/%d", group.Project.WebURL, vuln.ID),
+		})
+	}
+
+	var description bytes.Buffer
+	if err := tmpl.Execute(&description, data); err != nil {
+		return "", fmt.Errorf("failed to render issue template: %w", err)
+	}
+	fmt.Fprintf(&description, "\n<!-- %s%s -->\n", fingerprintMarker, group.Fingerprint)
+	return description.String(), nil
+}
+
+// IssueOptions holds the options for creating issues from vulnerabilities.
+// The ProjectID and GroupID fields select the vulnerabilities of a single project, or of every project in a group and
+// its subgroups. Exactly one of them is required. The Filter field narrows the vulnerabilities down further; its
+// States are ignored, as open vulnerabilities get an issue while issues whose vulnerabilities are all closed are closed.
+// The GroupBy field selects whether each vulnerability, or each identifier within a project, gets its own issue.
+// The Labels field lists the labels added to every issue, and the Assignees field the rules issues are assigned by.
+// The Template field is the text/template issue descriptions are rendered with, defaulting to DefaultIssueTemplate.
+// The Confidential field creates confidential issues. The DryRun field reports the planned actions without applying them.
+type IssueOptions struct {
+	ProjectID    string              `json:"project_id" yaml:"project_id"`
+	GroupID      string              `json:"group_id" yaml:"group_id"`
+	Filter       VulnerabilityFilter `json:"filter" yaml:"filter"`
+	GroupBy      IssueGrouping       `json:"group_by" yaml:"group_by"`
+	Labels       []string            `json:"labels" yaml:"labels"`
+	Assignees    AssigneeRules       `json:"assignees" yaml:"assignees"`
+	Template     string              `json:"template" yaml:"template"`
+	Confidential bool                `json:"confidential" yaml:"confidential"`
+	DryRun       bool                `json:"dry_run" yaml:"dry_run"`
+}
+
+// CreateIssues creates an issue for every group of open vulnerabilities matching the filter, updating the issue
+// created by an earlier run instead when one is found by its fingerprint. Issues whose vulnerabilities are all
+// resolved or dismissed are closed. Failures affecting a single issue are recorded as non-fatal errors in the report.
+func CreateIssues(ctx context.Context, baseURL string, options *IssueOptions, client *gitlab.Client) (*IssueReport, error) {
+	report := &IssueReport{
+		BaseURL:   baseURL,
+		DryRun:    options.DryRun,
+		Resources: IssueResources{Issues: []*IssueChange{}},
+		ErrorLog:  nonfatal.NewErrorLog(),
+	}
+	if (options.ProjectID == "") == (options.GroupID == "") {
+		return report, errors.New("exactly one of a project ID or a group ID is required")
+	}
+	if options.GroupBy != IssueGroupingVulnerability && options.GroupBy != IssueGroupingIdentifier {
+		return report, fmt.Errorf("invalid grouping %q, valid values are 'vulnerability', 'identifier'", options.GroupBy)
+	}
+	tmpl, err := NewIssueTemplate(options.Template)
+	if err != nil {
+		return report, err
+	}
+
+	vulns, err := selectVulnerabilities(ctx, client, options.ProjectID, options.GroupID, nil, &report.ErrorLog)
+	if err != nil {
+		return report, err
+	}
+	filter := options.Filter
+	filter.States = nil
+	matching := []*gitlab.ProjectVulnerability{}
+	for _, vuln := range vulns {
+		if filter.Matches(vuln) {
+			matching = append(matching, vuln)
+		}
+	}
+
+	users := map[string]int{}
+	for _, group := range GroupForIssues(matching, options.GroupBy) {
+		change, err := syncIssue(ctx, client, tmpl, group, options, users)
+		if err != nil {
+			report.AddError(nonfatal.Newf("project", group.Project.PathWithNamespace, err, "failed to sync issue for %s in project %s: %s", group.Key, group.Project.PathWithNamespace, err.Error()))
+		}
+		if change != nil {
+			report.Resources.Issues = append(report.Resources.Issues, change)
+		}
+	}
+	return report, nil
+}
+
+// syncIssue brings the issue of a group up to date, returning nil when there is nothing to do.
+func syncIssue(ctx context.Context, client *gitlab.Client, tmpl *template.Template, group *IssueGroup, options *IssueOptions, users map[string]int) (*IssueChange, error) {
+	existing, err := findIssue(ctx, client, group)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &IssueChange{
+		Fingerprint:      group.Fingerprint,
+		Key:              group.Key,
+		ProjectID:        group.Project.ID,
+		ProjectPath:      group.Project.PathWithNamespace,
+		Title:            group.Title(),
+		Severity:         group.Severity(),
+		VulnerabilityIDs: []int{},
+	}
+	for _, vuln := range group.Vulnerabilities {
+		change.VulnerabilityIDs = append(change.VulnerabilityIDs, vuln.ID)
+	}
+	if existing != nil {
+		change.IssueIID = existing.IID
+		change.IssueURL = existing.WebURL
+	}
+
+	switch {
+	case group.Open() && existing == nil:
+		change.Action = IssueActionCreate
+	case group.Open() && existing.State == "closed":
+		change.Action = IssueActionReopen
+	case group.Open():
+		change.Action = IssueActionUpdate
+	case existing != nil && existing.State != "closed":
+		change.Action = IssueActionClose
+	default:
+		return nil, nil
+	}
+	if options.DryRun {
+		return change, nil
+	}
+
+	description, err := RenderIssueDescription(tmpl, group)
+	if err != nil {
+		change.Error = err.Error()
+		return change, err
+	}
+	var assigneeIDs *[]int
+	if username := options.Assignees.Assignee(change.Severity, group.Vulnerabilities[0].ReportType); username != "" {
+		id, err := lookupUser(ctx, client, username, users)
+		if err != nil {
+			change.Error = err.Error()
+			return change, err
+		}
+		assigneeIDs = &[]int{id}
+	}
+
+	if change.Action == IssueActionCreate {
+		labels := gitlab.LabelOptions(options.Labels)
+		issue, _, err := client.Issues.CreateIssue(group.Project.ID, &gitlab.CreateIssueOptions{
+			Title:        gitlab.Ptr(change.Title),
+			Description:  gitlab.Ptr(description),
+			Labels:       &labels,
+			AssigneeIDs:  assigneeIDs,
+			Confidential: gitlab.Ptr(options.Confidential),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			change.Error = err.Error()
+			return change, err
+		}
+		change.IssueIID = issue.IID
+		change.IssueURL = issue.WebURL
+		change.Applied = true
+		if err := linkIssue(ctx, client, issue.ID, change.VulnerabilityIDs); err != nil {
+			return change, fmt.Errorf("created issue %s but failed to link its vulnerabilities: %w", issue.WebURL, err)
+		}
+		return change, nil
+	}
+
+	labels := gitlab.LabelOptions(options.Labels)
+	update := &gitlab.UpdateIssueOptions{
+		Title:       gitlab.Ptr(change.Title),
+		Description: gitlab.Ptr(description),
+		AddLabels:   &labels,
+		AssigneeIDs: assigneeIDs,
+	}
+	switch change.Action {
+	case IssueActionReopen:
+		update.StateEvent = gitlab.Ptr("reopen")
+	case IssueActionClose:
+		update.StateEvent = gitlab.Ptr("close")
+	}
+	if _, _, err := client.Issues.UpdateIssue(group.Project.ID, existing.IID, update, gitlab.WithContext(ctx)); err != nil {
+		change.Error = err.Error()
+		return change, err
+	}
+	change.Applied = true
+	if change.Action != IssueActionClose {
+		if err := linkNewVulnerabilities(ctx, client, existing.ID, change.VulnerabilityIDs); err != nil {
+			return change, fmt.Errorf("updated issue %s but failed to link its vulnerabilities: %w", existing.WebURL, err)
+		}
+	}
+	return change, nil
+}
+
+// findIssue returns the issue of a project holding the group's fingerprint in its description, or nil if none does.
+func findIssue(ctx context.Context, client *gitlab.Client, group *IssueGroup) (*gitlab.Issue, error) {
+	issues, _, err := client.Issues.ListProjectIssues(group.Project.ID, &gitlab.ListProjectIssuesOptions{
+		ListOptions: gitlab.ListOptions{Page: 1, PerPage: 100},
+		Search:      gitlab.Ptr(group.Fingerprint),
+		In:          gitlab.Ptr("description"),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if strings.Contains(issue.Description, fingerprintMarker+group.Fingerprint) {
+			return issue, nil
+		}
+	}
+	return nil, nil
+}
+
+// lookupUser resolves a username to a user ID, caching the result.
+func lookupUser(ctx context.Context, client *gitlab.Client, username string, users map[string]int) (int, error) {
+	if id, ok := users[username]; ok {
+		return id, nil
+	}
+	found, _, err := client.Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.Ptr(username)}, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	if len(found) == 0 {
+		return 0, fmt.Errorf("assignee %s not found", username)
+	}
+	users[username] = found[0].ID
+	return found[0].ID, nil
+}
+
+// linkIssue links vulnerabilities to an issue through the GraphQL API, which the REST API does not support.
+func linkIssue(ctx context.Context, client *gitlab.Client, issueID int, vulnerabilityIDs []int) error {
+	ids := make([]string, 0, len(vulnerabilityIDs))
+	for _, id := range vulnerabilityIDs {
+		ids = append(ids, fmt.Sprintf("gid://gitlab/Vulnerability/%d", id))
+	}
+	out := struct {
+		Result struct {
+			Errors []string `json:"errors"`
+		} `json:"result"`
+	}{}
+	err := graphQL(ctx, client, `mutation($issueId: IssueID!, $vulnerabilityIds: [VulnerabilityID!]!) {
+  result: vulnerabilityIssueLinkCreate(input: {issueId: $issueId, vulnerabilityIds: $vulnerabilityIds}) { errors }
+}`, map[string]interface{}{
+		"issueId":          fmt.Sprintf("gid://gitlab/Issue/%d", issueID),
+		"vulnerabilityIds": ids,
+	}, &out)
+	if err != nil {
+		return err
+	}
+	if len(out.Result.Errors) > 0 {
+		return errors.New(strings.Join(out.Result.Errors, "; "))
+	}
+	return nil
+}
+
+// linkNewVulnerabilities links the vulnerabilities that are not linked to an issue yet, such as those that joined its
+// group since it was created.
+func linkNewVulnerabilities(ctx context.Context, client *gitlab.Client, issueID int, vulnerabilityIDs []int) error {
+	linked, err := linkedVulnerabilities(ctx, client, issueID)
+	if err != nil {
+		return err
+	}
+	missing := []int{}
+	for _, id := range vulnerabilityIDs {
+		if !linked[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return linkIssue(ctx, client, issueID, missing)
+}
+
+// linkedVulnerabilities returns the IDs of the vulnerabilities linked to an issue, through the GraphQL API.
+func linkedVulnerabilities(ctx context.Context, client *gitlab.Client, issueID int) (map[int]bool, error) {
+	linked := map[int]bool{}
+	variables := map[string]interface{}{
+		"id": fmt.Sprintf("gid://gitlab/Issue/%d", issueID),
+	}
+	for {
+		out := struct {
+			Result struct {
+				RelatedVulnerabilities struct {
+					Nodes []struct {
+						ID string `json:"id"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"relatedVulnerabilities"`
+			} `json:"result"`
+		}{}
+		err := graphQL(ctx, client, `query($id: IssueID!, $after: String) {
+  result: issue(id: $id) {
+    relatedVulnerabilities(first: 100, after: $after) {
+      nodes { id }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`, variables, &out)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range out.Result.RelatedVulnerabilities.Nodes {
+			id, err := strconv.Atoi(strings.TrimPrefix(node.ID, "gid://gitlab/Vulnerability/"))
+			if err != nil {
+				return nil, fmt.Errorf("unexpected vulnerability ID %q", node.ID)
+			}
+			linked[id] = true
+		}
+		if !out.Result.RelatedVulnerabilities.PageInfo.HasNextPage {
+			return linked, nil
+		}
+		variables["after"] = out.Result.RelatedVulnerabilities.PageInfo.EndCursor
+	}
+}
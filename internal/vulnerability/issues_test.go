@@ -0,0 +1,128 @@
+package vulnerability_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestNewAssigneeRules(t *testing.T) {
+	rules, err := vulnerability.NewAssigneeRules([]string{"critical=@alice", "SAST=bob", "*=carol"})
+	if err != nil {
+		t.Fatalf("NewAssigneeRules() error = %v", err)
+	}
+	tests := []struct {
+		severity   vulnerability.Severity
+		reportType string
+		want       string
+	}{
+		{severity: vulnerability.SeverityCritical, reportType: "sast", want: "alice"},
+		{severity: vulnerability.SeverityHigh, reportType: "sast", want: "bob"},
+		{severity: vulnerability.SeverityLow, reportType: "dast", want: "carol"},
+	}
+	for _, tt := range tests {
+		if got := rules.Assignee(tt.severity, tt.reportType); got != tt.want {
+			t.Errorf("Assignee(%s, %s) = %q, want %q", tt.severity, tt.reportType, got, tt.want)
+		}
+	}
+	if got := (vulnerability.AssigneeRules{}).Assignee(vulnerability.SeverityHigh, "sast"); got != "" {
+		t.Errorf("expected no assignee without rules, got %q", got)
+	}
+
+	for _, pair := range []string{"critical", "urgent=alice", "high="} {
+		if _, err := vulnerability.NewAssigneeRules([]string{pair}); err == nil {
+			t.Errorf("expected an error for %q", pair)
+		}
+	}
+}
+
+func TestGroupForIssues(t *testing.T) {
+	api := &gitlab.Project{ID: 1, PathWithNamespace: "acme/api", WebURL: "https://gitlab.example.com/acme/api"}
+	web := &gitlab.Project{ID: 2, PathWithNamespace: "acme/web"}
+	withCVE := func(id int, project *gitlab.Project, state string, cve string) *gitlab.ProjectVulnerability {
+		vuln := &gitlab.ProjectVulnerability{ID: id, Project: project, State: state, Severity: "high", Title: "Title", Finding: &gitlab.Finding{}}
+		if cve != "" {
+			vuln.Finding.RawMetadata = `{"identifiers": [{"type": "cve", "name": "` + cve + `"}]}`
+		}
+		return vuln
+	}
+	vulns := []*gitlab.ProjectVulnerability{
+		withCVE(3, api, "resolved", "CVE-2024-1"),
+		withCVE(1, api, "detected", "CVE-2024-1"),
+		withCVE(2, web, "detected", "CVE-2024-1"),
+		withCVE(4, api, "detected", ""),
+		{ID: 5, State: "detected"},
+	}
+
+	groups := vulnerability.GroupForIssues(vulns, vulnerability.IssueGroupingIdentifier)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if groups[0].Key != "CVE-2024-1" || len(groups[0].Vulnerabilities) != 2 || groups[0].Vulnerabilities[0].ID != 1 {
+		t.Errorf("unexpected first group %+v", groups[0])
+	}
+	if !groups[0].Open() || groups[0].Title() != "Security: CVE-2024-1 (2 vulnerabilities)" {
+		t.Errorf("unexpected first group state or title %q", groups[0].Title())
+	}
+	if groups[1].Key != "vulnerability-4" || groups[2].Project.ID != 2 {
+		t.Errorf("unexpected groups %+v %+v", groups[1], groups[2])
+	}
+	if groups[0].Fingerprint == groups[2].Fingerprint {
+		t.Error("expected the same identifier in different projects to have different fingerprints")
+	}
+
+	again := vulnerability.GroupForIssues(vulns, vulnerability.IssueGroupingIdentifier)
+	if again[0].Fingerprint != groups[0].Fingerprint {
+		t.Error("expected fingerprints to be stable across runs")
+	}
+
+	perVulnerability := vulnerability.GroupForIssues(vulns, vulnerability.IssueGroupingVulnerability)
+	if len(perVulnerability) != 4 {
+		t.Errorf("expected 4 groups, got %d", len(perVulnerability))
+	}
+	if perVulnerability[1].Open() {
+		t.Error("expected the group of a resolved vulnerability to be closed")
+	}
+}
+
+func TestRenderIssueDescription(t *testing.T) {
+	project := &gitlab.Project{ID: 1, PathWithNamespace: "acme/api", WebURL: "https://gitlab.example.com/acme/api"}
+	vuln := newVulnerability("detected", "critical")
+	vuln.Project = project
+	vuln.Title = "Log4Shell"
+	group := vulnerability.GroupForIssues([]*gitlab.ProjectVulnerability{vuln}, vulnerability.IssueGroupingVulnerability)[0]
+
+	tmpl, err := vulnerability.NewIssueTemplate("")
+	if err != nil {
+		t.Fatalf("NewIssueTemplate() error = %v", err)
+	}
+	description, err := vulnerability.RenderIssueDescription(tmpl, group)
+	if err != nil {
+		t.Fatalf("RenderIssueDescription() error = %v", err)
+	}
+	for _, want := range []string{
+		"| **Severity** | critical |",
+		"| **Identifiers** | Gemnasium-1234, CVE-2021-44228 |",
+		"[Log4Shell](https://gitlab.example.com/acme/api/-/security/vulnerabilities/1) (detected) in `services/api/package-lock.json`",
+		"<!-- gitlabctl-fingerprint:" + group.Fingerprint + " -->",
+	} {
+		if !strings.Contains(description, want) {
+			t.Errorf("expected description to contain %q, got:\n%s", want, description)
+		}
+	}
+
+	tmpl, err = vulnerability.NewIssueTemplate("{{ .Severity }} in {{ .ProjectPath }}")
+	if err != nil {
+		t.Fatalf("NewIssueTemplate() error = %v", err)
+	}
+	description, _ = vulnerability.RenderIssueDescription(tmpl, group)
+	if !strings.HasPrefix(description, "critical in acme/api\n<!-- gitlabctl-fingerprint:") {
+		t.Errorf("unexpected custom description %q", description)
+	}
+
+	if _, err := vulnerability.NewIssueTemplate("{{ .Severity"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
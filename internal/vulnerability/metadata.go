@@ -0,0 +1,74 @@
+package vulnerability
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// Identifier represents an identifier of a vulnerability finding, such as a CVE or CWE.
+type Identifier struct {
+	Type  string `json:"type" yaml:"type"`
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+	URL   string `json:"url" yaml:"url"`
+}
+
+// FindingMetadata holds the details of a vulnerability finding that the Gitlab API only exposes within the finding's
+// raw metadata: its identifiers, the file it was found in, and the scanner that reported it.
+type FindingMetadata struct {
+	Identifiers []Identifier `json:"identifiers" yaml:"identifiers"`
+	File        string       `json:"file" yaml:"file"`
+	Image       string       `json:"image" yaml:"image"`
+	ScannerID   string       `json:"scanner_id" yaml:"scanner_id"`
+	ScannerName string       `json:"scanner_name" yaml:"scanner_name"`
+}
+
+type rawMetadata struct {
+	Identifiers []Identifier `json:"identifiers"`
+	Location    struct {
+		File  string `json:"file"`
+		Image string `json:"image"`
+	} `json:"location"`
+	Scanner struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"scanner"`
+}
+
+// ParseFindingMetadata extracts the FindingMetadata of a vulnerability. Vulnerabilities without a finding, or whose raw
+// metadata cannot be parsed, return empty metadata.
+func ParseFindingMetadata(vuln *gitlab.ProjectVulnerability) FindingMetadata {
+	metadata := FindingMetadata{Identifiers: []Identifier{}}
+	if vuln.Finding == nil || vuln.Finding.RawMetadata == "" {
+		return metadata
+	}
+
+	raw := rawMetadata{}
+	if err := json.Unmarshal([]byte(vuln.Finding.RawMetadata), &raw); err != nil {
+		return metadata
+	}
+	if raw.Identifiers != nil {
+		metadata.Identifiers = raw.Identifiers
+	}
+	metadata.File = raw.Location.File
+	metadata.Image = raw.Location.Image
+	metadata.ScannerID = raw.Scanner.ID
+	metadata.ScannerName = raw.Scanner.Name
+	return metadata
+}
+
+// PrimaryIdentifier returns the name of the first CVE identifier of the finding, falling back to its first identifier
+// of any type. An empty string is returned if the finding has no identifiers.
+func (m FindingMetadata) PrimaryIdentifier() string {
+	for _, identifier := range m.Identifiers {
+		if strings.EqualFold(identifier.Type, "cve") {
+			return identifier.Name
+		}
+	}
+	if len(m.Identifiers) > 0 {
+		return m.Identifiers[0].Name
+	}
+	return ""
+}
@@ -3,17 +3,111 @@
 package vulnerability
 
 import (
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
 	"github.com/xanzy/go-gitlab"
 )
 
+// Vulnerability represents a Gitlab vulnerability along with the results of gitlabctl's own evaluation of it. The
+// Gitlab fields are embedded and inlined, so they are output exactly as they were before gitlabctl evaluated them.
+// The Suppression field is set when a suppression rule matched the vulnerability. The DetectedAt, AgeDays,
+// TimeToResolveDays and SLA fields are set by EvaluateAge.
+type Vulnerability struct {
+	gitlab.ProjectVulnerability `yaml:",inline"`
+	Suppression                 *Suppression   `json:"suppression,omitempty" yaml:"suppression,omitempty"`
+	DetectedAt                  *time.Time     `json:"detected_at,omitempty" yaml:"detected_at,omitempty"`
+	AgeDays                     *int           `json:"age_days,omitempty" yaml:"age_days,omitempty"`
+	TimeToResolveDays           *int           `json:"time_to_resolve_days,omitempty" yaml:"time_to_resolve_days,omitempty"`
+	SLA                         *SLAEvaluation `json:"sla,omitempty" yaml:"sla,omitempty"`
+}
+
 // GitlabResources represents a collection of Gitlab vulnerabilities.
 type GitlabResources struct {
-	Vulnerabilities []*gitlab.ProjectVulnerability `json:"vulnerabilities" yaml:"vulnerabilities"`
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities" yaml:"vulnerabilities"`
+}
+
+// Summary holds statistics computed across the reported vulnerabilities.
+type Summary struct {
+	SLA    *SLASummary `json:"sla,omitempty" yaml:"sla,omitempty"`
+	Counts *Counts     `json:"counts,omitempty" yaml:"counts,omitempty"`
 }
 
 // GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+// The ThresholdExceeded field counts the unsuppressed vulnerabilities at or above the failure threshold, if one was set.
 type GitlabResourceReport struct {
-	BaseURL   string          `json:"base_url" yaml:"base_url"`
-	Resources GitlabResources `json:"resources" yaml:"resources"`
-	Errors    []string        `json:"errors" yaml:"errors"`
+	BaseURL           string          `json:"base_url" yaml:"base_url"`
+	Resources         GitlabResources `json:"resources" yaml:"resources"`
+	Summary           Summary         `json:"summary" yaml:"summary"`
+	ThresholdExceeded int             `json:"threshold_exceeded" yaml:"threshold_exceeded"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
+
+// StateChange represents the transition of a single vulnerability to a new state. The Applied field is false for a
+// dry run, or when the transition failed, in which case the Error field holds the reason.
+type StateChange struct {
+	VulnerabilityID int      `json:"vulnerability_id" yaml:"vulnerability_id"`
+	ProjectID       int      `json:"project_id" yaml:"project_id"`
+	ProjectPath     string   `json:"project_path" yaml:"project_path"`
+	Title           string   `json:"title" yaml:"title"`
+	Severity        Severity `json:"severity" yaml:"severity"`
+	ReportType      string   `json:"report_type" yaml:"report_type"`
+	Identifier      string   `json:"identifier" yaml:"identifier"`
+	FromState       State    `json:"from_state" yaml:"from_state"`
+	ToState         State    `json:"to_state" yaml:"to_state"`
+	Applied         bool     `json:"applied" yaml:"applied"`
+	Error           string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// StateChangeResources represents the collection of vulnerability state changes.
+type StateChangeResources struct {
+	Changes []*StateChange `json:"changes" yaml:"changes"`
+}
+
+// StateChangeReport represents a report of vulnerability state changes, planned or applied, and non-fatal errors
+// encountered along the way.
+type StateChangeReport struct {
+	BaseURL     string               `json:"base_url" yaml:"base_url"`
+	TargetState State                `json:"target_state" yaml:"target_state"`
+	DryRun      bool                 `json:"dry_run" yaml:"dry_run"`
+	Resources   StateChangeResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
+
+// IssueChange represents the action taken, or planned in a dry run, on the issue tracking a group of vulnerabilities.
+// The Applied field is false for a dry run, or when the action failed, in which case the Error field holds the reason.
+type IssueChange struct {
+	Fingerprint      string      `json:"fingerprint" yaml:"fingerprint"`
+	Key              string      `json:"key" yaml:"key"`
+	ProjectID        int         `json:"project_id" yaml:"project_id"`
+	ProjectPath      string      `json:"project_path" yaml:"project_path"`
+	Title            string      `json:"title" yaml:"title"`
+	Severity         Severity    `json:"severity" yaml:"severity"`
+	VulnerabilityIDs []int       `json:"vulnerability_ids" yaml:"vulnerability_ids"`
+	Action           IssueAction `json:"action" yaml:"action"`
+	IssueIID         int         `json:"issue_iid,omitempty" yaml:"issue_iid,omitempty"`
+	IssueURL         string      `json:"issue_url,omitempty" yaml:"issue_url,omitempty"`
+	Applied          bool        `json:"applied" yaml:"applied"`
+	Error            string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// IssueResources represents the collection of issue changes.
+type IssueResources struct {
+	Issues []*IssueChange `json:"issues" yaml:"issues"`
+}
+
+// IssueReport represents a report of the issues created, updated or closed from vulnerabilities, and non-fatal errors
+// encountered along the way.
+type IssueReport struct {
+	BaseURL   string         `json:"base_url" yaml:"base_url"`
+	DryRun    bool           `json:"dry_run" yaml:"dry_run"`
+	Resources IssueResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
 }
@@ -0,0 +1,63 @@
+package vulnerability
+
+// ReportType represents the type of security scan that reported a vulnerability, as defined by the Gitlab API.
+type ReportType string
+
+const (
+	ReportTypeSAST                 ReportType = "sast"
+	ReportTypeDAST                 ReportType = "dast"
+	ReportTypeDependencyScanning   ReportType = "dependency_scanning"
+	ReportTypeContainerScanning    ReportType = "container_scanning"
+	ReportTypeSecretDetection      ReportType = "secret_detection"
+	ReportTypeCoverageFuzzing      ReportType = "coverage_fuzzing"
+	ReportTypeAPIFuzzing           ReportType = "api_fuzzing"
+	ReportTypeClusterImageScanning ReportType = "cluster_image_scanning"
+	ReportTypeGeneric              ReportType = "generic"
+)
+
+// ReportTypes lists every ReportType.
+var ReportTypes = []ReportType{
+	ReportTypeSAST,
+	ReportTypeDAST,
+	ReportTypeDependencyScanning,
+	ReportTypeContainerScanning,
+	ReportTypeSecretDetection,
+	ReportTypeCoverageFuzzing,
+	ReportTypeAPIFuzzing,
+	ReportTypeClusterImageScanning,
+	ReportTypeGeneric,
+}
+
+// ParseReportType strictly converts user input to a ReportType, returning an error for unrecognized values.
+func ParseReportType(reportType string) (ReportType, error) {
+	valid := make([]string, 0, len(ReportTypes))
+	for _, r := range ReportTypes {
+		valid = append(valid, string(r))
+	}
+	parsed, err := parseEnum("report type", reportType, valid)
+	return ReportType(parsed), err
+}
+
+// ParseReportTypes strictly converts a slice of user input to a slice of ReportTypes, returning an error for the first
+// unrecognized value.
+func ParseReportTypes(reportTypes []string) ([]ReportType, error) {
+	result := make([]ReportType, 0, len(reportTypes))
+	for _, reportType := range reportTypes {
+		parsed, err := ParseReportType(reportType)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+// ContainsReportType checks if a slice of ReportTypes contains a specific ReportType, returning true if it does.
+func ContainsReportType(reportType ReportType, reportTypes []ReportType) bool {
+	for _, r := range reportTypes {
+		if r == reportType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package vulnerability
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule is a declarative suppression of the vulnerabilities it matches. Every populated matcher must match for the rule
+// to apply: Identifiers, Paths and Scanners match as they do in a VulnerabilityFilter, while Projects matches project
+// IDs or full paths and may contain * wildcards (e.g. acme/legacy-*).
+// The Reason field is required and is recorded on every suppressed vulnerability. The Expires field is an optional
+// YYYY-MM-DD date after which the rule no longer suppresses anything.
+type Rule struct {
+	ID          string   `json:"id" yaml:"id"`
+	Identifiers []string `json:"identifiers" yaml:"identifiers"`
+	Paths       []string `json:"paths" yaml:"paths"`
+	Scanners    []string `json:"scanners" yaml:"scanners"`
+	Projects    []string `json:"projects" yaml:"projects"`
+	Reason      string   `json:"reason" yaml:"reason"`
+	Expires     string   `json:"expires" yaml:"expires"`
+}
+
+// RuleSet holds the suppression rules loaded from a rules file.
+type RuleSet struct {
+	Suppressions []*Rule `json:"suppressions" yaml:"suppressions"`
+}
+
+// Suppression records the rule that suppressed a vulnerability.
+type Suppression struct {
+	Rule    string     `json:"rule" yaml:"rule"`
+	Reason  string     `json:"reason" yaml:"reason"`
+	Expires *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// LoadRules reads a RuleSet from the YAML (or JSON) file at the provided path, validating every rule. Rules without an
+// ID are named after their position in the file.
+func LoadRules(filePath string) (*RuleSet, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &RuleSet{}
+	if err := yaml.UnmarshalStrict(data, rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", filePath, err)
+	}
+	for i, rule := range rules.Suppressions {
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("suppressions[%d]", i)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %s in %s: %w", rule.ID, filePath, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) validate() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return errors.New("a reason is required")
+	}
+	if len(r.Identifiers) == 0 && len(r.Paths) == 0 && len(r.Scanners) == 0 && len(r.Projects) == 0 {
+		return errors.New("at least one of identifiers, paths, scanners or projects is required")
+	}
+	for _, pattern := range append(r.Identifiers, r.Projects...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if r.Expires != "" && r.expiry() == nil {
+		return fmt.Errorf("invalid expiry date %q, expected YYYY-MM-DD", r.Expires)
+	}
+	return nil
+}
+
+// expiry returns the parsed expiry date of the rule, or nil if it has none.
+func (r *Rule) expiry() *time.Time {
+	expires, err := time.Parse("2006-01-02", r.Expires)
+	if err != nil {
+		return nil
+	}
+	return &expires
+}
+
+// Expired reports whether the rule has an expiry date that has passed. A rule remains active for the whole of its
+// expiry date.
+func (r *Rule) Expired(now time.Time) bool {
+	expires := r.expiry()
+	return expires != nil && !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// Matches reports whether every populated matcher of the rule matches the vulnerability, regardless of expiry.
+func (r *Rule) Matches(vuln *gitlab.ProjectVulnerability) bool {
+	filter := VulnerabilityFilter{
+		Scanners:    r.Scanners,
+		Identifiers: r.Identifiers,
+		Paths:       r.Paths,
+	}
+	if !filter.Matches(vuln) {
+		return false
+	}
+	return len(r.Projects) == 0 || matchesProject(r.Projects, vuln.Project)
+}
+
+func matchesProject(patterns []string, project *gitlab.Project) bool {
+	if project == nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern == strconv.Itoa(project.ID) {
+			return true
+		}
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(project.PathWithNamespace)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Suppress returns the Suppression of the first active rule matching the vulnerability, or nil if none match.
+// A nil RuleSet suppresses nothing.
+func (rs *RuleSet) Suppress(vuln *gitlab.ProjectVulnerability, now time.Time) *Suppression {
+	if rs == nil {
+		return nil
+	}
+	for _, rule := range rs.Suppressions {
+		if rule.Expired(now) || !rule.Matches(vuln) {
+			continue
+		}
+		return &Suppression{
+			Rule:    rule.ID,
+			Reason:  rule.Reason,
+			Expires: rule.expiry(),
+		}
+	}
+	return nil
+}
+
+// ExpiredRules returns the rules whose expiry date has passed.
+func (rs *RuleSet) ExpiredRules(now time.Time) []*Rule {
+	expired := []*Rule{}
+	if rs == nil {
+		return expired
+	}
+	for _, rule := range rs.Suppressions {
+		if rule.Expired(now) {
+			expired = append(expired, rule)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,121 @@
+package vulnerability_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"github.com/xanzy/go-gitlab"
+)
+
+const rulesFile = `suppressions:
+  - id: log4shell-tests
+    identifiers: [CVE-2021-44228]
+    paths: ["services/**"]
+    projects: ["acme/*"]
+    reason: Only reachable from test fixtures
+    expires: 2030-01-31
+  - identifiers: [CWE-79]
+    reason: Legacy admin UI is behind the VPN
+    expires: 2020-01-01
+`
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := vulnerability.LoadRules(writeRules(t, rulesFile))
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if len(rules.Suppressions) != 2 || rules.Suppressions[1].ID != "suppressions[1]" {
+		t.Errorf("unexpected rules %+v", rules.Suppressions)
+	}
+
+	invalid := []string{
+		"suppressions:\n  - identifiers: [CVE-1]\n",
+		"suppressions:\n  - reason: everything\n",
+		"suppressions:\n  - identifiers: [CVE-1]\n    reason: x\n    expires: next week\n",
+		"suppressions:\n  - identifiers: [CVE-1]\n    reason: x\n    owner: me\n",
+	}
+	for _, content := range invalid {
+		if _, err := vulnerability.LoadRules(writeRules(t, content)); err == nil {
+			t.Errorf("expected an error loading %q", content)
+		}
+	}
+}
+
+func TestRuleSetSuppress(t *testing.T) {
+	rules, err := vulnerability.LoadRules(writeRules(t, rulesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	vuln := newVulnerability("detected", "critical")
+	vuln.Project = &gitlab.Project{ID: 7, PathWithNamespace: "acme/api"}
+	suppression := rules.Suppress(vuln, now)
+	if suppression == nil || suppression.Rule != "log4shell-tests" || suppression.Expires == nil {
+		t.Fatalf("expected vulnerability to be suppressed by log4shell-tests, got %+v", suppression)
+	}
+
+	vuln.Project = &gitlab.Project{ID: 8, PathWithNamespace: "other/api"}
+	if suppression := rules.Suppress(vuln, now); suppression != nil {
+		t.Errorf("expected project matcher to exclude vulnerability, got %+v", suppression)
+	}
+
+	vuln.Project = &gitlab.Project{ID: 7, PathWithNamespace: "acme/api"}
+	if suppression := rules.Suppress(vuln, time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)); suppression != nil {
+		t.Errorf("expected expired rule not to suppress, got %+v", suppression)
+	}
+
+	expired := rules.ExpiredRules(now)
+	if len(expired) != 1 || expired[0].ID != "suppressions[1]" {
+		t.Errorf("unexpected expired rules %+v", expired)
+	}
+
+	var none *vulnerability.RuleSet
+	if none.Suppress(vuln, now) != nil || len(none.ExpiredRules(now)) != 0 {
+		t.Error("expected a nil RuleSet to suppress nothing")
+	}
+}
+
+func TestRuleExpired(t *testing.T) {
+	rule := &vulnerability.Rule{Expires: "2026-10-19"}
+	if rule.Expired(time.Date(2026, 10, 19, 23, 59, 0, 0, time.UTC)) {
+		t.Error("expected rule to be active for the whole of its expiry date")
+	}
+	if !rule.Expired(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected rule to be expired the day after its expiry date")
+	}
+}
+
+func TestExceedsThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		vuln      *vulnerability.Vulnerability
+		threshold vulnerability.Severity
+		want      bool
+	}{
+		{"Test Above Threshold", &vulnerability.Vulnerability{ProjectVulnerability: gitlab.ProjectVulnerability{Severity: "critical"}}, vulnerability.SeverityHigh, true},
+		{"Test At Threshold", &vulnerability.Vulnerability{ProjectVulnerability: gitlab.ProjectVulnerability{Severity: "high"}}, vulnerability.SeverityHigh, true},
+		{"Test Suppressed", &vulnerability.Vulnerability{ProjectVulnerability: gitlab.ProjectVulnerability{Severity: "high"}, Suppression: &vulnerability.Suppression{Rule: "r"}}, vulnerability.SeverityHigh, false},
+		{"Test Below Threshold", &vulnerability.Vulnerability{ProjectVulnerability: gitlab.ProjectVulnerability{Severity: "medium"}}, vulnerability.SeverityHigh, false},
+		{"Test No Threshold", &vulnerability.Vulnerability{ProjectVulnerability: gitlab.ProjectVulnerability{Severity: "critical"}}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vuln.ExceedsThreshold(tt.threshold); got != tt.want {
+				t.Errorf("ExceedsThreshold(%q) = %v, want %v", tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
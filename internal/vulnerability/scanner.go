@@ -0,0 +1,74 @@
+package vulnerability
+
+// Scanner represents the ID of a Gitlab security analyzer, as recorded in the findings it reports.
+type Scanner string
+
+const (
+	ScannerBrakeman           Scanner = "brakeman"
+	ScannerFlawfinder         Scanner = "flawfinder"
+	ScannerGemnasium          Scanner = "gemnasium"
+	ScannerGemnasiumMaven     Scanner = "gemnasium-maven"
+	ScannerGemnasiumPython    Scanner = "gemnasium-python"
+	ScannerGitleaks           Scanner = "gitleaks"
+	ScannerKICS               Scanner = "kics"
+	ScannerKubesec            Scanner = "kubesec"
+	ScannerMobSF              Scanner = "mobsf"
+	ScannerNodeJSScan         Scanner = "nodejs-scan"
+	ScannerPHPCSSecurityAudit Scanner = "phpcs_security_audit"
+	ScannerPMDApex            Scanner = "pmd-apex"
+	ScannerSecurityCodeScan   Scanner = "security_code_scan"
+	ScannerSemgrep            Scanner = "semgrep"
+	ScannerSobelow            Scanner = "sobelow"
+	ScannerSpotBugs           Scanner = "spotbugs"
+	ScannerTrivy              Scanner = "trivy"
+	ScannerZAProxy            Scanner = "zaproxy"
+)
+
+// Scanners lists the Scanner of every analyzer Gitlab ships.
+var Scanners = []Scanner{
+	ScannerBrakeman,
+	ScannerFlawfinder,
+	ScannerGemnasium,
+	ScannerGemnasiumMaven,
+	ScannerGemnasiumPython,
+	ScannerGitleaks,
+	ScannerKICS,
+	ScannerKubesec,
+	ScannerMobSF,
+	ScannerNodeJSScan,
+	ScannerPHPCSSecurityAudit,
+	ScannerPMDApex,
+	ScannerSecurityCodeScan,
+	ScannerSemgrep,
+	ScannerSobelow,
+	ScannerSpotBugs,
+	ScannerTrivy,
+	ScannerZAProxy,
+}
+
+// ParseScanner strictly converts user input to a scanner filter value, which is either a Scanner or a ReportType
+// (matching every scanner of that type), returning an error for unrecognized values.
+func ParseScanner(scanner string) (string, error) {
+	valid := make([]string, 0, len(Scanners)+len(ReportTypes))
+	for _, s := range Scanners {
+		valid = append(valid, string(s))
+	}
+	for _, r := range ReportTypes {
+		valid = append(valid, string(r))
+	}
+	return parseEnum("scanner", scanner, valid)
+}
+
+// ParseScanners strictly converts a slice of user input to scanner filter values, returning an error for the first
+// unrecognized value.
+func ParseScanners(scanners []string) ([]string, error) {
+	result := make([]string, 0, len(scanners))
+	for _, scanner := range scanners {
+		parsed, err := ParseScanner(scanner)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
@@ -16,7 +16,46 @@ const (
 	SeverityCritical Severity = "critical"
 )
 
-// ToSeverity converts a string to a Severity, returning SeverityUnknown if the string is not recognized.
+// Severities lists every Severity, from least to most severe.
+var Severities = []Severity{SeverityUnknown, SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseSeverity strictly converts user input to a Severity, returning an error for unrecognized values.
+func ParseSeverity(severity string) (Severity, error) {
+	valid := make([]string, 0, len(Severities))
+	for _, s := range Severities {
+		valid = append(valid, string(s))
+	}
+	parsed, err := parseEnum("severity", severity, valid)
+	return Severity(parsed), err
+}
+
+// ParseSeverities strictly converts a slice of user input to a slice of Severities, returning an error for the first
+// unrecognized value.
+func ParseSeverities(severities []string) ([]Severity, error) {
+	result := make([]Severity, 0, len(severities))
+	for _, severity := range severities {
+		parsed, err := ParseSeverity(severity)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+// SeveritiesAtLeast returns every Severity as severe as, or more severe than, the provided severity.
+func SeveritiesAtLeast(minimum Severity) []Severity {
+	result := make([]Severity, 0)
+	for _, severity := range Severities {
+		if severity.AtLeast(minimum) {
+			result = append(result, severity)
+		}
+	}
+	return result
+}
+
+// ToSeverity converts a string to a Severity, returning SeverityUnknown if the string is not recognized. It is meant
+// for values returned by the Gitlab API; use ParseSeverity to validate user input.
 func ToSeverity(severity string) Severity {
 	switch strings.ToLower(severity) {
 	case "info":
@@ -51,3 +90,18 @@ func ContainsSeverity(severity Severity, severities []Severity) bool {
 	}
 	return false
 }
+
+// severityRank orders severities from least to most severe.
+var severityRank = map[Severity]int{
+	SeverityUnknown:  0,
+	SeverityInfo:     1,
+	SeverityLow:      2,
+	SeverityMedium:   3,
+	SeverityHigh:     4,
+	SeverityCritical: 5,
+}
+
+// AtLeast reports whether the severity is as severe as, or more severe than, the provided severity.
+func (s Severity) AtLeast(severity Severity) bool {
+	return severityRank[s] >= severityRank[severity]
+}
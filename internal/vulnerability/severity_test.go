@@ -1,6 +1,7 @@
 package vulnerability_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/Method-Security/gitlabctl/internal/vulnerability"
@@ -100,3 +101,41 @@ func TestContainsSeverity(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		want     vulnerability.Severity
+		wantErr  bool
+	}{
+		{name: "Test Parse Critical", severity: "critical", want: vulnerability.SeverityCritical},
+		{name: "Test Parse Caps", severity: "HIGH", want: vulnerability.SeverityHigh},
+		{name: "Test Parse Unknown", severity: "unknown", want: vulnerability.SeverityUnknown},
+		{name: "Test Parse Typo", severity: "hgih", wantErr: true},
+		{name: "Test Parse Empty", severity: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := vulnerability.ParseSeverity(tt.severity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeverity(%s) error = %v, wantErr %v", tt.severity, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeverity(%s) = %s, want %s", tt.severity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeveritiesAtLeast(t *testing.T) {
+	got := vulnerability.SeveritiesAtLeast(vulnerability.SeverityHigh)
+	want := []vulnerability.Severity{vulnerability.SeverityHigh, vulnerability.SeverityCritical}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SeveritiesAtLeast(high) = %v, want %v", got, want)
+	}
+	if got := vulnerability.SeveritiesAtLeast(vulnerability.SeverityUnknown); !reflect.DeepEqual(got, vulnerability.Severities) {
+		t.Errorf("SeveritiesAtLeast(unknown) = %v, want every severity", got)
+	}
+}
@@ -0,0 +1,205 @@
+package vulnerability
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// SLAPolicy maps severities to the number of days vulnerabilities of that severity must be remediated within.
+// Severities without an entry have no SLA.
+type SLAPolicy map[Severity]int
+
+// DefaultSLAPolicy returns the default remediation SLAs: 7 days for critical, 30 days for high and 90 days for medium
+// severity vulnerabilities.
+func DefaultSLAPolicy() SLAPolicy {
+	return SLAPolicy{
+		SeverityCritical: 7,
+		SeverityHigh:     30,
+		SeverityMedium:   90,
+	}
+}
+
+// Pairs returns the policy as severity=days pairs, from the most to the least severe, as accepted by NewSLAPolicy.
+func (p SLAPolicy) Pairs() []string {
+	pairs := make([]string, 0, len(p))
+	for i := len(Severities) - 1; i >= 0; i-- {
+		if days, ok := p[Severities[i]]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s=%d", Severities[i], days))
+		}
+	}
+	return pairs
+}
+
+// NewSLAPolicy creates an SLAPolicy from severity=days pairs, such as critical=7. No pairs yield a nil policy, which
+// disables SLA evaluation.
+func NewSLAPolicy(pairs []string) (SLAPolicy, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	policy := SLAPolicy{}
+	for _, pair := range pairs {
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid SLA %q, expected severity=days", pair)
+		}
+		severity, err := ParseSeverity(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLA %q: %w", pair, err)
+		}
+		d, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid SLA %q, days must be a positive integer", pair)
+		}
+		policy[severity] = d
+	}
+	return policy, nil
+}
+
+// SLAStatus represents the outcome of evaluating a vulnerability against its remediation SLA.
+type SLAStatus string
+
+const (
+	SLAStatusWithin   SLAStatus = "within"
+	SLAStatusBreached SLAStatus = "breached"
+)
+
+// SLAEvaluation records the remediation SLA of a vulnerability and whether it was met. Open vulnerabilities breach
+// their SLA once they are older than it, while resolved vulnerabilities breached it if they took longer to resolve.
+type SLAEvaluation struct {
+	Days   int       `json:"days" yaml:"days"`
+	DueAt  time.Time `json:"due_at" yaml:"due_at"`
+	Status SLAStatus `json:"status" yaml:"status"`
+}
+
+// SLASummary holds remediation statistics across the reported vulnerabilities. MTTRDays holds the mean time to resolve,
+// in days, of the resolved vulnerabilities of each severity, and BreachesByProject counts the vulnerabilities that
+// breached their SLA in each project.
+type SLASummary struct {
+	Policy            SLAPolicy            `json:"policy" yaml:"policy"`
+	Evaluated         int                  `json:"evaluated" yaml:"evaluated"`
+	Breached          int                  `json:"breached" yaml:"breached"`
+	MTTRDays          map[Severity]float64 `json:"mttr_days" yaml:"mttr_days"`
+	BreachesByProject map[string]int       `json:"breaches_by_project" yaml:"breaches_by_project"`
+}
+
+// DetectedAt returns when a vulnerability was first detected: the earlier of its creation and that of its finding.
+func DetectedAt(vuln *gitlab.ProjectVulnerability) *time.Time {
+	detectedAt := vuln.CreatedAt
+	if vuln.Finding != nil && vuln.Finding.CreatedAt != nil && (detectedAt == nil || vuln.Finding.CreatedAt.Before(*detectedAt)) {
+		detectedAt = vuln.Finding.CreatedAt
+	}
+	return detectedAt
+}
+
+// closedAt returns when a vulnerability was resolved or dismissed, or nil if it is still open.
+func closedAt(vuln *gitlab.ProjectVulnerability) *time.Time {
+	switch ToState(vuln.State) {
+	case StateResolved:
+		return vuln.ResolvedAt
+	case StateDismissed:
+		return vuln.DismissedAt
+	}
+	return nil
+}
+
+func days(d time.Duration) int {
+	return int(d.Hours() / 24)
+}
+
+// EvaluateAge sets the age, time to resolve and SLA evaluation of a vulnerability. The age runs from detection until
+// the vulnerability was closed, or until now if it is still open. Dismissed vulnerabilities are not evaluated against
+// the SLA, and neither are vulnerabilities whose severity has no SLA in the policy.
+func EvaluateAge(vuln *Vulnerability, policy SLAPolicy, now time.Time) {
+	vuln.DetectedAt = DetectedAt(&vuln.ProjectVulnerability)
+	if vuln.DetectedAt == nil {
+		return
+	}
+
+	end := now
+	if closed := closedAt(&vuln.ProjectVulnerability); closed != nil {
+		end = *closed
+	}
+	age := days(end.Sub(*vuln.DetectedAt))
+	vuln.AgeDays = &age
+
+	state := ToState(vuln.State)
+	if state == StateResolved && vuln.ResolvedAt != nil {
+		timeToResolve := age
+		vuln.TimeToResolveDays = &timeToResolve
+	}
+
+	slaDays, ok := policy[ToSeverity(vuln.Severity)]
+	if !ok || state == StateDismissed {
+		return
+	}
+	evaluation := &SLAEvaluation{
+		Days:   slaDays,
+		DueAt:  vuln.DetectedAt.AddDate(0, 0, slaDays),
+		Status: SLAStatusWithin,
+	}
+	if end.After(evaluation.DueAt) {
+		evaluation.Status = SLAStatusBreached
+	}
+	vuln.SLA = evaluation
+}
+
+// SLATally accumulates the SLASummary of vulnerabilities one at a time, as they are evaluated with EvaluateAge, so
+// vulnerabilities that are streamed rather than kept in the report are still summarized.
+type SLATally struct {
+	summary       *SLASummary
+	resolvedDays  map[Severity]int
+	resolvedCount map[Severity]int
+}
+
+// NewSLATally creates an empty SLATally for the provided policy.
+func NewSLATally(policy SLAPolicy) *SLATally {
+	return &SLATally{
+		summary: &SLASummary{
+			Policy:            policy,
+			MTTRDays:          map[Severity]float64{},
+			BreachesByProject: map[string]int{},
+		},
+		resolvedDays:  map[Severity]int{},
+		resolvedCount: map[Severity]int{},
+	}
+}
+
+// Add counts a vulnerability that has already been evaluated with EvaluateAge into the summary.
+func (t *SLATally) Add(vuln *Vulnerability) {
+	if vuln.TimeToResolveDays != nil {
+		severity := ToSeverity(vuln.Severity)
+		t.resolvedDays[severity] += *vuln.TimeToResolveDays
+		t.resolvedCount[severity]++
+	}
+	if vuln.SLA == nil {
+		return
+	}
+	t.summary.Evaluated++
+	if vuln.SLA.Status == SLAStatusBreached {
+		t.summary.Breached++
+		t.summary.BreachesByProject[ProjectKey(&vuln.ProjectVulnerability)]++
+	}
+}
+
+// Summary returns the SLASummary of the vulnerabilities added so far.
+func (t *SLATally) Summary() *SLASummary {
+	for severity, count := range t.resolvedCount {
+		t.summary.MTTRDays[severity] = float64(t.resolvedDays[severity]) / float64(count)
+	}
+	return t.summary
+}
+
+// ProjectKey returns the full path of a vulnerability's project, falling back to its ID when the path is unknown.
+func ProjectKey(vuln *gitlab.ProjectVulnerability) string {
+	if vuln.Project == nil {
+		return "unknown"
+	}
+	if vuln.Project.PathWithNamespace != "" {
+		return vuln.Project.PathWithNamespace
+	}
+	return strconv.Itoa(vuln.Project.ID)
+}
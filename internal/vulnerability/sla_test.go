@@ -0,0 +1,133 @@
+package vulnerability_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"github.com/xanzy/go-gitlab"
+)
+
+func date(day int) *time.Time {
+	t := time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestNewSLAPolicy(t *testing.T) {
+	policy, err := vulnerability.NewSLAPolicy([]string{"critical=7", "High=30"})
+	if err != nil {
+		t.Fatalf("NewSLAPolicy() error = %v", err)
+	}
+	if policy[vulnerability.SeverityCritical] != 7 || policy[vulnerability.SeverityHigh] != 30 || len(policy) != 2 {
+		t.Errorf("unexpected policy %v", policy)
+	}
+
+	if policy, err := vulnerability.NewSLAPolicy(nil); err != nil || policy != nil {
+		t.Errorf("NewSLAPolicy(nil) = %v, %v, want a nil policy disabling SLA evaluation", policy, err)
+	}
+	defaults, err := vulnerability.NewSLAPolicy(vulnerability.DefaultSLAPolicy().Pairs())
+	if err != nil || !reflect.DeepEqual(defaults, vulnerability.DefaultSLAPolicy()) {
+		t.Errorf("NewSLAPolicy(DefaultSLAPolicy().Pairs()) = %v, %v, want the default policy", defaults, err)
+	}
+
+	for _, pairs := range [][]string{{"critical"}, {"urgent=7"}, {"high=0"}, {"high=soon"}} {
+		if _, err := vulnerability.NewSLAPolicy(pairs); err == nil {
+			t.Errorf("expected an error for %v", pairs)
+		}
+	}
+}
+
+func TestEvaluateAge(t *testing.T) {
+	now := *date(31)
+	policy := vulnerability.DefaultSLAPolicy()
+	tests := []struct {
+		name              string
+		vuln              *gitlab.ProjectVulnerability
+		wantAge           int
+		wantTimeToResolve *int
+		wantStatus        vulnerability.SLAStatus
+	}{
+		{
+			name:       "Open within SLA",
+			vuln:       &gitlab.ProjectVulnerability{State: "detected", Severity: "high", CreatedAt: date(10)},
+			wantAge:    21,
+			wantStatus: vulnerability.SLAStatusWithin,
+		},
+		{
+			name:       "Open breached, detected before creation",
+			vuln:       &gitlab.ProjectVulnerability{State: "confirmed", Severity: "critical", CreatedAt: date(28), Finding: &gitlab.Finding{CreatedAt: date(20)}},
+			wantAge:    11,
+			wantStatus: vulnerability.SLAStatusBreached,
+		},
+		{
+			name:              "Resolved late",
+			vuln:              &gitlab.ProjectVulnerability{State: "resolved", Severity: "critical", CreatedAt: date(1), ResolvedAt: date(10)},
+			wantAge:           9,
+			wantTimeToResolve: intPtr(9),
+			wantStatus:        vulnerability.SLAStatusBreached,
+		},
+		{
+			name:    "Dismissed is not evaluated",
+			vuln:    &gitlab.ProjectVulnerability{State: "dismissed", Severity: "critical", CreatedAt: date(1), DismissedAt: date(2)},
+			wantAge: 1,
+		},
+		{
+			name:    "Low has no SLA",
+			vuln:    &gitlab.ProjectVulnerability{State: "detected", Severity: "low", CreatedAt: date(1)},
+			wantAge: 30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vuln := &vulnerability.Vulnerability{ProjectVulnerability: *tt.vuln}
+			vulnerability.EvaluateAge(vuln, policy, now)
+			if vuln.AgeDays == nil || *vuln.AgeDays != tt.wantAge {
+				t.Errorf("AgeDays = %v, want %d", vuln.AgeDays, tt.wantAge)
+			}
+			if (vuln.TimeToResolveDays == nil) != (tt.wantTimeToResolve == nil) ||
+				(tt.wantTimeToResolve != nil && *vuln.TimeToResolveDays != *tt.wantTimeToResolve) {
+				t.Errorf("TimeToResolveDays = %v, want %v", vuln.TimeToResolveDays, tt.wantTimeToResolve)
+			}
+			var status vulnerability.SLAStatus
+			if vuln.SLA != nil {
+				status = vuln.SLA.Status
+			}
+			if status != tt.wantStatus {
+				t.Errorf("SLA status = %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSLATally(t *testing.T) {
+	now := *date(31)
+	policy := vulnerability.DefaultSLAPolicy()
+	api := &gitlab.Project{ID: 1, PathWithNamespace: "acme/api"}
+	vulns := []*vulnerability.Vulnerability{
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: api, State: "detected", Severity: "critical", CreatedAt: date(1)}},
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: api, State: "resolved", Severity: "high", CreatedAt: date(1), ResolvedAt: date(5)}},
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: &gitlab.Project{ID: 2}, State: "resolved", Severity: "high", CreatedAt: date(1), ResolvedAt: date(11)}},
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: &gitlab.Project{ID: 2}, State: "detected", Severity: "medium", CreatedAt: date(1)}},
+	}
+	tally := vulnerability.NewSLATally(policy)
+	for _, vuln := range vulns {
+		vulnerability.EvaluateAge(vuln, policy, now)
+		tally.Add(vuln)
+	}
+
+	summary := tally.Summary()
+	if summary.Evaluated != 4 || summary.Breached != 1 {
+		t.Errorf("Evaluated = %d, Breached = %d, want 4 and 1", summary.Evaluated, summary.Breached)
+	}
+	if summary.BreachesByProject["acme/api"] != 1 || len(summary.BreachesByProject) != 1 {
+		t.Errorf("unexpected BreachesByProject %v", summary.BreachesByProject)
+	}
+	if summary.MTTRDays[vulnerability.SeverityHigh] != 7 {
+		t.Errorf("MTTRDays[high] = %v, want 7", summary.MTTRDays[vulnerability.SeverityHigh])
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
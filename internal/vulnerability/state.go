@@ -9,15 +9,49 @@ type State string
 
 const (
 	StateDetected  State = "detected"
+	StateConfirmed State = "confirmed"
 	StateResolved  State = "resolved"
 	StateDismissed State = "dismissed"
 )
 
-// ToState converts a string to a State, returning StateDetected if the string is not recognized.
+// States lists every State, in the order of a vulnerability's lifecycle.
+var States = []State{StateDetected, StateConfirmed, StateResolved, StateDismissed}
+
+// OpenStates lists the States of vulnerabilities that still need triage or remediation.
+var OpenStates = []State{StateDetected, StateConfirmed}
+
+// ParseState strictly converts user input to a State, returning an error for unrecognized values.
+func ParseState(state string) (State, error) {
+	valid := make([]string, 0, len(States))
+	for _, s := range States {
+		valid = append(valid, string(s))
+	}
+	parsed, err := parseEnum("state", state, valid)
+	return State(parsed), err
+}
+
+// ParseStates strictly converts a slice of user input to a slice of States, returning an error for the first
+// unrecognized value.
+func ParseStates(states []string) ([]State, error) {
+	result := make([]State, 0, len(states))
+	for _, state := range states {
+		parsed, err := ParseState(state)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+// ToState converts a string to a State, returning StateDetected if the string is not recognized. It is meant for
+// values returned by the Gitlab API; use ParseState to validate user input.
 func ToState(state string) State {
 	switch strings.ToLower(state) {
 	case "detected":
 		return StateDetected
+	case "confirmed":
+		return StateConfirmed
 	case "resolved":
 		return StateResolved
 	case "dismissed":
@@ -134,3 +134,58 @@ func TestContainsState(t *testing.T) {
 		})
 	}
 }
+
+func TestParseState(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   string
+		want    vulnerability.State
+		wantErr string
+	}{
+		{
+			name:  "Test Parse Confirmed",
+			state: "confirmed",
+			want:  vulnerability.StateConfirmed,
+		},
+		{
+			name:  "Test Parse Caps And Spaces",
+			state: " Dismissed ",
+			want:  vulnerability.StateDismissed,
+		},
+		{
+			name:    "Test Parse Typo",
+			state:   "dismised",
+			wantErr: `invalid state "dismised", did you mean "dismissed"? Valid values are 'detected', 'confirmed', 'resolved', 'dismissed'`,
+		},
+		{
+			name:    "Test Parse Unknown",
+			state:   "open",
+			wantErr: `invalid state "open", valid values are 'detected', 'confirmed', 'resolved', 'dismissed'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := vulnerability.ParseState(tt.state)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseState(%s) error = %v, want %s", tt.state, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseState(%s) = %s, %v, want %s", tt.state, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStates(t *testing.T) {
+	got, err := vulnerability.ParseStates([]string{"detected", "confirmed"})
+	if err != nil || !reflect.DeepEqual(got, vulnerability.OpenStates) {
+		t.Errorf("ParseStates() = %v, %v, want %v", got, err, vulnerability.OpenStates)
+	}
+	if _, err := vulnerability.ParseStates([]string{"detected", "fixed"}); err == nil {
+		t.Error("expected an error for an unknown state")
+	}
+}
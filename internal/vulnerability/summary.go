@@ -0,0 +1,107 @@
+package vulnerability
+
+import (
+	"sort"
+	"strings"
+)
+
+// IdentifierCount counts the vulnerabilities sharing an identifier.
+type IdentifierCount struct {
+	Identifier string `json:"identifier" yaml:"identifier"`
+	Count      int    `json:"count" yaml:"count"`
+}
+
+// MonthCount counts the vulnerabilities detected in a calendar month, formatted as YYYY-MM, broken down by severity.
+type MonthCount struct {
+	Month      string           `json:"month" yaml:"month"`
+	Count      int              `json:"count" yaml:"count"`
+	BySeverity map[Severity]int `json:"by_severity" yaml:"by_severity"`
+}
+
+// Counts holds aggregated vulnerability counts. TopIdentifiers lists the most common CVEs, most common first, and
+// ByDetectionMonth lists the detection trend in chronological order.
+type Counts struct {
+	Total            int                `json:"total" yaml:"total"`
+	Suppressed       int                `json:"suppressed" yaml:"suppressed"`
+	ByProject        map[string]int     `json:"by_project" yaml:"by_project"`
+	BySeverity       map[Severity]int   `json:"by_severity" yaml:"by_severity"`
+	ByState          map[State]int      `json:"by_state" yaml:"by_state"`
+	ByReportType     map[string]int     `json:"by_report_type" yaml:"by_report_type"`
+	ByScanner        map[string]int     `json:"by_scanner" yaml:"by_scanner"`
+	TopIdentifiers   []*IdentifierCount `json:"top_identifiers" yaml:"top_identifiers"`
+	ByDetectionMonth []*MonthCount      `json:"by_detection_month" yaml:"by_detection_month"`
+}
+
+// CountVulnerabilities aggregates vulnerabilities into Counts, keeping the topN most common CVEs. Severities and
+// states are normalized with ToSeverity and ToState, so the counts match the detailed report.
+func CountVulnerabilities(vulns []*Vulnerability, topN int) *Counts {
+	counts := &Counts{
+		ByProject:        map[string]int{},
+		BySeverity:       map[Severity]int{},
+		ByState:          map[State]int{},
+		ByReportType:     map[string]int{},
+		ByScanner:        map[string]int{},
+		TopIdentifiers:   []*IdentifierCount{},
+		ByDetectionMonth: []*MonthCount{},
+	}
+
+	identifiers := map[string]int{}
+	months := map[string]*MonthCount{}
+	for _, vuln := range vulns {
+		severity := ToSeverity(vuln.Severity)
+		counts.Total++
+		if vuln.Suppression != nil {
+			counts.Suppressed++
+		}
+		counts.ByProject[ProjectKey(&vuln.ProjectVulnerability)]++
+		counts.BySeverity[severity]++
+		counts.ByState[ToState(vuln.State)]++
+		if vuln.ReportType != "" {
+			counts.ByReportType[vuln.ReportType]++
+		}
+
+		metadata := ParseFindingMetadata(&vuln.ProjectVulnerability)
+		if metadata.ScannerID != "" {
+			counts.ByScanner[metadata.ScannerID]++
+		}
+		seen := map[string]bool{}
+		for _, identifier := range metadata.Identifiers {
+			name := strings.ToUpper(identifier.Name)
+			if !strings.EqualFold(identifier.Type, "cve") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			identifiers[name]++
+		}
+
+		if detectedAt := DetectedAt(&vuln.ProjectVulnerability); detectedAt != nil {
+			month := detectedAt.UTC().Format("2006-01")
+			if months[month] == nil {
+				months[month] = &MonthCount{Month: month, BySeverity: map[Severity]int{}}
+			}
+			months[month].Count++
+			months[month].BySeverity[severity]++
+		}
+	}
+
+	for identifier, count := range identifiers {
+		counts.TopIdentifiers = append(counts.TopIdentifiers, &IdentifierCount{Identifier: identifier, Count: count})
+	}
+	sort.Slice(counts.TopIdentifiers, func(i, j int) bool {
+		if counts.TopIdentifiers[i].Count != counts.TopIdentifiers[j].Count {
+			return counts.TopIdentifiers[i].Count > counts.TopIdentifiers[j].Count
+		}
+		return counts.TopIdentifiers[i].Identifier < counts.TopIdentifiers[j].Identifier
+	})
+	if topN >= 0 && len(counts.TopIdentifiers) > topN {
+		counts.TopIdentifiers = counts.TopIdentifiers[:topN]
+	}
+
+	for _, month := range months {
+		counts.ByDetectionMonth = append(counts.ByDetectionMonth, month)
+	}
+	sort.Slice(counts.ByDetectionMonth, func(i, j int) bool {
+		return counts.ByDetectionMonth[i].Month < counts.ByDetectionMonth[j].Month
+	})
+	return counts
+}
@@ -0,0 +1,42 @@
+package vulnerability_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestCountVulnerabilities(t *testing.T) {
+	api := &gitlab.Project{ID: 1, PathWithNamespace: "acme/api"}
+	withCVE := func(cve string) *gitlab.Finding {
+		return &gitlab.Finding{RawMetadata: `{"scanner": {"id": "trivy"}, "identifiers": [{"type": "cve", "name": "` + cve + `"}, {"type": "cve", "name": "` + cve + `"}]}`}
+	}
+	vulns := []*vulnerability.Vulnerability{
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: api, State: "detected", Severity: "critical", ReportType: "container_scanning", CreatedAt: date(5), Finding: withCVE("CVE-2024-1")}},
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: api, State: "resolved", Severity: "CRITICAL", ReportType: "container_scanning", CreatedAt: date(6), Finding: withCVE("cve-2024-1")}},
+		{ProjectVulnerability: gitlab.ProjectVulnerability{Project: &gitlab.Project{ID: 2}, State: "detected", Severity: "low", ReportType: "sast", Finding: withCVE("CVE-2024-2")}, Suppression: &vulnerability.Suppression{Rule: "r"}},
+		{ProjectVulnerability: *newVulnerability("dismissed", "high")},
+	}
+	vulns[3].Project = api
+
+	counts := vulnerability.CountVulnerabilities(vulns, 1)
+	if counts.Total != 4 || counts.Suppressed != 1 {
+		t.Errorf("Total = %d, Suppressed = %d, want 4 and 1", counts.Total, counts.Suppressed)
+	}
+	if counts.ByProject["acme/api"] != 3 || counts.ByProject["2"] != 1 {
+		t.Errorf("unexpected ByProject %v", counts.ByProject)
+	}
+	if counts.BySeverity[vulnerability.SeverityCritical] != 2 || counts.ByState[vulnerability.StateDetected] != 2 {
+		t.Errorf("unexpected BySeverity %v or ByState %v", counts.BySeverity, counts.ByState)
+	}
+	if counts.ByReportType["container_scanning"] != 2 || counts.ByScanner["trivy"] != 3 || counts.ByScanner["gemnasium"] != 1 {
+		t.Errorf("unexpected ByReportType %v or ByScanner %v", counts.ByReportType, counts.ByScanner)
+	}
+	if len(counts.TopIdentifiers) != 1 || counts.TopIdentifiers[0].Identifier != "CVE-2024-1" || counts.TopIdentifiers[0].Count != 2 {
+		t.Errorf("unexpected TopIdentifiers %+v", counts.TopIdentifiers)
+	}
+	if len(counts.ByDetectionMonth) != 1 || counts.ByDetectionMonth[0].Month != "2026-01" || counts.ByDetectionMonth[0].Count != 2 {
+		t.Errorf("unexpected ByDetectionMonth %+v", counts.ByDetectionMonth)
+	}
+}
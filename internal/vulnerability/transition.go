@@ -0,0 +1,288 @@
+package vulnerability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/projects"
+	"github.com/xanzy/go-gitlab"
+)
+
+// DismissalReason represents the reason recorded when dismissing a vulnerability, as defined by the Gitlab API.
+type DismissalReason string
+
+const (
+	DismissalReasonAcceptableRisk    DismissalReason = "acceptable_risk"
+	DismissalReasonFalsePositive     DismissalReason = "false_positive"
+	DismissalReasonMitigatingControl DismissalReason = "mitigating_control"
+	DismissalReasonUsedInTests       DismissalReason = "used_in_tests"
+	DismissalReasonNotApplicable     DismissalReason = "not_applicable"
+)
+
+// DismissalReasons lists every DismissalReason accepted by the Gitlab API.
+var DismissalReasons = []DismissalReason{
+	DismissalReasonAcceptableRisk,
+	DismissalReasonFalsePositive,
+	DismissalReasonMitigatingControl,
+	DismissalReasonUsedInTests,
+	DismissalReasonNotApplicable,
+}
+
+// TransitionStates lists the States vulnerabilities can be transitioned to.
+var TransitionStates = []State{StateConfirmed, StateDismissed, StateResolved}
+
+// TransitionOptions holds the options for transitioning vulnerabilities to a new state.
+// The ProjectID, GroupID and IDs fields select the vulnerabilities to consider: those of a single project, of every
+// project in a group and its subgroups, or an explicit list of vulnerability IDs. Exactly one of them is required.
+// The Filter field narrows the selected vulnerabilities down further. When it specifies no states, only open
+// (detected or confirmed) vulnerabilities are considered. Vulnerabilities fetched by ID carry no finding, so their
+// scanners, identifiers and paths are unknown and those filters cannot be combined with IDs.
+// The TargetState field is the state to transition the vulnerabilities to, and the Comment field is the comment
+// recorded with every transition. The DismissalReason field is required when, and only when, dismissing.
+// The DryRun field reports the planned transitions without applying them.
+type TransitionOptions struct {
+	ProjectID       string              `json:"project_id" yaml:"project_id"`
+	GroupID         string              `json:"group_id" yaml:"group_id"`
+	IDs             []int               `json:"ids" yaml:"ids"`
+	Filter          VulnerabilityFilter `json:"filter" yaml:"filter"`
+	TargetState     State               `json:"target_state" yaml:"target_state"`
+	Comment         string              `json:"comment" yaml:"comment"`
+	DismissalReason DismissalReason     `json:"dismissal_reason" yaml:"dismissal_reason"`
+	DryRun          bool                `json:"dry_run" yaml:"dry_run"`
+}
+
+// Validate checks that the options describe a transition the Gitlab API will accept.
+func (o *TransitionOptions) Validate() error {
+	scopes := 0
+	for _, set := range []bool{o.ProjectID != "", o.GroupID != "", len(o.IDs) > 0} {
+		if set {
+			scopes++
+		}
+	}
+	if scopes != 1 {
+		return errors.New("exactly one of a project ID, a group ID or a list of vulnerability IDs is required")
+	}
+	if len(o.IDs) > 0 && (len(o.Filter.Scanners) > 0 || len(o.Filter.Identifiers) > 0 || len(o.Filter.Paths) > 0) {
+		return errors.New("scanners, identifiers and paths cannot be filtered on when selecting vulnerabilities by ID, as the Gitlab API does not return their findings")
+	}
+	if !ContainsState(o.TargetState, TransitionStates) {
+		return fmt.Errorf("invalid target state %q, valid values are 'confirmed', 'dismissed', 'resolved'", o.TargetState)
+	}
+	if strings.TrimSpace(o.Comment) == "" {
+		return errors.New("a comment is required")
+	}
+	if o.TargetState == StateDismissed {
+		if o.DismissalReason == "" {
+			return errors.New("a dismissal reason is required when dismissing vulnerabilities")
+		}
+		if !containsDismissalReason(o.DismissalReason) {
+			return fmt.Errorf("invalid dismissal reason %q, valid values are 'acceptable_risk', 'false_positive', 'mitigating_control', 'used_in_tests', 'not_applicable'", o.DismissalReason)
+		}
+	} else if o.DismissalReason != "" {
+		return errors.New("a dismissal reason can only be provided when dismissing vulnerabilities")
+	}
+	return nil
+}
+
+func containsDismissalReason(reason DismissalReason) bool {
+	for _, r := range DismissalReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadVulnerabilityIDs reads a JSON list of vulnerability IDs from the provided path.
+func LoadVulnerabilityIDs(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to parse vulnerability IDs file %s, expected a JSON list of IDs: %w", path, err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("vulnerability IDs file %s is empty", path)
+	}
+	return ids, nil
+}
+
+// PlanTransitions returns the planned StateChanges for the vulnerabilities that match the filter and are not already
+// in the target state. Vulnerabilities that are filtered out are not included.
+func PlanTransitions(vulns []*gitlab.ProjectVulnerability, options *TransitionOptions) []*StateChange {
+	filter := options.Filter
+	if len(filter.States) == 0 {
+		filter.States = []State{StateDetected, StateConfirmed}
+	}
+
+	changes := []*StateChange{}
+	for _, vuln := range vulns {
+		if ToState(vuln.State) == options.TargetState || !filter.Matches(vuln) {
+			continue
+		}
+		change := &StateChange{
+			VulnerabilityID: vuln.ID,
+			Title:           vuln.Title,
+			Severity:        ToSeverity(vuln.Severity),
+			ReportType:      vuln.ReportType,
+			Identifier:      ParseFindingMetadata(vuln).PrimaryIdentifier(),
+			FromState:       ToState(vuln.State),
+			ToState:         options.TargetState,
+		}
+		if vuln.Project != nil {
+			change.ProjectID = vuln.Project.ID
+			change.ProjectPath = vuln.Project.PathWithNamespace
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// TransitionVulnerabilities transitions the selected vulnerabilities to the target state, recording the comment and
+// dismissal reason with every transition. When DryRun is set the planned transitions are reported without being applied.
+// Failures to list the vulnerabilities of a single project, or to transition a single vulnerability, are recorded as
+// non-fatal errors in the report.
+func TransitionVulnerabilities(ctx context.Context, baseURL string, options *TransitionOptions, client *gitlab.Client) (*StateChangeReport, error) {
+	report := &StateChangeReport{
+		BaseURL:     baseURL,
+		TargetState: options.TargetState,
+		DryRun:      options.DryRun,
+		Resources:   StateChangeResources{Changes: []*StateChange{}},
+		ErrorLog:    nonfatal.NewErrorLog(),
+	}
+	if err := options.Validate(); err != nil {
+		return report, err
+	}
+
+	vulns, err := selectVulnerabilities(ctx, client, options.ProjectID, options.GroupID, options.IDs, &report.ErrorLog)
+	if err != nil {
+		return report, err
+	}
+	report.Resources.Changes = PlanTransitions(vulns, options)
+	if options.DryRun {
+		return report, nil
+	}
+
+	for _, change := range report.Resources.Changes {
+		if err := setState(ctx, client, change.VulnerabilityID, options); err != nil {
+			change.Error = err.Error()
+			report.AddError(nonfatal.Newf("vulnerability", fmt.Sprintf("%d", change.VulnerabilityID), err, "failed to transition vulnerability %d to %s: %s", change.VulnerabilityID, options.TargetState, err.Error()))
+			continue
+		}
+		change.Applied = true
+	}
+	return report, nil
+}
+
+// selectVulnerabilities fetches the vulnerabilities of a single project, of every project in a group and its
+// subgroups, or with the provided IDs. Vulnerabilities listed by project are attached to the full project, so its path
+// is known. Failures affecting a single project or vulnerability are recorded in errs.
+func selectVulnerabilities(ctx context.Context, client *gitlab.Client, projectID string, groupID string, ids []int, errs *nonfatal.ErrorLog) ([]*gitlab.ProjectVulnerability, error) {
+	vulns := []*gitlab.ProjectVulnerability{}
+	var targets []*gitlab.Project
+	switch {
+	case len(ids) > 0:
+		for _, id := range ids {
+			vuln, err := GetVulnerability(ctx, client, id)
+			if err != nil {
+				errs.AddError(nonfatal.Newf("vulnerability", fmt.Sprintf("%d", id), err, "failed to get vulnerability %d: %s", id, err.Error()))
+				continue
+			}
+			vulns = append(vulns, vuln)
+		}
+		return vulns, nil
+	case projectID != "":
+		project, _, err := client.Projects.GetProject(projectID, &gitlab.GetProjectOptions{}, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		targets = []*gitlab.Project{project}
+	case groupID != "":
+		projectReport, err := projects.EnumerateProjectsForGroup(ctx, "", client, &projects.EnumerateProjectsOptions{
+			Mine:     false,
+			Archived: false,
+			GroupID:  groupID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		errs.Merge(projectReport.ErrorLog)
+		targets = projectReport.Resources.Projects
+	}
+
+	for _, project := range targets {
+		projectVulns, err := ListProjectVulnerabilities(ctx, client, project.ID)
+		if err != nil {
+			if projectID != "" {
+				return nil, err
+			}
+			errs.AddError(nonfatal.Newf("project", project.PathWithNamespace, err, "failed to list vulnerabilities for project %s: %s", project.PathWithNamespace, err.Error()))
+			continue
+		}
+		for _, vuln := range projectVulns {
+			vuln.Project = project
+		}
+		vulns = append(vulns, projectVulns...)
+	}
+	return vulns, nil
+}
+
+// GetVulnerability fetches a single vulnerability by ID. go-gitlab does not model the single vulnerability API, so
+// the request is made directly. Unlike the project vulnerabilities API, it does not return the vulnerability's finding.
+func GetVulnerability(ctx context.Context, client *gitlab.Client, id int) (*gitlab.ProjectVulnerability, error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("vulnerabilities/%d", id), nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	vuln := &gitlab.ProjectVulnerability{}
+	if _, err := client.Do(req, vuln); err != nil {
+		return nil, err
+	}
+	return vuln, nil
+}
+
+// transitionMutations holds the GraphQL mutation that transitions a vulnerability to each target state.
+var transitionMutations = map[State]string{
+	StateConfirmed: `mutation($id: VulnerabilityID!, $comment: String) {
+  result: vulnerabilityConfirm(input: {id: $id, comment: $comment}) { errors }
+}`,
+	StateResolved: `mutation($id: VulnerabilityID!, $comment: String) {
+  result: vulnerabilityResolve(input: {id: $id, comment: $comment}) { errors }
+}`,
+	StateDismissed: `mutation($id: VulnerabilityID!, $comment: String, $dismissalReason: VulnerabilityDismissalReason) {
+  result: vulnerabilityDismiss(input: {id: $id, comment: $comment, dismissalReason: $dismissalReason}) { errors }
+}`,
+}
+
+// setState transitions a single vulnerability through the GraphQL API, as the REST API cannot record a comment or
+// dismissal reason.
+func setState(ctx context.Context, client *gitlab.Client, id int, options *TransitionOptions) error {
+	variables := map[string]interface{}{
+		"id":      fmt.Sprintf("gid://gitlab/Vulnerability/%d", id),
+		"comment": options.Comment,
+	}
+	if options.TargetState == StateDismissed {
+		variables["dismissalReason"] = strings.ToUpper(string(options.DismissalReason))
+	}
+
+	out := struct {
+		Result struct {
+			Errors []string `json:"errors"`
+		} `json:"result"`
+	}{}
+	if err := graphQL(ctx, client, transitionMutations[options.TargetState], variables, &out); err != nil {
+		return err
+	}
+	if len(out.Result.Errors) > 0 {
+		return errors.New(strings.Join(out.Result.Errors, "; "))
+	}
+	return nil
+}
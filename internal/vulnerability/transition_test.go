@@ -0,0 +1,104 @@
+package vulnerability_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestTransitionOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options vulnerability.TransitionOptions
+		wantErr bool
+	}{
+		{
+			name:    "Resolve project",
+			options: vulnerability.TransitionOptions{ProjectID: "1", TargetState: vulnerability.StateResolved, Comment: "fixed"},
+			wantErr: false,
+		},
+		{
+			name:    "Dismiss with reason",
+			options: vulnerability.TransitionOptions{IDs: []int{1}, TargetState: vulnerability.StateDismissed, Comment: "test data", DismissalReason: vulnerability.DismissalReasonUsedInTests},
+			wantErr: false,
+		},
+		{
+			name:    "Dismiss without reason",
+			options: vulnerability.TransitionOptions{GroupID: "g", TargetState: vulnerability.StateDismissed, Comment: "test data"},
+			wantErr: true,
+		},
+		{
+			name:    "Reason when confirming",
+			options: vulnerability.TransitionOptions{GroupID: "g", TargetState: vulnerability.StateConfirmed, Comment: "real", DismissalReason: vulnerability.DismissalReasonFalsePositive},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown reason",
+			options: vulnerability.TransitionOptions{GroupID: "g", TargetState: vulnerability.StateDismissed, Comment: "x", DismissalReason: "bored"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing comment",
+			options: vulnerability.TransitionOptions{ProjectID: "1", TargetState: vulnerability.StateResolved, Comment: " "},
+			wantErr: true,
+		},
+		{
+			name:    "Detected is not a target",
+			options: vulnerability.TransitionOptions{ProjectID: "1", TargetState: vulnerability.StateDetected, Comment: "reopen"},
+			wantErr: true,
+		},
+		{
+			name:    "Identifiers with IDs",
+			options: vulnerability.TransitionOptions{IDs: []int{1}, Filter: vulnerability.VulnerabilityFilter{Identifiers: []string{"CVE-*"}}, TargetState: vulnerability.StateResolved, Comment: "fixed"},
+			wantErr: true,
+		},
+		{
+			name:    "Severities with IDs",
+			options: vulnerability.TransitionOptions{IDs: []int{1}, Filter: vulnerability.VulnerabilityFilter{Severities: []vulnerability.Severity{vulnerability.SeverityHigh}}, TargetState: vulnerability.StateResolved, Comment: "fixed"},
+			wantErr: false,
+		},
+		{
+			name:    "Two scopes",
+			options: vulnerability.TransitionOptions{ProjectID: "1", GroupID: "g", TargetState: vulnerability.StateResolved, Comment: "fixed"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlanTransitions(t *testing.T) {
+	vulns := []*gitlab.ProjectVulnerability{
+		{ID: 1, State: "detected", Severity: "high", Project: &gitlab.Project{ID: 7, PathWithNamespace: "acme/api"}},
+		{ID: 2, State: "confirmed", Severity: "low"},
+		{ID: 3, State: "resolved", Severity: "high"},
+		{ID: 4, State: "dismissed", Severity: "high"},
+	}
+
+	options := &vulnerability.TransitionOptions{TargetState: vulnerability.StateResolved}
+	changes := vulnerability.PlanTransitions(vulns, options)
+	if len(changes) != 2 || changes[0].VulnerabilityID != 1 || changes[1].VulnerabilityID != 2 {
+		t.Fatalf("expected open vulnerabilities 1 and 2 to be planned, got %+v", changes)
+	}
+	if changes[0].ProjectPath != "acme/api" || changes[0].FromState != vulnerability.StateDetected || changes[0].Applied {
+		t.Errorf("unexpected change %+v", changes[0])
+	}
+
+	options = &vulnerability.TransitionOptions{
+		TargetState: vulnerability.StateDismissed,
+		Filter: vulnerability.VulnerabilityFilter{
+			States:     []vulnerability.State{vulnerability.StateResolved, vulnerability.StateDismissed},
+			Severities: []vulnerability.Severity{vulnerability.SeverityHigh},
+		},
+	}
+	changes = vulnerability.PlanTransitions(vulns, options)
+	if len(changes) != 1 || changes[0].VulnerabilityID != 3 {
+		t.Errorf("expected only vulnerability 3 to be planned, got %+v", changes)
+	}
+}
@@ -3,76 +3,175 @@ package vulnerability
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/Method-Security/gitlabctl/internal/checkpoint"
+	"github.com/Method-Security/gitlabctl/internal/ndjson"
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/projects"
+	"github.com/Method-Security/gitlabctl/internal/telemetry"
 	"github.com/xanzy/go-gitlab"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // EnumerateSecurityVulnerabilitiesOptions holds the options for enumerating security vulnerabilities.
-// The ProjectID field is used to specify the project ID to enumerate vulnerabilities for.
+// The ProjectID and GroupID fields select a single project, or every project in a group and its subgroups. Exactly one
+// of them is required.
 // The States field is used to filter vulnerabilities by state, only returning vulnerabilities that match the specified states.
 // The Severities field is used to filter vulnerabilities by severity, only returning vulnerabilities that match the specified severities.
+// The ReportTypes field is used to filter vulnerabilities by report type. When empty, every report type is returned.
+// The Rules field holds the suppression rules applied to the returned vulnerabilities. Suppressed vulnerabilities are
+// still returned, marked with the rule that suppressed them.
+// The FailOnSeverity field sets a failure threshold: unsuppressed vulnerabilities at or above this severity are counted
+// in the report's ThresholdExceeded field.
+// The SLA field holds the remediation SLA every vulnerability's age is evaluated against. When it is nil, ages and SLAs
+// are not evaluated.
+// The Summary field replaces the individual vulnerabilities in the report with aggregated counts, listing the
+// TopIdentifiers most common CVEs.
+// The Stream field, when set, receives the vulnerabilities as they are fetched instead of the report. It is ignored
+// for summaries, which need every vulnerability.
+// The Checkpoint field, when set, records the projects listed and the vulnerability pages fetched so far, so an
+// interrupted group enumeration can resume.
 type EnumerateSecurityVulnerabilitiesOptions struct {
-	ProjectID  int        `json:"project_id" yaml:"project_id"`
-	States     []State    `json:"states" yaml:"states"`
-	Severities []Severity `json:"severities" yaml:"severities"`
+	ProjectID      int                    `json:"project_id" yaml:"project_id"`
+	GroupID        string                 `json:"group_id" yaml:"group_id"`
+	States         []State                `json:"states" yaml:"states"`
+	Severities     []Severity             `json:"severities" yaml:"severities"`
+	ReportTypes    []ReportType           `json:"report_types" yaml:"report_types"`
+	Rules          *RuleSet               `json:"rules" yaml:"rules"`
+	FailOnSeverity Severity               `json:"fail_on_severity" yaml:"fail_on_severity"`
+	SLA            SLAPolicy              `json:"sla" yaml:"sla"`
+	Summary        bool                   `json:"summary" yaml:"summary"`
+	TopIdentifiers int                    `json:"top_identifiers" yaml:"top_identifiers"`
+	Stream         *ndjson.Writer         `json:"-" yaml:"-"`
+	Checkpoint     *checkpoint.Checkpoint `json:"-" yaml:"-"`
 }
 
 // NewEnumerateSecurityVulnerabilitiesOptions creates a new EnumerateSecurityVulnerabilitiesOptions struct with
-// the provided project ID, states, and severities.
-// If states are not provided, the default state of 'detected' is used.
-// If severities are not provided, the default is that all severities are included.
-func NewEnumerateSecurityVulnerabilitiesOptions(projectID int, states []string, severities []string) (*EnumerateSecurityVulnerabilitiesOptions, error) {
-	if projectID == 0 {
-		return nil, errors.New("project ID is required")
+// the provided project or group ID, states, severities, minimum severity and report types, returning an error for any
+// unrecognized value.
+// If states are not provided, the default open states of 'detected' and 'confirmed' are used.
+// If severities are not provided, the default is that all severities at or above the minimum severity are included,
+// which is every severity when no minimum is provided. Severities and a minimum severity cannot be combined.
+func NewEnumerateSecurityVulnerabilitiesOptions(projectID int, groupID string, states []string, severities []string, minSeverity string, reportTypes []string) (*EnumerateSecurityVulnerabilitiesOptions, error) {
+	if (projectID == 0) == (groupID == "") {
+		return nil, errors.New("exactly one of a project ID or a group ID is required")
 	}
-	if len(states) == 0 {
-		states = []string{"detected"}
-	}
-	if len(severities) == 0 {
-		severities = []string{"unknown", "info", "low", "medium", "high", "critical"}
+	filter, err := ParseFilter(states, severities, minSeverity, reportTypes, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return &EnumerateSecurityVulnerabilitiesOptions{
-		ProjectID:  projectID,
-		States:     ToStates(states),
-		Severities: ToSeverities(severities),
-	}, nil
+	opts := &EnumerateSecurityVulnerabilitiesOptions{
+		ProjectID:   projectID,
+		GroupID:     groupID,
+		States:      filter.States,
+		Severities:  filter.Severities,
+		ReportTypes: filter.ReportTypes,
+	}
+	if len(opts.States) == 0 {
+		opts.States = OpenStates
+	}
+	if len(opts.Severities) == 0 {
+		opts.Severities = Severities
+	}
+	return opts, nil
 }
 
-// EnumerateSecurityVulnerabilities enumerates all of the security vulnerabilities for a project, filtering by the provided options.
+// EnumerateSecurityVulnerabilities enumerates all of the security vulnerabilities for a project, or for every project in
+// a group and its subgroups, filtering by the provided options. Every page of vulnerabilities is evaluated, and streamed
+// when the options hold a stream, as soon as it is fetched. Failures to list the vulnerabilities of a project are
+// recorded as non-fatal errors in the report.
 func EnumerateSecurityVulnerabilities(ctx context.Context, baseURL string, enumerateOpts *EnumerateSecurityVulnerabilitiesOptions, client *gitlab.Client) (*GitlabResourceReport, error) {
 	report := GitlabResourceReport{
 		Resources: GitlabResources{},
-		Errors:    []string{},
+		ErrorLog:  nonfatal.NewErrorLog(),
 		BaseURL:   baseURL,
 	}
 
-	opt := &gitlab.ListProjectVulnerabilitiesOptions{
-		ListOptions: gitlab.ListOptions{
-			Page:    1,
-			PerPage: 100,
-		},
+	now := time.Now()
+	for _, rule := range enumerateOpts.Rules.ExpiredRules(now) {
+		report.AddError(nonfatal.Newf("suppression_rule", rule.ID, nil, "suppression rule %s expired on %s and no longer suppresses vulnerabilities", rule.ID, rule.Expires))
 	}
 
-	for {
-		vulns, resp, err := client.ProjectVulnerabilities.ListProjectVulnerabilities(enumerateOpts.ProjectID, opt)
-		if err != nil {
-			report.Errors = append(report.Errors, err.Error())
-			break
-		}
+	stream := enumerateOpts.Stream
+	if enumerateOpts.Summary {
+		stream = nil
+	}
+	var sla *SLATally
+	if enumerateOpts.SLA != nil {
+		sla = NewSLATally(enumerateOpts.SLA)
+	}
 
-		filteredVulns := FilterVulnerabilities(vulns, enumerateOpts.States, enumerateOpts.Severities)
-		report.Resources.Vulnerabilities = append(report.Resources.Vulnerabilities, filteredVulns...)
+	evaluate := func(vulns []*gitlab.ProjectVulnerability) {
+		for _, vuln := range FilterVulnerabilities(vulns, enumerateOpts.States, enumerateOpts.Severities) {
+			if len(enumerateOpts.ReportTypes) > 0 && !ContainsReportType(ReportType(vuln.ReportType), enumerateOpts.ReportTypes) {
+				continue
+			}
+			result := &Vulnerability{
+				ProjectVulnerability: *vuln,
+				Suppression:          enumerateOpts.Rules.Suppress(vuln, now),
+			}
+			if enumerateOpts.SLA != nil {
+				EvaluateAge(result, enumerateOpts.SLA, now)
+				sla.Add(result)
+			}
+			if result.ExceedsThreshold(enumerateOpts.FailOnSeverity) {
+				report.ThresholdExceeded++
+			}
+			if !stream.Emit("vulnerabilities", result) {
+				report.Resources.Vulnerabilities = append(report.Resources.Vulnerabilities, result)
+			}
+		}
+	}
 
-		if resp.CurrentPage >= resp.TotalPages {
-			break
+	if enumerateOpts.GroupID != "" {
+		projectReport, err := projects.EnumerateProjectsForGroup(ctx, baseURL, client, &projects.EnumerateProjectsOptions{
+			Mine:       false,
+			Archived:   false,
+			GroupID:    enumerateOpts.GroupID,
+			Checkpoint: enumerateOpts.Checkpoint,
+		})
+		if err != nil {
+			return &report, err
 		}
+		report.Merge(projectReport.ErrorLog)
+		for _, project := range projectReport.Resources.Projects {
+			projectCtx, span := telemetry.StartSpan(ctx, "list project vulnerabilities",
+				attribute.Int("gitlab.project.id", project.ID), attribute.String("gitlab.project.path", project.PathWithNamespace))
+			err := walkProjectVulnerabilities(projectCtx, client, project.ID, enumerateOpts.Checkpoint, func(vulns []*gitlab.ProjectVulnerability) {
+				// Attach the full project, so its path is known
+				for _, vuln := range vulns {
+					vuln.Project = project
+				}
+				evaluate(vulns)
+			})
+			telemetry.EndSpan(span, err)
+			if err != nil {
+				report.AddError(nonfatal.Newf("project", project.PathWithNamespace, err, "failed to list vulnerabilities for project %s: %s", project.PathWithNamespace, err.Error()))
+			}
+		}
+	} else if err := walkProjectVulnerabilities(ctx, client, enumerateOpts.ProjectID, nil, evaluate); err != nil {
+		report.AddError(nonfatal.New("project", fmt.Sprintf("%d", enumerateOpts.ProjectID), err))
+	}
 
-		opt.ListOptions.Page = resp.NextPage
+	if sla != nil {
+		report.Summary.SLA = sla.Summary()
+	}
+	if enumerateOpts.Summary {
+		report.Summary.Counts = CountVulnerabilities(report.Resources.Vulnerabilities, enumerateOpts.TopIdentifiers)
+		report.Resources.Vulnerabilities = []*Vulnerability{}
 	}
 	return &report, nil
 }
 
+// ExceedsThreshold reports whether the vulnerability is unsuppressed and at or above the provided failure threshold.
+// An empty threshold is never exceeded.
+func (v *Vulnerability) ExceedsThreshold(threshold Severity) bool {
+	return threshold != "" && v.Suppression == nil && ToSeverity(v.Severity).AtLeast(threshold)
+}
+
 // FilterVulnerabilities filters a slice of vulnerabilities by state and severity, returning only the vulnerabilities
 // that match the provided states and severities.
 func FilterVulnerabilities(vulns []*gitlab.ProjectVulnerability, states []State, severities []Severity) []*gitlab.ProjectVulnerability {
@@ -84,3 +183,49 @@ func FilterVulnerabilities(vulns []*gitlab.ProjectVulnerability, states []State,
 	}
 	return filteredVulns
 }
+
+// ListProjectVulnerabilities lists every vulnerability of a project, following pagination until the last page.
+func ListProjectVulnerabilities(ctx context.Context, client *gitlab.Client, projectID int) ([]*gitlab.ProjectVulnerability, error) {
+	result := []*gitlab.ProjectVulnerability{}
+	err := walkProjectVulnerabilities(ctx, client, projectID, nil, func(vulns []*gitlab.ProjectVulnerability) {
+		result = append(result, vulns...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// walkProjectVulnerabilities lists the vulnerabilities of a project page by page, passing every page to walk as soon as
+// it is fetched. The checkpoint, when set, records every page before it is walked, so an interrupted listing resumes
+// after the last page fetched, walking the pages recorded by the previous run first.
+func walkProjectVulnerabilities(ctx context.Context, client *gitlab.Client, projectID int, cp *checkpoint.Checkpoint, walk func([]*gitlab.ProjectVulnerability)) error {
+	listing := fmt.Sprintf("projects/%d/vulnerabilities", projectID)
+	var listed []*gitlab.ProjectVulnerability
+	page, err := cp.Position(listing, &listed)
+	if err != nil {
+		return err
+	}
+	if len(listed) > 0 {
+		walk(listed)
+	}
+
+	opt := &gitlab.ListProjectVulnerabilitiesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for page != 0 {
+		opt.ListOptions.Page = page
+		vulns, resp, err := client.ProjectVulnerabilities.ListProjectVulnerabilities(projectID, opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if err := cp.Advance(listing, vulns, resp.NextPage); err != nil {
+			return err
+		}
+		walk(vulns)
+		page = resp.NextPage
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package vulnerability_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/vulnerability"
+)
+
+func TestNewEnumerateSecurityVulnerabilitiesOptions(t *testing.T) {
+	opts, err := vulnerability.NewEnumerateSecurityVulnerabilitiesOptions(1, "", nil, nil, "", nil)
+	if err != nil {
+		t.Fatalf("NewEnumerateSecurityVulnerabilitiesOptions() error = %v", err)
+	}
+	if !reflect.DeepEqual(opts.States, vulnerability.OpenStates) || !reflect.DeepEqual(opts.Severities, vulnerability.Severities) || len(opts.ReportTypes) != 0 {
+		t.Errorf("unexpected defaults %+v", opts)
+	}
+
+	opts, err = vulnerability.NewEnumerateSecurityVulnerabilitiesOptions(0, "acme", []string{"resolved"}, nil, "medium", []string{"SAST", "secret_detection"})
+	if err != nil {
+		t.Fatalf("NewEnumerateSecurityVulnerabilitiesOptions() error = %v", err)
+	}
+	wantSeverities := []vulnerability.Severity{vulnerability.SeverityMedium, vulnerability.SeverityHigh, vulnerability.SeverityCritical}
+	wantReportTypes := []vulnerability.ReportType{vulnerability.ReportTypeSAST, vulnerability.ReportTypeSecretDetection}
+	if !reflect.DeepEqual(opts.Severities, wantSeverities) || !reflect.DeepEqual(opts.ReportTypes, wantReportTypes) {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	invalid := []struct {
+		name        string
+		projectID   int
+		groupID     string
+		states      []string
+		severities  []string
+		minSeverity string
+		reportTypes []string
+	}{
+		{name: "Missing project", projectID: 0},
+		{name: "Project and group", projectID: 1, groupID: "acme"},
+		{name: "Unknown state", projectID: 1, states: []string{"dismised"}},
+		{name: "Unknown severity", projectID: 1, severities: []string{"severe"}},
+		{name: "Unknown minimum severity", projectID: 1, minSeverity: "hi"},
+		{name: "Severities and minimum", projectID: 1, severities: []string{"high"}, minSeverity: "high"},
+		{name: "Unknown report type", projectID: 1, reportTypes: []string{"fuzzing"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := vulnerability.NewEnumerateSecurityVulnerabilitiesOptions(tt.projectID, tt.groupID, tt.states, tt.severities, tt.minSeverity, tt.reportTypes); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
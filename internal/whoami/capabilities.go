@@ -0,0 +1,157 @@
+package whoami
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requirement describes what a gitlabctl command needs from a token in order to run successfully.
+// Scopes lists the token scopes of which at least one must be present for the command to work at all.
+// RequiresAdmin marks commands that cannot run without an administrator token, while AdminNote is set for commands
+// that run without one but return a partial view of the instance.
+// Role is the minimum role the authenticated user needs on the projects or groups the command targets. Roles are held
+// per project or group, so they cannot be checked without a target and are reported instead of evaluated.
+// RequiresEnterprise marks commands that depend on Gitlab Enterprise Edition APIs.
+type requirement struct {
+	Command            string
+	Scopes             []string
+	RequiresAdmin      bool
+	AdminNote          string
+	Role               string
+	RequiresEnterprise bool
+}
+
+var requirements = []requirement{
+	{
+		Command: "projects",
+		Scopes:  []string{"api", "read_api"},
+		AdminNote: "without an administrator token only projects visible to the authenticated user are returned " +
+			"when --mine=false",
+	},
+	{
+		Command:            "vulnerabilities",
+		Scopes:             []string{"api", "read_api"},
+		RequiresEnterprise: true,
+	},
+	{
+		Command:            "vulnerabilities set-state",
+		Scopes:             []string{"api"},
+		Role:               "maintainer",
+		RequiresEnterprise: true,
+	},
+	{
+		Command:            "vulnerabilities create-issues",
+		Scopes:             []string{"api"},
+		RequiresEnterprise: true,
+	},
+	{
+		Command:       "users",
+		Scopes:        []string{"api", "read_api"},
+		RequiresAdmin: true,
+	},
+	{
+		Command:   "groups",
+		Scopes:    []string{"api", "read_api"},
+		AdminNote: "without an administrator token only groups the authenticated user is a member of are audited",
+		Role:      "owner",
+	},
+	{
+		Command:            "audit-events",
+		Scopes:             []string{"api", "read_api"},
+		AdminNote:          "without an administrator token instance audit events are unavailable and only group and project scopes can be used",
+		RequiresEnterprise: true,
+	},
+	{
+		Command: "registry",
+		Scopes:  []string{"api", "read_api"},
+	},
+	{
+		Command: "packages",
+		Scopes:  []string{"api", "read_api"},
+		Role:    "maintainer",
+	},
+	{
+		Command:            "dependencies",
+		Scopes:             []string{"api", "read_api"},
+		RequiresEnterprise: true,
+	},
+	{
+		Command:            "licenses",
+		Scopes:             []string{"api", "read_api"},
+		RequiresEnterprise: true,
+	},
+}
+
+// statusRank orders capability statuses from best to worst so that multiple findings can be combined.
+var statusRank = map[CapabilityStatus]int{
+	CapabilityAvailable:   0,
+	CapabilityUnknown:     1,
+	CapabilityDegraded:    2,
+	CapabilityUnavailable: 3,
+}
+
+func worst(a CapabilityStatus, b CapabilityStatus) CapabilityStatus {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
+}
+
+func hasAnyScope(scopes []string, wanted []string) bool {
+	for _, scope := range scopes {
+		for _, w := range wanted {
+			if strings.EqualFold(scope, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EvaluateCapabilities determines which gitlabctl commands will work with a token, based on its scopes, whether the
+// authenticated user is an administrator, and the edition of the Gitlab instance. A nil token or instance means that
+// information could not be retrieved, in which case the affected checks report CapabilityUnknown.
+func EvaluateCapabilities(token *TokenInfo, admin bool, instance *InstanceInfo) []Capability {
+	capabilities := make([]Capability, 0, len(requirements))
+	for _, req := range requirements {
+		capability := Capability{
+			Command:      req.Command,
+			Status:       CapabilityAvailable,
+			Reasons:      []string{},
+			RequiredRole: req.Role,
+		}
+
+		switch {
+		case token == nil:
+			capability.Status = worst(capability.Status, CapabilityUnknown)
+			capability.Reasons = append(capability.Reasons, "token scopes could not be determined")
+		case !token.Active || token.Revoked:
+			capability.Status = CapabilityUnavailable
+			capability.Reasons = append(capability.Reasons, "token is not active")
+		case !hasAnyScope(token.Scopes, req.Scopes):
+			capability.Status = CapabilityUnavailable
+			capability.Reasons = append(capability.Reasons, fmt.Sprintf("token requires one of the scopes: %s", strings.Join(req.Scopes, ", ")))
+		}
+
+		if req.RequiresAdmin && !admin {
+			capability.Status = CapabilityUnavailable
+			capability.Reasons = append(capability.Reasons, "requires an administrator token")
+		} else if req.AdminNote != "" && !admin {
+			capability.Status = worst(capability.Status, CapabilityDegraded)
+			capability.Reasons = append(capability.Reasons, req.AdminNote)
+		}
+
+		if req.RequiresEnterprise {
+			if instance == nil {
+				capability.Status = worst(capability.Status, CapabilityUnknown)
+				capability.Reasons = append(capability.Reasons, "instance edition could not be determined")
+			} else if !instance.Enterprise {
+				capability.Status = worst(capability.Status, CapabilityDegraded)
+				capability.Reasons = append(capability.Reasons, "requires Gitlab Enterprise Edition")
+			}
+		}
+
+		capabilities = append(capabilities, capability)
+	}
+	return capabilities
+}
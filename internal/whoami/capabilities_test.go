@@ -0,0 +1,114 @@
+package whoami_test
+
+import (
+	"testing"
+
+	"github.com/Method-Security/gitlabctl/internal/whoami"
+)
+
+func findCapability(capabilities []whoami.Capability, command string) *whoami.Capability {
+	for i := range capabilities {
+		if capabilities[i].Command == command {
+			return &capabilities[i]
+		}
+	}
+	return nil
+}
+
+func TestEvaluateCapabilities(t *testing.T) {
+	enterprise := &whoami.InstanceInfo{Version: "16.11.0-ee", Enterprise: true, Edition: "ee"}
+	community := &whoami.InstanceInfo{Version: "16.11.0", Enterprise: false, Edition: "ce"}
+	readAPI := &whoami.TokenInfo{Scopes: []string{"read_api"}, Active: true}
+
+	tests := []struct {
+		name     string
+		token    *whoami.TokenInfo
+		admin    bool
+		instance *whoami.InstanceInfo
+		command  string
+		want     whoami.CapabilityStatus
+	}{
+		{
+			name:     "Test Admin Read API",
+			token:    readAPI,
+			admin:    true,
+			instance: enterprise,
+			command:  "projects",
+			want:     whoami.CapabilityAvailable,
+		},
+		{
+			name:     "Test Non Admin Degraded",
+			token:    readAPI,
+			admin:    false,
+			instance: enterprise,
+			command:  "projects",
+			want:     whoami.CapabilityDegraded,
+		},
+		{
+			name:     "Test Missing Scope",
+			token:    &whoami.TokenInfo{Scopes: []string{"read_user"}, Active: true},
+			admin:    true,
+			instance: enterprise,
+			command:  "projects",
+			want:     whoami.CapabilityUnavailable,
+		},
+		{
+			name:     "Test Inactive Token",
+			token:    &whoami.TokenInfo{Scopes: []string{"api"}, Active: false, Revoked: true},
+			admin:    true,
+			instance: enterprise,
+			command:  "vulnerabilities",
+			want:     whoami.CapabilityUnavailable,
+		},
+		{
+			name:     "Test Community Edition",
+			token:    readAPI,
+			admin:    true,
+			instance: community,
+			command:  "vulnerabilities",
+			want:     whoami.CapabilityDegraded,
+		},
+		{
+			name:     "Test Unknown Token",
+			token:    nil,
+			admin:    true,
+			instance: enterprise,
+			command:  "vulnerabilities",
+			want:     whoami.CapabilityUnknown,
+		},
+		{
+			name:     "Test Unknown Instance",
+			token:    readAPI,
+			admin:    true,
+			instance: nil,
+			command:  "vulnerabilities",
+			want:     whoami.CapabilityUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capability := findCapability(whoami.EvaluateCapabilities(tt.token, tt.admin, tt.instance), tt.command)
+			if capability == nil {
+				t.Fatalf("EvaluateCapabilities did not return a capability for %s", tt.command)
+			}
+			if capability.Status != tt.want {
+				t.Errorf("EvaluateCapabilities(%s) status = %s, want %s (reasons: %v)", tt.command, capability.Status, tt.want, capability.Reasons)
+			}
+		})
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	token := &whoami.TokenInfo{Scopes: []string{"api"}, Active: true}
+	instance := &whoami.InstanceInfo{Version: "16.11.0-ee", Enterprise: true, Edition: "ee"}
+	capabilities := whoami.EvaluateCapabilities(token, false, instance)
+
+	setState := findCapability(capabilities, "vulnerabilities set-state")
+	if setState == nil || setState.RequiredRole != "maintainer" || setState.Status != whoami.CapabilityAvailable {
+		t.Errorf("vulnerabilities set-state = %+v, want an available capability requiring the maintainer role", setState)
+	}
+	if projects := findCapability(capabilities, "projects"); projects == nil || projects.RequiredRole != "" {
+		t.Errorf("projects = %+v, want no required role", projects)
+	}
+}
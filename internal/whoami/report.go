@@ -0,0 +1,66 @@
+package whoami
+
+import (
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/Method-Security/gitlabctl/internal/reportmeta"
+	"github.com/xanzy/go-gitlab"
+)
+
+// CapabilityStatus represents whether a gitlabctl command is expected to work with the supplied token.
+type CapabilityStatus string
+
+const (
+	CapabilityAvailable   CapabilityStatus = "available"
+	CapabilityDegraded    CapabilityStatus = "degraded"
+	CapabilityUnavailable CapabilityStatus = "unavailable"
+	CapabilityUnknown     CapabilityStatus = "unknown"
+)
+
+// Capability describes whether a single gitlabctl command will work with the supplied token, along with the reasons
+// that led to that determination. RequiredRole is the minimum role the authenticated user needs on the projects or
+// groups the command targets for complete results, which does not affect the status as it depends on the target.
+type Capability struct {
+	Command      string           `json:"command" yaml:"command"`
+	Status       CapabilityStatus `json:"status" yaml:"status"`
+	Reasons      []string         `json:"reasons" yaml:"reasons"`
+	RequiredRole string           `json:"required_role,omitempty" yaml:"required_role,omitempty"`
+}
+
+// InstanceInfo holds the version and edition information of the Gitlab instance the token was issued by.
+type InstanceInfo struct {
+	Version    string `json:"version" yaml:"version"`
+	Revision   string `json:"revision" yaml:"revision"`
+	Enterprise bool   `json:"enterprise" yaml:"enterprise"`
+	Edition    string `json:"edition" yaml:"edition"`
+	KASEnabled bool   `json:"kas_enabled" yaml:"kas_enabled"`
+}
+
+// TokenInfo holds the details of the personal access token used to authenticate, as reported by the Gitlab API.
+type TokenInfo struct {
+	ID         int      `json:"id" yaml:"id"`
+	Name       string   `json:"name" yaml:"name"`
+	Scopes     []string `json:"scopes" yaml:"scopes"`
+	Active     bool     `json:"active" yaml:"active"`
+	Revoked    bool     `json:"revoked" yaml:"revoked"`
+	ExpiresAt  string   `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty" yaml:"last_used_at,omitempty"`
+}
+
+// GitlabResources represents the authenticated identity, its token, the Gitlab instance and the capabilities the
+// token grants to gitlabctl commands.
+type GitlabResources struct {
+	User         *gitlab.User  `json:"user" yaml:"user"`
+	Admin        bool          `json:"admin" yaml:"admin"`
+	Token        *TokenInfo    `json:"token" yaml:"token"`
+	Instance     *InstanceInfo `json:"instance" yaml:"instance"`
+	Capabilities []Capability  `json:"capabilities" yaml:"capabilities"`
+}
+
+// GitlabResourceReport represents a report of Gitlab resources and non-fatal errors encountered during enumeration.
+type GitlabResourceReport struct {
+	BaseURL   string          `json:"base_url" yaml:"base_url"`
+	Resources GitlabResources `json:"resources" yaml:"resources"`
+
+	reportmeta.Section `yaml:",inline"`
+	nonfatal.ErrorLog  `yaml:",inline"`
+}
@@ -0,0 +1,104 @@
+// Package whoami holds the data structures and logic necessary to introspect the token supplied to gitlabctl, reporting
+// the authenticated user, the token's scopes, the Gitlab instance it belongs to and which commands it can run.
+package whoami
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Method-Security/gitlabctl/internal/nonfatal"
+	"github.com/xanzy/go-gitlab"
+)
+
+// EnumerateIdentity introspects the token the Gitlab client was created with. The authenticated user is required, so
+// failing to fetch it is returned as an error. Failures to retrieve the token details or instance information are
+// recorded as non-fatal errors in the report, and the capability evaluation treats that information as unknown.
+func EnumerateIdentity(ctx context.Context, baseURL string, client *gitlab.Client) (*GitlabResourceReport, error) {
+	report := &GitlabResourceReport{
+		Resources: GitlabResources{},
+		ErrorLog:  nonfatal.NewErrorLog(),
+		BaseURL:   baseURL,
+	}
+
+	user, _, err := client.Users.CurrentUser()
+	if err != nil {
+		return report, err
+	}
+	report.Resources.User = user
+	report.Resources.Admin = user.IsAdmin
+
+	token, err := FetchTokenInfo(ctx, client)
+	if err != nil {
+		report.AddError(nonfatal.New("token", "", err))
+	}
+	report.Resources.Token = token
+
+	instance, err := FetchInstanceInfo(ctx, client)
+	if err != nil {
+		report.AddError(nonfatal.New("instance", "", err))
+	}
+	report.Resources.Instance = instance
+
+	report.Resources.Capabilities = EvaluateCapabilities(token, user.IsAdmin, instance)
+	return report, nil
+}
+
+// FetchTokenInfo retrieves the details of the personal access token the client is authenticated with, using the
+// personal access token self endpoint.
+func FetchTokenInfo(ctx context.Context, client *gitlab.Client) (*TokenInfo, error) {
+	pat, _, err := client.PersonalAccessTokens.GetSinglePersonalAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &TokenInfo{
+		ID:      pat.ID,
+		Name:    pat.Name,
+		Scopes:  pat.Scopes,
+		Active:  pat.Active,
+		Revoked: pat.Revoked,
+	}
+	if pat.ExpiresAt != nil {
+		token.ExpiresAt = pat.ExpiresAt.String()
+	}
+	if pat.LastUsedAt != nil {
+		token.LastUsedAt = pat.LastUsedAt.Format(time.RFC3339)
+	}
+	return token, nil
+}
+
+// FetchInstanceInfo retrieves the version and edition of the Gitlab instance. The metadata endpoint is preferred as it
+// reports the edition directly; instances older than Gitlab 15.2 fall back to the version endpoint, where the edition is
+// derived from the version suffix.
+func FetchInstanceInfo(ctx context.Context, client *gitlab.Client) (*InstanceInfo, error) {
+	metadata, _, err := client.Metadata.GetMetadata(gitlab.WithContext(ctx))
+	if err == nil {
+		return &InstanceInfo{
+			Version:    metadata.Version,
+			Revision:   metadata.Revision,
+			Enterprise: metadata.Enterprise,
+			Edition:    edition(metadata.Enterprise),
+			KASEnabled: metadata.KAS.Enabled,
+		}, nil
+	}
+
+	version, _, err := client.Version.GetVersion(gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	enterprise := strings.HasSuffix(version.Version, "-ee")
+	return &InstanceInfo{
+		Version:    version.Version,
+		Revision:   version.Revision,
+		Enterprise: enterprise,
+		Edition:    edition(enterprise),
+	}, nil
+}
+
+func edition(enterprise bool) string {
+	if enterprise {
+		return "ee"
+	}
+	return "ce"
+}
@@ -16,6 +16,15 @@ func main() {
 	gitlabctl.InitRootCommand()
 	gitlabctl.InitProjectsCmd()
 	gitlabctl.InitVulnerabilityCmd()
+	gitlabctl.InitWhoamiCmd()
+	gitlabctl.InitUsersCmd()
+	gitlabctl.InitGroupsCmd()
+	gitlabctl.InitAuditEventsCmd()
+	gitlabctl.InitRegistryCmd()
+	gitlabctl.InitPackagesCmd()
+	gitlabctl.InitDependenciesCmd()
+	gitlabctl.InitLicensesCmd()
+	gitlabctl.InitConfigCmd()
 
 	if err := gitlabctl.RootCmd.Execute(); err != nil {
 		os.Exit(1)